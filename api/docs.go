@@ -0,0 +1,25 @@
+// Package api
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+
+	"caty/docs"
+)
+
+var swaggerUI = http.StripPrefix("/docs", http.FileServer(swaggerFiles.HTTP))
+
+// Docs 提供内嵌的API文档 /docs/swagger.json 与 Swagger UI /docs/
+func Docs(ctx *gin.Context) {
+	switch ctx.Param("filepath") {
+	case "/swagger.json":
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", docs.Swagger)
+	case "/swagger-initializer.js":
+		ctx.Data(http.StatusOK, "application/javascript; charset=utf-8", docs.SwaggerInitializer)
+	default:
+		swaggerUI.ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
@@ -0,0 +1,54 @@
+// Package api
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/health"
+)
+
+type HealthResponse struct {
+	// 状态
+	// Required: true
+	Status string `json:"status"`
+}
+
+// Healthz godoc
+// swagger:operation GET /healthz 通用 SNullRequest
+// ---
+// summary: 存活检查
+// description: 进程存活即返回200，不检查依赖
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SHealthResponse"
+func Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, &HealthResponse{Status: "ok"})
+}
+
+// Readyz godoc
+// swagger:operation GET /readyz 通用 SNullRequest
+// ---
+// summary: 就绪检查
+// description: 检查mysql、etcd、rabbitmq等依赖，全部可用时返回200，启动中、关闭中或依赖不可用时返回503
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SReadyResponse"
+//   '503':
+//     type: object
+//     "$ref": "#/responses/SReadyResponse"
+func Readyz(ctx *gin.Context) {
+	report := health.Check(ctx.Request.Context())
+	if !report.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
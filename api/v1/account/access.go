@@ -0,0 +1,169 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// RequestAccess godoc
+// swagger:operation POST /v1/access-requests 权限申请 SAccessRequestRequest
+// ---
+// summary: 提交权限申请
+// description: 当前用户附带理由申请指定服务的操作权限及时长，批准后生成限时授权
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccessResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RequestAccess(ctx *gin.Context) {
+	var request account.AccessRequest
+	if err := ctx.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.RequestAccess(ctx.Request.Context(), &request)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ListAccess godoc
+// swagger:operation GET /v1/access-requests 权限申请 SAccessListRequest
+// ---
+// summary: 查询权限申请
+// description: 管理员查询账户内的权限申请，其他用户查询本人提交的以及本人可审批的权限申请
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccessResponses"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func ListAccess(ctx *gin.Context) {
+	request := &account.AccessListRequest{}
+	if err := ctx.BindQuery(request); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.ListAccess(ctx.Request.Context(), request)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RetrieveAccess godoc
+// swagger:operation GET /v1/access-requests/{id} 权限申请 SAccessRetrieveRequest
+// ---
+// summary: 查询指定权限申请
+// description: 申请人、可审批人或管理员查询权限申请
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccessResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RetrieveAccess(ctx *gin.Context) {
+	var access account.Access
+	if err := ctx.BindUri(&access); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.RetrieveAccess(ctx.Request.Context(), &access)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ApproveAccess godoc
+// swagger:operation POST /v1/access-requests/{id}/approve 权限申请 SAccessApproveRequest
+// ---
+// summary: 批准权限申请
+// description: 拥有所申请服务管理员权限的审批人批准他人的权限申请，为申请人创建限时授权
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccessResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func ApproveAccess(ctx *gin.Context) {
+	var access account.Access
+	if err := ctx.BindUri(&access); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var decision account.DecisionRequest
+	if err := ctx.ShouldBindBodyWith(&decision, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.ApproveAccess(ctx.Request.Context(), &access, &decision)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// DenyAccess godoc
+// swagger:operation POST /v1/access-requests/{id}/deny 权限申请 SAccessDenyRequest
+// ---
+// summary: 拒绝权限申请
+// description: 拥有所申请服务管理员权限的审批人拒绝他人的权限申请
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccessResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func DenyAccess(ctx *gin.Context) {
+	var access account.Access
+	if err := ctx.BindUri(&access); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var decision account.DecisionRequest
+	if err := ctx.ShouldBindBodyWith(&decision, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.DenyAccess(ctx.Request.Context(), &access, &decision)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -4,13 +4,16 @@
 package account
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/crochee/lirity/e"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 
+	"caty/pkg/code"
 	"caty/pkg/service/account"
+	"caty/pkg/v"
 )
 
 // Register godoc
@@ -32,18 +35,19 @@ import (
 func Register(ctx *gin.Context) {
 	var registerRequest account.CreateRequest
 	if err := ctx.ShouldBindBodyWith(&registerRequest, binding.JSON); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	if err := account.ValidPassword(registerRequest.Password); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	response, err := account.Create(ctx.Request.Context(), &registerRequest)
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
+	setWarnings(ctx, response.Warnings)
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -64,12 +68,12 @@ func Register(ctx *gin.Context) {
 func List(ctx *gin.Context) {
 	retrieveRequest := &account.RetrievesRequest{}
 	if err := ctx.BindQuery(retrieveRequest); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	response, err := account.List(ctx.Request.Context(), retrieveRequest)
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, response)
@@ -79,7 +83,7 @@ func List(ctx *gin.Context) {
 // swagger:operation PATCH /v1/accounts/{id} 账户 SAccountUpdateRequest
 // ---
 // summary: 编辑账户
-// description: 编辑指定账户的信息
+// description: 编辑指定账户的信息，携带If-Match时校验账户版本，成功后通过ETag返回新版本
 // Consumes:
 // - application/json
 // produces:
@@ -94,26 +98,34 @@ func List(ctx *gin.Context) {
 func Update(ctx *gin.Context) {
 	var user account.User
 	if err := ctx.BindUri(&user); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	var modifyRequest account.UpdateRequest
 	if err := ctx.ShouldBindBodyWith(&modifyRequest, binding.JSON); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	if err := account.ValidPassword(modifyRequest.Password); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	if err := account.ValidPermission(modifyRequest.Permission); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
-	if err := account.Update(ctx.Request.Context(), &user, &modifyRequest); err != nil {
-		e.Error(ctx, err)
+	var precondition account.Precondition
+	if err := ctx.ShouldBindHeader(&precondition); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
+	result, err := account.Update(ctx.Request.Context(), &user, &precondition, &modifyRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	setWarnings(ctx, result.Warnings)
+	ctx.Header("ETag", result.ETag)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -134,14 +146,15 @@ func Update(ctx *gin.Context) {
 func Retrieve(ctx *gin.Context) {
 	var user account.User
 	if err := ctx.BindUri(&user); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	response, err := account.Retrieve(ctx.Request.Context(), &user)
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
+	ctx.Header("ETag", account.ETag(response.Version))
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -149,7 +162,7 @@ func Retrieve(ctx *gin.Context) {
 // swagger:operation DELETE /v1/accounts/{id} 账户 SAccountDeleteRequest
 // ---
 // summary: 删除指定账户
-// description: 删除指定账户信息
+// description: 管理员删除指定账户信息，删除主账号时级联删除其下子账号，可通过purge=true彻底删除，携带If-Match时校验账户版本
 // produces:
 // - application/json
 // responses:
@@ -162,13 +175,90 @@ func Retrieve(ctx *gin.Context) {
 func Delete(ctx *gin.Context) {
 	var user account.User
 	if err := ctx.BindUri(&user); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var deleteRequest account.DeleteRequest
+	if err := ctx.BindQuery(&deleteRequest); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var precondition account.Precondition
+	if err := ctx.ShouldBindHeader(&precondition); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
-	err := account.Delete(ctx.Request.Context(), &user)
+	var err error
+	if deleteRequest.Purge {
+		err = account.Purge(ctx.Request.Context(), &user, &precondition)
+	} else {
+		err = account.Delete(ctx.Request.Context(), &user, &precondition)
+	}
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
 	ctx.Status(http.StatusNoContent)
 }
+
+// Restore godoc
+// swagger:operation POST /v1/accounts/{id}/restore 账户 SAccountRestoreRequest
+// ---
+// summary: 恢复指定账户
+// description: 在恢复期限内恢复已删除的账户，恢复主账号时一并恢复被级联删除的子账号
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Restore(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.Restore(ctx.Request.Context(), &user); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// EffectivePermission godoc
+// swagger:operation GET /v1/accounts/{id}/effective-permissions 账户 SAccountEffectivePermissionRequest
+// ---
+// summary: 查询账户有效权限
+// description: 查询账户自身与所属用户组合并后的权限及其来源
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountEffectivePermissionResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func EffectivePermission(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.EffectivePermission(ctx.Request.Context(), &user)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// setWarnings 通过Warning响应头返回告警信息
+func setWarnings(ctx *gin.Context, warnings []string) {
+	for _, warning := range warnings {
+		ctx.Writer.Header().Add("Warning", fmt.Sprintf("299 %s %q", v.ServiceName, warning))
+	}
+}
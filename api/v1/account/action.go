@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 
+	"caty/pkg/code"
 	"caty/pkg/service/account"
 )
 
@@ -17,7 +18,7 @@ import (
 // swagger:operation POST /v1/accounts/login 账户 SAccountLoginRequest
 // ---
 // summary: 用户登录
-// description: 用户登录获取token信息
+// description: 用户登录获取token信息，支持用户ID、账户名与用户名或已认证的邮箱登录
 // Consumes:
 // - application/json
 // produces:
@@ -32,17 +33,66 @@ import (
 func Login(ctx *gin.Context) {
 	var request account.LoginRequest
 	if err := ctx.ShouldBindBodyWith(&request, binding.JSON); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	if err := account.ValidPassword(request.Password); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	response, err := account.Login(ctx.Request.Context(), &request)
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, response)
 }
+
+// Logout godoc
+// swagger:operation POST /v1/accounts/logout 账户 SAccountLogoutRequest
+// ---
+// summary: 用户登出
+// description: 结束当前token的登录会话，登出后token失效
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Logout(ctx *gin.Context) {
+	if err := account.Logout(ctx.Request.Context()); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RevokeSessions godoc
+// swagger:operation DELETE /v1/accounts/{id}/sessions 账户 SAccountRevokeSessionsRequest
+// ---
+// summary: 结束账户的全部登录会话
+// description: 本人或管理员结束账户的全部登录会话，已登录签发的token全部失效
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RevokeSessions(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.RevokeSessions(ctx.Request.Context(), &user); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
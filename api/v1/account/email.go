@@ -0,0 +1,39 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// ConfirmEmail godoc
+// swagger:operation POST /v1/accounts/{id}/confirm-email 账户 SAccountConfirmEmailRequest
+// ---
+// summary: 确认账户邮箱已认证
+// description: 管理员确认账户当前的邮箱已认证，认证后可使用邮箱登录，修改邮箱后须重新认证
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func ConfirmEmail(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.ConfirmEmail(ctx.Request.Context(), &user); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
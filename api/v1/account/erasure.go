@@ -0,0 +1,96 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// RequestErasure godoc
+// swagger:operation POST /v1/accounts/{id}/erasures 账户 SAccountRequestErasureRequest
+// ---
+// summary: 申请擦除用户数据
+// description: 本人或管理员申请擦除用户的个人数据，主账号须先转让
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountErasureResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RequestErasure(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.RequestErasure(ctx.Request.Context(), &user)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RetrieveErasure godoc
+// swagger:operation GET /v1/erasures/{id} 账户 SAccountRetrieveErasureRequest
+// ---
+// summary: 查询数据擦除请求
+// description: 被擦除用户本人或管理员查询数据擦除请求，完成后包含完成报告
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountErasureResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RetrieveErasure(ctx *gin.Context) {
+	var erasure account.Erasure
+	if err := ctx.BindUri(&erasure); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.RetrieveErasure(ctx.Request.Context(), &erasure)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CompleteErasure godoc
+// swagger:operation POST /v1/erasures/{id}/complete 账户 SAccountCompleteErasureRequest
+// ---
+// summary: 执行数据擦除
+// description: 管理员执行数据擦除，匿名化用户的用户名、邮箱、描述与属性，保留用户记录与审计事件并返回完成报告
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountErasureResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func CompleteErasure(ctx *gin.Context) {
+	var erasure account.Erasure
+	if err := ctx.BindUri(&erasure); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.CompleteErasure(ctx.Request.Context(), &erasure)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,43 @@
+// Package account
+package account
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// Export godoc
+// swagger:operation GET /v1/accounts/{id}/data-export 账户 SAccountExportRequest
+// ---
+// summary: 导出用户数据
+// description: 本人或管理员导出用户的个人数据，zip文件中包含资料、权限、登录会话、审计事件与变更历史的JSON与CSV
+// produces:
+// - application/zip
+// responses:
+//   '200':
+//     description: zip文件
+//     schema:
+//       type: file
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Export(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	result, err := account.Export(ctx.Request.Context(), &user)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", result.FileName))
+	ctx.Data(http.StatusOK, "application/zip", result.Data)
+}
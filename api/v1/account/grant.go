@@ -0,0 +1,107 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// CreateGrant godoc
+// swagger:operation POST /v1/accounts/{id}/grants 账户 SAccountCreateGrantRequest
+// ---
+// summary: 创建限时授权
+// description: 管理员为用户授予仅在not_before与not_after之间生效的权限，登录签发的token不晚于最早失效的限时授权过期
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountGrantResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func CreateGrant(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var grantRequest account.GrantRequest
+	if err := ctx.ShouldBindBodyWith(&grantRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.ValidPermission(grantRequest.Permission); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.CreateGrant(ctx.Request.Context(), &user, &grantRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Grants godoc
+// swagger:operation GET /v1/accounts/{id}/grants 账户 SAccountGrantsRequest
+// ---
+// summary: 查询限时授权
+// description: 本人或管理员查询用户尚未过期清理的限时授权
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountGrantResponses"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Grants(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.Grants(ctx.Request.Context(), &user)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RevokeGrant godoc
+// swagger:operation DELETE /v1/grants/{id} 账户 SAccountRevokeGrantRequest
+// ---
+// summary: 撤销限时授权
+// description: 管理员提前撤销限时授权，已签发的token在过期前仍然有效
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RevokeGrant(ctx *gin.Context) {
+	var grant account.Grant
+	if err := ctx.BindUri(&grant); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.RevokeGrant(ctx.Request.Context(), &grant); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
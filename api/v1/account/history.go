@@ -0,0 +1,79 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// History godoc
+// swagger:operation GET /v1/accounts/{id}/history 账户 SAccountHistoryRequest
+// ---
+// summary: 查询账户变更历史
+// description: 按版本倒序查询用户或其所属账户的字段变更历史，密码只记录为已变更
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountHistoryResponses"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func History(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	historyRequest := &account.HistoryRequest{}
+	if err := ctx.BindQuery(historyRequest); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.History(ctx.Request.Context(), &user, historyRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Revert godoc
+// swagger:operation POST /v1/accounts/{id}/history/{version}/revert 账户 SAccountRevertRequest
+// ---
+// summary: 回滚账户到历史版本
+// description: 将用户的名称、邮箱、权限、描述与属性回滚到指定历史版本，成功后通过ETag返回新版本
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Revert(ctx *gin.Context) {
+	var historyVersion account.HistoryVersion
+	if err := ctx.BindUri(&historyVersion); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var precondition account.Precondition
+	if err := ctx.ShouldBindHeader(&precondition); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	result, err := account.Revert(ctx.Request.Context(), &historyVersion, &precondition)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Header("ETag", result.ETag)
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// PutLabels godoc
+// swagger:operation PUT /v1/accounts/{id}/labels 账户 SAccountPutLabelsRequest
+// ---
+// summary: 设置用户标签
+// description: 管理员以键值对覆盖指定用户的全部标签，携带If-Match时校验账户版本，成功后通过ETag返回新版本
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountLabelResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func PutLabels(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var request account.LabelRequest
+	if err := ctx.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var precondition account.Precondition
+	if err := ctx.ShouldBindHeader(&precondition); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.PutLabels(ctx.Request.Context(), &user, &precondition, &request)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Header("ETag", response.ETag)
+	ctx.JSON(http.StatusOK, response)
+}
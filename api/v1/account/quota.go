@@ -0,0 +1,76 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// Quotas godoc
+// swagger:operation GET /v1/accounts/{id}/quotas 账户 SAccountQuotasRequest
+// ---
+// summary: 查询账户配额
+// description: 本人或管理员查询用户所属账户各资源的配额上限与用量
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountQuotaResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Quotas(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.Quotas(ctx.Request.Context(), &user)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// PutQuotas godoc
+// swagger:operation PUT /v1/accounts/{id}/quotas 账户 SAccountPutQuotasRequest
+// ---
+// summary: 设置账户配额
+// description: 全局管理员单独设置用户所属账户的配额上限，未设置的资源使用配置的默认上限
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountQuotaResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func PutQuotas(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var request account.QuotaRequest
+	if err := ctx.ShouldBindBodyWith(&request, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.PutQuotas(ctx.Request.Context(), &user, &request)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
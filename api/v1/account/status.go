@@ -0,0 +1,81 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// Suspend godoc
+// swagger:operation POST /v1/accounts/{id}/suspend 账户 SAccountSuspendRequest
+// ---
+// summary: 暂停指定账户
+// description: 暂停指定账户，暂停后无法登录且已签发的token失效
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Suspend(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var statusRequest account.StatusRequest
+	if err := ctx.ShouldBindBodyWith(&statusRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.Suspend(ctx.Request.Context(), &user, &statusRequest); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Reactivate godoc
+// swagger:operation POST /v1/accounts/{id}/reactivate 账户 SAccountReactivateRequest
+// ---
+// summary: 重新激活指定账户
+// description: 重新激活已暂停、锁定或禁用的账户
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Reactivate(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var statusRequest account.StatusRequest
+	if err := ctx.ShouldBindBodyWith(&statusRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := account.Reactivate(ctx.Request.Context(), &user, &statusRequest); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
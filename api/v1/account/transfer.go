@@ -0,0 +1,132 @@
+// Package account
+package account
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/account"
+)
+
+// InitiateTransfer godoc
+// swagger:operation POST /v1/accounts/{id}/transfers 账户 SAccountInitiateTransferRequest
+// ---
+// summary: 发起主账号转让
+// description: 主账号本人或管理员发起主账号转让，目标用户接受后交换主账号身份与权限
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountTransferResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func InitiateTransfer(ctx *gin.Context) {
+	var user account.User
+	if err := ctx.BindUri(&user); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var transferRequest account.TransferRequest
+	if err := ctx.ShouldBindBodyWith(&transferRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.InitiateTransfer(ctx.Request.Context(), &user, &transferRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RetrieveTransfer godoc
+// swagger:operation GET /v1/transfers/{id} 账户 SAccountRetrieveTransferRequest
+// ---
+// summary: 查询主账号转让
+// description: 转让双方或管理员查询主账号转让
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountTransferResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RetrieveTransfer(ctx *gin.Context) {
+	var transfer account.Transfer
+	if err := ctx.BindUri(&transfer); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.RetrieveTransfer(ctx.Request.Context(), &transfer)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// AcceptTransfer godoc
+// swagger:operation POST /v1/transfers/{id}/accept 账户 SAccountAcceptTransferRequest
+// ---
+// summary: 接受主账号转让
+// description: 目标用户接受主账号转让，主账号身份与权限在同一事务中交换
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountTransferResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func AcceptTransfer(ctx *gin.Context) {
+	var transfer account.Transfer
+	if err := ctx.BindUri(&transfer); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.AcceptTransfer(ctx.Request.Context(), &transfer)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CancelTransfer godoc
+// swagger:operation POST /v1/transfers/{id}/cancel 账户 SAccountCancelTransferRequest
+// ---
+// summary: 取消主账号转让
+// description: 转让双方或管理员取消待接受的主账号转让
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SAccountTransferResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func CancelTransfer(ctx *gin.Context) {
+	var transfer account.Transfer
+	if err := ctx.BindUri(&transfer); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := account.CancelTransfer(ctx.Request.Context(), &transfer)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
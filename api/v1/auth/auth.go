@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 
+	"caty/pkg/code"
 	"caty/pkg/service/auth"
 )
 
@@ -17,7 +18,7 @@ import (
 // swagger:operation POST /v1/auths/sign 鉴权 SAuthSignRequest
 // ---
 // summary: 生成token
-// description: 生成token信息
+// description: 生成token信息，仅全局管理员可用
 // Consumes:
 // - application/json
 // produces:
@@ -32,12 +33,12 @@ import (
 func Sign(ctx *gin.Context) {
 	var request auth.TokenClaims
 	if err := ctx.ShouldBindBodyWith(&request, binding.JSON); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
-	token, err := auth.Create(ctx.Request.Context(), &request)
+	token, err := auth.Sign(ctx.Request.Context(), &request)
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, token)
@@ -62,12 +63,12 @@ func Sign(ctx *gin.Context) {
 func Parse(ctx *gin.Context) {
 	var apiToken auth.APIToken
 	if err := ctx.ShouldBindBodyWith(&apiToken, binding.JSON); err != nil {
-		e.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
 		return
 	}
 	token, err := auth.Parse(ctx.Request.Context(), &apiToken)
 	if err != nil {
-		e.Error(ctx, err)
+		code.Error(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, token)
@@ -0,0 +1,171 @@
+// Package group
+package group
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/group"
+)
+
+// Create godoc
+// swagger:operation POST /v1/groups 用户组 SGroupCreateRequest
+// ---
+// summary: 创建用户组
+// description: 在指定账户下创建用户组
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SGroupResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Create(ctx *gin.Context) {
+	var createRequest group.CreateRequest
+	if err := ctx.ShouldBindBodyWith(&createRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if _, err := auth.ParsePermission(createRequest.Permission); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := group.Create(ctx.Request.Context(), &createRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// List godoc
+// swagger:operation GET /v1/groups 用户组 SGroupRetrievesRequest
+// ---
+// summary: 查询用户组
+// description: 根据条件查询用户组列表
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SGroupResponses"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func List(ctx *gin.Context) {
+	retrieveRequest := &group.RetrievesRequest{}
+	if err := ctx.BindQuery(retrieveRequest); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := group.List(ctx.Request.Context(), retrieveRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Update godoc
+// swagger:operation PATCH /v1/groups/{id} 用户组 SGroupUpdateRequest
+// ---
+// summary: 编辑用户组
+// description: 编辑指定用户组的信息
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Update(ctx *gin.Context) {
+	var g group.Group
+	if err := ctx.BindUri(&g); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var updateRequest group.UpdateRequest
+	if err := ctx.ShouldBindBodyWith(&updateRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if updateRequest.Permission != "" {
+		if _, err := auth.ParsePermission(updateRequest.Permission); err != nil {
+			code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+			return
+		}
+	}
+	if err := group.Update(ctx.Request.Context(), &g, &updateRequest); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Retrieve godoc
+// swagger:operation GET /v1/groups/{id} 用户组 SGroupRetrieveRequest
+// ---
+// summary: 查询指定用户组
+// description: 查询指定用户组的信息
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SGroupResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Retrieve(ctx *gin.Context) {
+	var g group.Group
+	if err := ctx.BindUri(&g); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := group.Retrieve(ctx.Request.Context(), &g)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Delete godoc
+// swagger:operation DELETE /v1/groups/{id} 用户组 SGroupDeleteRequest
+// ---
+// summary: 删除指定用户组
+// description: 删除指定用户组及其成员关系
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Delete(ctx *gin.Context) {
+	var g group.Group
+	if err := ctx.BindUri(&g); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := group.Delete(ctx.Request.Context(), &g); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
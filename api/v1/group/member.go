@@ -0,0 +1,94 @@
+// Package group
+package group
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/group"
+)
+
+// ListUsers godoc
+// swagger:operation GET /v1/groups/{id}/users 用户组 SGroupListUsersRequest
+// ---
+// summary: 查询用户组成员
+// description: 查询指定用户组的成员列表
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SGroupMemberResponses"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func ListUsers(ctx *gin.Context) {
+	var g group.Group
+	if err := ctx.BindUri(&g); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := group.ListUsers(ctx.Request.Context(), &g)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// AddUser godoc
+// swagger:operation PUT /v1/groups/{id}/users/{user_id} 用户组 SGroupAddUserRequest
+// ---
+// summary: 添加用户组成员
+// description: 将同一账户下的用户加入用户组
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func AddUser(ctx *gin.Context) {
+	var member group.Member
+	if err := ctx.BindUri(&member); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := group.AddUser(ctx.Request.Context(), &member); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveUser godoc
+// swagger:operation DELETE /v1/groups/{id}/users/{user_id} 用户组 SGroupRemoveUserRequest
+// ---
+// summary: 移除用户组成员
+// description: 将用户从用户组中移除
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func RemoveUser(ctx *gin.Context) {
+	var member group.Member
+	if err := ctx.BindUri(&member); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := group.RemoveUser(ctx.Request.Context(), &member); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,171 @@
+// Package label
+package label
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/label"
+)
+
+// Create godoc
+// swagger:operation POST /v1/label-bindings 标签授权 SLabelBindingCreateRequest
+// ---
+// summary: 创建标签授权
+// description: 在指定账户下创建标签授权，账户内标签匹配选择器的用户自动获得其权限
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SLabelBindingResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Create(ctx *gin.Context) {
+	var createRequest label.CreateRequest
+	if err := ctx.ShouldBindBodyWith(&createRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if _, err := auth.ParsePermission(createRequest.Permission); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := label.Create(ctx.Request.Context(), &createRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// List godoc
+// swagger:operation GET /v1/label-bindings 标签授权 SLabelBindingRetrievesRequest
+// ---
+// summary: 查询标签授权
+// description: 根据条件查询标签授权列表
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SLabelBindingResponses"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func List(ctx *gin.Context) {
+	retrieveRequest := &label.RetrievesRequest{}
+	if err := ctx.BindQuery(retrieveRequest); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := label.List(ctx.Request.Context(), retrieveRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Update godoc
+// swagger:operation PATCH /v1/label-bindings/{id} 标签授权 SLabelBindingUpdateRequest
+// ---
+// summary: 编辑标签授权
+// description: 编辑指定标签授权的选择器、权限等信息
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Update(ctx *gin.Context) {
+	var b label.Binding
+	if err := ctx.BindUri(&b); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var updateRequest label.UpdateRequest
+	if err := ctx.ShouldBindBodyWith(&updateRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if updateRequest.Permission != "" {
+		if _, err := auth.ParsePermission(updateRequest.Permission); err != nil {
+			code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+			return
+		}
+	}
+	if err := label.Update(ctx.Request.Context(), &b, &updateRequest); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Retrieve godoc
+// swagger:operation GET /v1/label-bindings/{id} 标签授权 SLabelBindingRetrieveRequest
+// ---
+// summary: 查询指定标签授权
+// description: 查询指定标签授权的信息
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SLabelBindingResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Retrieve(ctx *gin.Context) {
+	var b label.Binding
+	if err := ctx.BindUri(&b); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := label.Retrieve(ctx.Request.Context(), &b)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Delete godoc
+// swagger:operation DELETE /v1/label-bindings/{id} 标签授权 SLabelBindingDeleteRequest
+// ---
+// summary: 删除指定标签授权
+// description: 删除指定标签授权，匹配的用户在重新登录后不再获得其权限
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Delete(ctx *gin.Context) {
+	var b label.Binding
+	if err := ctx.BindUri(&b); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := label.Delete(ctx.Request.Context(), &b); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
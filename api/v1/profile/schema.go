@@ -0,0 +1,103 @@
+// Package profile
+package profile
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/code"
+	"caty/pkg/service/profile"
+)
+
+// Put godoc
+// swagger:operation PUT /v1/profile-schemas/{id} 用户属性 SProfileSchemaPutRequest
+// ---
+// summary: 设置用户属性模式
+// description: 设置账户下用户属性的JSON Schema，注册和编辑用户时按此校验
+// Consumes:
+// - application/json
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SProfileSchemaResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Put(ctx *gin.Context) {
+	var account profile.Account
+	if err := ctx.BindUri(&account); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	var schemaRequest profile.SchemaRequest
+	if err := ctx.ShouldBindBodyWith(&schemaRequest, binding.JSON); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := profile.Put(ctx.Request.Context(), &account, &schemaRequest)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Retrieve godoc
+// swagger:operation GET /v1/profile-schemas/{id} 用户属性 SProfileSchemaRetrieveRequest
+// ---
+// summary: 查询用户属性模式
+// description: 查询账户下用户属性的JSON Schema
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     type: object
+//     "$ref": "#/responses/SProfileSchemaResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Retrieve(ctx *gin.Context) {
+	var account profile.Account
+	if err := ctx.BindUri(&account); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	response, err := profile.Retrieve(ctx.Request.Context(), &account)
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Delete godoc
+// swagger:operation DELETE /v1/profile-schemas/{id} 用户属性 SProfileSchemaDeleteRequest
+// ---
+// summary: 删除用户属性模式
+// description: 删除账户下用户属性的JSON Schema，删除后不再校验用户属性
+// produces:
+// - application/json
+// responses:
+//   '204':
+//     type: object
+//     "$ref": "#/responses/SNullResponse"
+//   default:
+//     type: object
+//     "$ref": "#/responses/SResponseCode"
+func Delete(ctx *gin.Context) {
+	var account profile.Account
+	if err := ctx.BindUri(&account); err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	if err := profile.Delete(ctx.Request.Context(), &account); err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -36,7 +36,7 @@ type VersionResult struct {
 }
 
 // Version godoc
-// swagger:operation GET / 通用 SNullRequest
+// swagger:operation GET /version 通用 SNullRequest
 // ---
 // summary: 查询api版本信息
 // description: 查询api版本详细信息
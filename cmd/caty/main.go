@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/crochee/lirity/logger"
 	"github.com/crochee/lirity/routine"
@@ -20,8 +21,16 @@ import (
 
 	"caty/config"
 	"caty/pkg/code"
+	"caty/pkg/cron"
 	"caty/pkg/dbx"
+	"caty/pkg/health"
 	"caty/pkg/message"
+	"caty/pkg/service/account"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/idempotency"
+	"caty/pkg/service/ratelimit"
+	"caty/pkg/tracex"
+	"caty/pkg/transport/grpcx"
 	"caty/pkg/transport/httpx"
 	"caty/pkg/v"
 	"caty/pkg/validator"
@@ -35,6 +44,8 @@ func main() {
 	if err := config.LoadConfig(*configFile); err != nil {
 		log.Fatal(err)
 	}
+	// 监听配置文件变更，目前只有跨域策略支持热加载，其他配置修改后须重启
+	config.Watch()
 	if err := code.Loading(); err != nil {
 		log.Fatal(err)
 	}
@@ -53,19 +64,38 @@ func main() {
 
 func run() error {
 	ctx := context.Background()
-	g := routine.NewGroup(ctx)
-	srv, err := httpx.NewServer(ctx)
+	// 初始化追踪
+	shutdownTrace, err := tracex.Init(ctx)
 	if err != nil {
 		return err
 	}
-	zap.S().Debugf("listen on %s", srv.Server.Addr)
+	defer func() {
+		if err := shutdownTrace(ctx); err != nil {
+			zap.S().Errorf("shutdown trace failed.Error:%+v", err)
+		}
+	}()
+	g := routine.NewGroup(ctx)
+	var srv *httpx.HTTPServer
+	if srv, err = httpx.NewServer(ctx); err != nil {
+		return err
+	}
+	zap.S().Debugf("listen on %v", srv.Listen)
+	var grpcSrv *grpcx.GRPCServer
+	if grpcx.Enabled() {
+		if grpcSrv, err = grpcx.NewServer(); err != nil {
+			return err
+		}
+		zap.S().Debugf("grpc listen on %v", grpcSrv.Listen)
+	}
 	// 服务启动流程
 	g.Go(func(ctx context.Context) error {
-		return startAction(ctx, srv)
+		return startAction(ctx, g, srv, grpcSrv)
 	})
+	// 独立的管理端口
+	g.Go(srv.StartAdmin)
 	// 服务关闭流程
 	g.Go(func(ctx context.Context) error {
-		return shutdownAction(ctx, srv)
+		return shutdownAction(ctx, srv, grpcSrv)
 	})
 	// 启动mq
 	g.Go(message.Setup)
@@ -75,26 +105,88 @@ func run() error {
 	return nil
 }
 
-func startAction(ctx context.Context, srv *httpx.HTTPServer) error {
+func startAction(ctx context.Context, g *routine.ErrGroup, srv *httpx.HTTPServer, grpcSrv *grpcx.GRPCServer) error {
 	// 初始化数据库
 	if err := dbx.Init(ctx); err != nil {
 		return err
 	}
+	health.Register("mysql", health.CheckerFunc(dbx.Ping))
+	if viper.GetBool("rabbitmq.enable") {
+		health.Register("rabbitmq", health.CheckerFunc(message.Ping))
+	}
 	if err := validator.Init(); err != nil {
 		return err
 	}
+	// 未配置签名密钥时无法签发与校验token
+	if _, err := auth.SecretKey(); err != nil {
+		return err
+	}
+	if err := cronAction(ctx); err != nil {
+		return err
+	}
 	zap.S().Infof("%s run on %s", v.ServiceName, gin.Mode())
+	health.SetReady()
+	if grpcSrv != nil {
+		g.Go(grpcSrv.Start)
+	}
 	return srv.Start(ctx)
 }
 
-func shutdownAction(ctx context.Context, srv *httpx.HTTPServer) error {
+func shutdownAction(ctx context.Context, srv *httpx.HTTPServer, grpcSrv *grpcx.GRPCServer) error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	select {
 	case <-ctx.Done():
+		health.SetShuttingDown()
 	case <-quit:
+		// 先标记为未就绪，等待编排系统摘除流量后再停止服务
+		health.SetShuttingDown()
+		time.Sleep(health.DrainDelay())
 	}
 	message.Close()
+	cron.Close()
 	zap.L().Info("shutting down server...")
+	if grpcSrv != nil {
+		if err := grpcSrv.Stop(ctx); err != nil {
+			zap.S().Errorf("shutdown grpc server failed.Error:%+v", err)
+		}
+	}
 	return srv.Stop(ctx)
 }
+
+func cronAction(ctx context.Context) error {
+	cron.Setup()
+	// 清理超过保留期限的软删除账户
+	if _, err := cron.Cron().AddFunc(account.PurgeSpec(), func() {
+		if err := account.PurgeExpired(ctx); err != nil {
+			zap.S().Errorf("purge expired account failed.Error:%+v", err)
+		}
+	}); err != nil {
+		return err
+	}
+	// 清理过期的限时授权并记录过期事件
+	if _, err := cron.Cron().AddFunc(account.GrantPurgeSpec(), func() {
+		if err := account.PurgeExpiredGrants(ctx); err != nil {
+			zap.S().Errorf("purge expired grant failed.Error:%+v", err)
+		}
+	}); err != nil {
+		return err
+	}
+	// 清理数据库中已回满的限流令牌桶
+	if ratelimit.Enabled() && ratelimit.StoreName() == ratelimit.StoreDB {
+		if _, err := cron.Cron().AddFunc(ratelimit.PurgeSpec(), func() {
+			if err := ratelimit.PurgeExpired(ctx); err != nil {
+				zap.S().Errorf("purge expired rate limit failed.Error:%+v", err)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	// 清理过期的幂等记录
+	_, err := cron.Cron().AddFunc(idempotency.PurgeSpec(), func() {
+		if err := idempotency.PurgeExpired(ctx); err != nil {
+			zap.S().Errorf("purge expired idempotency failed.Error:%+v", err)
+		}
+	})
+	return err
+}
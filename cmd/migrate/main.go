@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"caty/pkg/service/auth"
 )
 
 func main() {
@@ -52,6 +55,7 @@ func NewCmd() (*cobra.Command, error) {
 	rootCmd.AddCommand(command.NewCompletion())
 	rootCmd.AddCommand(up())
 	rootCmd.AddCommand(down())
+	rootCmd.AddCommand(grantAdmin())
 
 	return rootCmd, nil
 }
@@ -92,12 +96,56 @@ func down() *cobra.Command {
 	}
 }
 
+// grantAdmin 授予用户全局 * 的管理员权限，用于初始化或恢复全局管理员，用户重新登录后生效
+func grantAdmin() *cobra.Command {
+	return &cobra.Command{
+		Use:   "grant-admin <user-id>",
+		Short: "grant global admin permission to a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := open()
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			result, err := c.ExecContext(cmd.Context(), "UPDATE `user` "+
+				"SET `permission` = JSON_SET(`permission`, '$.\"*\"', ?), `version` = `version` + 1 "+
+				"WHERE `id` = ? AND `deleted` = 0", auth.Admin, args[0])
+			if err != nil {
+				return err
+			}
+			var affected int64
+			if affected, err = result.RowsAffected(); err != nil {
+				return err
+			}
+			if affected == 0 {
+				return fmt.Errorf("user %s not found", args[0])
+			}
+			return nil
+		},
+	}
+}
+
 func migrateOperate(cmd *cobra.Command, _ []string) (*migrate.Migrate, error) {
 	flags := cmd.Flags()
 	sourceURL, err := flags.GetString("path")
 	if err != nil {
 		return nil, err
 	}
+	c, err := open()
+	if err != nil {
+		return nil, err
+	}
+	var driver database.Driver
+	if driver, err = mysql.WithInstance(c, &mysql.Config{}); err != nil {
+		return nil, err
+	}
+	return migrate.NewWithDatabaseInstance(
+		"file://"+sourceURL,
+		"mysql", driver)
+}
+
+func open() (*sql.DB, error) {
 	o := &db.Option{
 		Debug:           viper.GetString("GIN_MODE") == "debug",
 		MaxOpenConn:     viper.GetInt("mysql.max_open_conns"),
@@ -110,16 +158,5 @@ func migrateOperate(cmd *cobra.Command, _ []string) (*migrate.Migrate, error) {
 		Charset:         viper.GetString("mysql.charset"),
 		ConnMaxLifetime: viper.GetDuration("mysql.conn_max_lifetime") * time.Second,
 	}
-
-	var c *sql.DB
-	if c, err = sql.Open("mysql", db.Dsn(o)); err != nil {
-		return nil, err
-	}
-	var driver database.Driver
-	if driver, err = mysql.WithInstance(c, &mysql.Config{}); err != nil {
-		return nil, err
-	}
-	return migrate.NewWithDatabaseInstance(
-		"file://"+sourceURL,
-		"mysql", driver)
+	return sql.Open("mysql", db.Dsn(o))
 }
@@ -2,8 +2,11 @@ package config
 
 import (
 	"path/filepath"
+	"sync"
 
 	"github.com/crochee/lirity/config"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
 )
 
 // LoadConfig init Config
@@ -16,3 +19,40 @@ func LoadConfig(path string) error {
 		config.WithConfigFile(absPath),
 	)
 }
+
+var (
+	watchOnce sync.Once
+	mutex     sync.RWMutex
+	handlers  []func(*viper.Viper)
+)
+
+// OnChange 注册配置文件变更后的回调，回调按注册顺序执行，参数为重新读取的配置文件，只能在回调中读取
+func OnChange(handler func(*viper.Viper)) {
+	mutex.Lock()
+	handlers = append(handlers, handler)
+	mutex.Unlock()
+}
+
+// Watch 监听配置文件变更，变更后重新读取配置文件并执行 OnChange 注册的回调。
+// viper不是并发安全的，全局配置在启动后保持不变，只有注册了回调的配置支持热加载
+func Watch() {
+	watchOnce.Do(func() {
+		watcher := viper.New()
+		watcher.SetConfigFile(viper.ConfigFileUsed())
+		watcher.OnConfigChange(func(fsnotify.Event) {
+			Notify(watcher)
+		})
+		watcher.WatchConfig()
+	})
+}
+
+// Notify 以配置 v 执行 OnChange 注册的回调
+func Notify(v *viper.Viper) {
+	mutex.RLock()
+	list := make([]func(*viper.Viper), len(handlers))
+	copy(list, handlers)
+	mutex.RUnlock()
+	for _, handler := range list {
+		handler(v)
+	}
+}
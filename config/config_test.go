@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestWatch(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	path := filepath.Join(t.TempDir(), "caty.yaml")
+	if err := os.WriteFile(path, []byte("cors:\n  allowed_origins: [https://a.example.com]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan []string, 1)
+	OnChange(func(v *viper.Viper) {
+		select {
+		case changed <- v.GetStringSlice("cors.allowed_origins"):
+		default:
+		}
+	})
+	Watch()
+	if err := os.WriteFile(path, []byte("cors:\n  allowed_origins: [https://b.example.com]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case origins := <-changed:
+		if len(origins) != 1 || origins[0] != "https://b.example.com" {
+			t.Fatalf("unexpected origins %v", origins)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change not notified")
+	}
+	// 全局配置不随文件变更
+	if origins := viper.GetStringSlice("cors.allowed_origins"); len(origins) != 1 ||
+		origins[0] != "https://a.example.com" {
+		t.Fatalf("unexpected global origins %v", origins)
+	}
+}
@@ -0,0 +1,16 @@
+// Package docs 内嵌 make swag 生成的OpenAPI文档
+package docs
+
+import (
+	_ "embed"
+)
+
+// Swagger swagger 2.0 格式的API文档
+//
+//go:embed swagger.json
+var Swagger []byte
+
+// SwaggerInitializer Swagger UI 的初始化脚本，加载同目录下的 swagger.json
+//
+//go:embed swagger-initializer.js
+var SwaggerInitializer []byte
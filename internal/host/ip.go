@@ -72,3 +72,15 @@ func GetIPByName(name string) (net.IP, error) {
 	}
 	return nil, fmt.Errorf("can't find %s ip", name)
 }
+
+// IP 获取网卡 name 的IP，获取失败时使用对外的IP
+func IP(name string) (string, error) {
+	ip, err := GetIPByName(name)
+	if err == nil {
+		return ip.String(), nil
+	}
+	if ip, err = ExternalIP(); err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
@@ -6,6 +6,9 @@ package swag
 import (
 	"caty/pkg/service/account"
 	"caty/pkg/service/auth"
+	"caty/pkg/service/group"
+	"caty/pkg/service/label"
+	"caty/pkg/service/profile"
 )
 
 // swagger:parameters SNullRequest
@@ -20,6 +23,20 @@ type SAccountRegisterRequest struct {
 	}
 }
 
+// swagger:parameters SAccountRegisterRequest SAccountRestoreRequest SGroupCreateRequest
+type SIdempotencyKey struct {
+	// 幂等键，保留期限内相同幂等键的请求返回首次的响应
+	// in: header
+	IdempotencyKey string `json:"Idempotency-Key"`
+}
+
+// swagger:parameters SAccountRetrievesRequest SAccountUpdateRequest SAccountRetrieveRequest SAccountDeleteRequest SAccountRestoreRequest SAccountSuspendRequest SAccountReactivateRequest SAccountConfirmEmailRequest SAccountHistoryRequest SAccountRevertRequest SAccountEffectivePermissionRequest SAccountExportRequest SAccountRevokeSessionsRequest SAccountRequestErasureRequest SAccountRetrieveErasureRequest SAccountCompleteErasureRequest SAccountQuotasRequest SAccountPutLabelsRequest SAccountCreateGrantRequest SAccountGrantsRequest SAccountRevokeGrantRequest SAccessListRequest SAccessRetrieveRequest SAccessApproveRequest SAccessDenyRequest
+type SAllTenants struct {
+	// 跨账户访问，仅全局管理员可用
+	// in: query
+	AllTenants bool `json:"all_tenants"`
+}
+
 // swagger:parameters SAccountRetrievesRequest
 type SAccountRetrievesRequest struct {
 	account.RetrievesRequest
@@ -32,6 +49,7 @@ type SAccountUpdateRequest struct {
 		account.UpdateRequest
 	}
 	account.User
+	account.Precondition
 }
 
 // swagger:parameters SAccountRetrieveRequest
@@ -42,6 +60,128 @@ type SAccountRetrieveRequest struct {
 // swagger:parameters SAccountDeleteRequest
 type SAccountDeleteRequest struct {
 	account.User
+	account.DeleteRequest
+	account.Precondition
+}
+
+// swagger:parameters SAccountRestoreRequest SAccountConfirmEmailRequest
+type SAccountRestoreRequest struct {
+	account.User
+}
+
+// swagger:parameters SAccountSuspendRequest SAccountReactivateRequest
+type SAccountStatusRequest struct {
+	// in: body
+	Body struct {
+		account.StatusRequest
+	}
+	account.User
+}
+
+// swagger:parameters SAccountHistoryRequest
+type SAccountHistoryRequest struct {
+	account.User
+	account.HistoryRequest
+}
+
+// swagger:parameters SAccountRevertRequest
+type SAccountRevertRequest struct {
+	account.HistoryVersion
+	account.Precondition
+}
+
+// swagger:parameters SAccountInitiateTransferRequest
+type SAccountInitiateTransferRequest struct {
+	// in: body
+	Body struct {
+		account.TransferRequest
+	}
+	account.User
+}
+
+// swagger:parameters SAccountRetrieveTransferRequest SAccountAcceptTransferRequest SAccountCancelTransferRequest
+type SAccountTransferRequest struct {
+	account.Transfer
+}
+
+// swagger:parameters SAccountExportRequest SAccountRequestErasureRequest SAccountRevokeSessionsRequest
+type SAccountErasureUserRequest struct {
+	account.User
+}
+
+// swagger:parameters SAccountQuotasRequest
+type SAccountQuotasRequest struct {
+	account.User
+}
+
+// swagger:parameters SAccountPutQuotasRequest
+type SAccountPutQuotasRequest struct {
+	// in: body
+	Body struct {
+		account.QuotaRequest
+	}
+	account.User
+}
+
+// swagger:parameters SAccountPutLabelsRequest
+type SAccountPutLabelsRequest struct {
+	// in: body
+	Body struct {
+		account.LabelRequest
+	}
+	account.User
+	account.Precondition
+}
+
+// swagger:parameters SAccountCreateGrantRequest
+type SAccountCreateGrantRequest struct {
+	// in: body
+	Body struct {
+		account.GrantRequest
+	}
+	account.User
+}
+
+// swagger:parameters SAccountGrantsRequest
+type SAccountGrantsRequest struct {
+	account.User
+}
+
+// swagger:parameters SAccountRevokeGrantRequest
+type SAccountRevokeGrantRequest struct {
+	account.Grant
+}
+
+// swagger:parameters SAccessRequestRequest
+type SAccessRequestRequest struct {
+	// in: body
+	Body struct {
+		account.AccessRequest
+	}
+}
+
+// swagger:parameters SAccessListRequest
+type SAccessListRequest struct {
+	account.AccessListRequest
+}
+
+// swagger:parameters SAccessRetrieveRequest
+type SAccessRetrieveRequest struct {
+	account.Access
+}
+
+// swagger:parameters SAccessApproveRequest SAccessDenyRequest
+type SAccessDecisionRequest struct {
+	// in: body
+	Body struct {
+		account.DecisionRequest
+	}
+	account.Access
+}
+
+// swagger:parameters SAccountRetrieveErasureRequest SAccountCompleteErasureRequest
+type SAccountErasureRequest struct {
+	account.Erasure
 }
 
 // swagger:parameters SAuthSignRequest
@@ -67,3 +207,96 @@ type SAccountLoginRequest struct {
 		account.LoginRequest
 	}
 }
+
+// swagger:parameters SAccountEffectivePermissionRequest
+type SAccountEffectivePermissionRequest struct {
+	account.User
+}
+
+// swagger:parameters SGroupCreateRequest
+type SGroupCreateRequest struct {
+	// in: body
+	Body struct {
+		group.CreateRequest
+	}
+}
+
+// swagger:parameters SGroupRetrievesRequest
+type SGroupRetrievesRequest struct {
+	group.RetrievesRequest
+}
+
+// swagger:parameters SGroupUpdateRequest
+type SGroupUpdateRequest struct {
+	// in: body
+	Body struct {
+		group.UpdateRequest
+	}
+	group.Group
+}
+
+// swagger:parameters SGroupRetrieveRequest
+type SGroupRetrieveRequest struct {
+	group.Group
+}
+
+// swagger:parameters SGroupDeleteRequest
+type SGroupDeleteRequest struct {
+	group.Group
+}
+
+// swagger:parameters SGroupListUsersRequest
+type SGroupListUsersRequest struct {
+	group.Group
+}
+
+// swagger:parameters SGroupAddUserRequest
+type SGroupAddUserRequest struct {
+	group.Member
+}
+
+// swagger:parameters SGroupRemoveUserRequest
+type SGroupRemoveUserRequest struct {
+	group.Member
+}
+
+// swagger:parameters SLabelBindingCreateRequest
+type SLabelBindingCreateRequest struct {
+	// in: body
+	Body struct {
+		label.CreateRequest
+	}
+}
+
+// swagger:parameters SLabelBindingRetrievesRequest
+type SLabelBindingRetrievesRequest struct {
+	label.RetrievesRequest
+}
+
+// swagger:parameters SLabelBindingUpdateRequest
+type SLabelBindingUpdateRequest struct {
+	// in: body
+	Body struct {
+		label.UpdateRequest
+	}
+	label.Binding
+}
+
+// swagger:parameters SLabelBindingRetrieveRequest SLabelBindingDeleteRequest
+type SLabelBindingRequest struct {
+	label.Binding
+}
+
+// swagger:parameters SProfileSchemaPutRequest
+type SProfileSchemaPutRequest struct {
+	// in: body
+	Body struct {
+		profile.SchemaRequest
+	}
+	profile.Account
+}
+
+// swagger:parameters SProfileSchemaRetrieveRequest SProfileSchemaDeleteRequest
+type SProfileSchemaRequest struct {
+	profile.Account
+}
@@ -4,10 +4,16 @@
 package swag
 
 import (
+	"github.com/crochee/lirity/e"
+
 	"caty/api"
-	"caty/pkg/resp"
+	"caty/pkg/health"
 	"caty/pkg/service/account"
 	"caty/pkg/service/auth"
+	"caty/pkg/service/group"
+	"caty/pkg/service/history"
+	"caty/pkg/service/label"
+	"caty/pkg/service/profile"
 )
 
 // swagger:response SNullResponse
@@ -18,7 +24,7 @@ type SNullResponse struct {
 type SResponseCode struct {
 	// in: body
 	Body struct {
-		resp.ResponseCode
+		e.InnerError
 	}
 }
 
@@ -30,6 +36,22 @@ type SAPIVersionResponse struct {
 	}
 }
 
+// swagger:response SHealthResponse
+type SHealthResponse struct {
+	// in: body
+	Body struct {
+		api.HealthResponse
+	}
+}
+
+// swagger:response SReadyResponse
+type SReadyResponse struct {
+	// in: body
+	Body struct {
+		health.Report
+	}
+}
+
 // swagger:response SAccountRegisterResponseResult
 type SAccountRegisterResponseResult struct {
 	// in: body
@@ -48,12 +70,86 @@ type SAccountRetrieveResponses struct {
 
 // swagger:response SAccountRetrieveResponse
 type SAccountRetrieveResponse struct {
+	// 资源版本，编辑、删除时通过If-Match携带
+	ETag string `json:"ETag"`
 	// in: body
 	Body struct {
 		account.RetrieveResponse
 	}
 }
 
+// swagger:response SAccountHistoryResponses
+type SAccountHistoryResponses struct {
+	// in: body
+	Body struct {
+		history.Responses
+	}
+}
+
+// swagger:response SAccountTransferResponse
+type SAccountTransferResponse struct {
+	// in: body
+	Body struct {
+		account.TransferResponse
+	}
+}
+
+// swagger:response SAccountErasureResponse
+type SAccountErasureResponse struct {
+	// in: body
+	Body struct {
+		account.ErasureResponse
+	}
+}
+
+// swagger:response SAccountQuotaResponse
+type SAccountQuotaResponse struct {
+	// in: body
+	Body struct {
+		account.QuotaResponse
+	}
+}
+
+// swagger:response SAccountLabelResponse
+type SAccountLabelResponse struct {
+	// in: body
+	Body struct {
+		account.LabelResponse
+	}
+}
+
+// swagger:response SAccountGrantResponse
+type SAccountGrantResponse struct {
+	// in: body
+	Body struct {
+		account.GrantResponse
+	}
+}
+
+// swagger:response SAccountGrantResponses
+type SAccountGrantResponses struct {
+	// in: body
+	Body struct {
+		account.GrantResponses
+	}
+}
+
+// swagger:response SAccessResponse
+type SAccessResponse struct {
+	// in: body
+	Body struct {
+		account.AccessResponse
+	}
+}
+
+// swagger:response SAccessResponses
+type SAccessResponses struct {
+	// in: body
+	Body struct {
+		account.AccessResponses
+	}
+}
+
 // swagger:response SAuthSignResponse
 type SAuthSignResponse struct {
 	// in: body
@@ -69,3 +165,59 @@ type SAuthParseResponse struct {
 		auth.TokenClaims
 	}
 }
+
+// swagger:response SAccountEffectivePermissionResponse
+type SAccountEffectivePermissionResponse struct {
+	// in: body
+	Body struct {
+		account.EffectivePermissionResponse
+	}
+}
+
+// swagger:response SGroupResponse
+type SGroupResponse struct {
+	// in: body
+	Body struct {
+		group.Response
+	}
+}
+
+// swagger:response SGroupResponses
+type SGroupResponses struct {
+	// in: body
+	Body struct {
+		group.Responses
+	}
+}
+
+// swagger:response SLabelBindingResponse
+type SLabelBindingResponse struct {
+	// in: body
+	Body struct {
+		label.Response
+	}
+}
+
+// swagger:response SLabelBindingResponses
+type SLabelBindingResponses struct {
+	// in: body
+	Body struct {
+		label.Responses
+	}
+}
+
+// swagger:response SGroupMemberResponses
+type SGroupMemberResponses struct {
+	// in: body
+	Body struct {
+		group.MemberResponses
+	}
+}
+
+// swagger:response SProfileSchemaResponse
+type SProfileSchemaResponse struct {
+	// in: body
+	Body struct {
+		profile.SchemaResponse
+	}
+}
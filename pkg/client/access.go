@@ -0,0 +1,120 @@
+// Package client
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/crochee/lirity/client"
+	"github.com/crochee/lirity/e"
+	"github.com/json-iterator/go"
+
+	"caty/pkg/service/account"
+)
+
+type Access interface {
+	RequestAccess(ctx context.Context, request *account.AccessRequest) (*account.AccessResponse, error)
+	ListAccess(ctx context.Context, request *account.AccessListRequest) (*account.AccessResponses, error)
+	ApproveAccess(ctx context.Context, access *account.Access,
+		request *account.DecisionRequest) (*account.AccessResponse, error)
+	DenyAccess(ctx context.Context, access *account.Access,
+		request *account.DecisionRequest) (*account.AccessResponse, error)
+}
+
+func NewAccess() Access {
+	return &AccessClient{
+		Client:     NewTraceClient(client.NewStandardClient()),
+		API:        jsoniter.ConfigCompatibleWithStandardLibrary,
+		URLHandler: NewURLHandler(),
+	}
+}
+
+type AccessClient struct {
+	client.Client
+	jsoniter.API
+	URLHandler
+}
+
+func (a *AccessClient) RequestAccess(ctx context.Context,
+	request *account.AccessRequest) (*account.AccessResponse, error) {
+	body, err := a.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var req *http.Request
+	if req, err = client.NewRequest(ctx, http.MethodPost, a.URL(ctx, "/v1/access-requests"),
+		body, a.Header(ctx)); err != nil {
+		return nil, err
+	}
+	return a.do(req)
+}
+
+func (a *AccessClient) ListAccess(ctx context.Context,
+	request *account.AccessListRequest) (*account.AccessResponses, error) {
+	params := url.Values{}
+	if request.UserID != "" {
+		params.Add("user_id", request.UserID)
+	}
+	if request.Status != "" {
+		params.Add("status", request.Status)
+	}
+
+	req, err := client.NewRequest(ctx, http.MethodGet, a.URLWithQuery(ctx, "/v1/access-requests", params),
+		nil, a.Header(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var response *http.Response
+	if response, err = a.Do(req); err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, e.From(response)
+	}
+	var result account.AccessResponses
+	if err = a.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *AccessClient) ApproveAccess(ctx context.Context, access *account.Access,
+	request *account.DecisionRequest) (*account.AccessResponse, error) {
+	return a.decide(ctx, "/v1/access-requests/"+access.ID+"/approve", request)
+}
+
+func (a *AccessClient) DenyAccess(ctx context.Context, access *account.Access,
+	request *account.DecisionRequest) (*account.AccessResponse, error) {
+	return a.decide(ctx, "/v1/access-requests/"+access.ID+"/deny", request)
+}
+
+func (a *AccessClient) decide(ctx context.Context, path string,
+	request *account.DecisionRequest) (*account.AccessResponse, error) {
+	body, err := a.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var req *http.Request
+	if req, err = client.NewRequest(ctx, http.MethodPost, a.URL(ctx, path), body, a.Header(ctx)); err != nil {
+		return nil, err
+	}
+	return a.do(req)
+}
+
+func (a *AccessClient) do(req *http.Request) (*account.AccessResponse, error) {
+	response, err := a.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, e.From(response)
+	}
+	var result account.AccessResponse
+	if err = a.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
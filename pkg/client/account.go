@@ -7,12 +7,17 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/crochee/lirity/client"
 	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/id"
 	"github.com/json-iterator/go"
 
 	"caty/pkg/service/account"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
 )
 
 type Account interface {
@@ -21,11 +26,12 @@ type Account interface {
 	Update(ctx context.Context, user *account.User, request *account.UpdateRequest) error
 	Retrieve(ctx context.Context, user *account.User) (*account.RetrieveResponse, error)
 	Delete(ctx context.Context, user *account.User) error
+	Login(ctx context.Context, request *account.LoginRequest) (*auth.APIToken, error)
 }
 
 func NewAccount() Account {
 	return &AccountClient{
-		Client:     client.NewStandardClient(),
+		Client:     NewTraceClient(client.NewStandardClient()),
 		API:        jsoniter.ConfigCompatibleWithStandardLibrary,
 		URLHandler: NewURLHandler(),
 	}
@@ -35,22 +41,53 @@ type AccountClient struct {
 	client.Client
 	jsoniter.API
 	URLHandler
+	// etags 缓存查询到的账户ETag，编辑、删除时自动携带If-Match
+	etags sync.Map
 }
 
+// header 生成请求头，已缓存ETag时携带If-Match
+func (a *AccountClient) header(ctx context.Context, userID string) http.Header {
+	header := a.Header(ctx)
+	if etag, ok := a.etags.Load(userID); ok {
+		header.Set("If-Match", etag.(string))
+	}
+	return header
+}
+
+// RegisterAttempts 注册请求因网络错误失败时的最大尝试次数
+var RegisterAttempts = 3
+
+// RetryInterval 重试间隔，按尝试次数递增
+var RetryInterval = 500 * time.Millisecond
+
 func (a *AccountClient) Register(ctx context.Context,
 	request *account.CreateRequest) (*account.CreateResponseResult, error) {
 	body, err := a.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
-	var req *http.Request
-	if req, err = client.NewRequest(ctx, http.MethodPost, a.URL(ctx, "/v1/account"),
-		body, a.Header(ctx)); err != nil {
-		return nil, err
+	// 重试时携带相同的幂等键，避免重复注册
+	if v.GetIdempotencyKey(ctx) == "" {
+		ctx = v.SetIdempotencyKey(ctx, id.UV4())
 	}
 	var response *http.Response
-	if response, err = a.Do(req); err != nil {
-		return nil, err
+	for attempt := 1; ; attempt++ {
+		var req *http.Request
+		if req, err = client.NewRequest(ctx, http.MethodPost, a.URL(ctx, "/v1/accounts"),
+			body, a.Header(ctx)); err != nil {
+			return nil, err
+		}
+		if response, err = a.Do(req); err == nil {
+			break
+		}
+		if attempt >= RegisterAttempts {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(time.Duration(attempt) * RetryInterval):
+		}
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
@@ -78,8 +115,17 @@ func (a *AccountClient) List(ctx context.Context,
 	if request.Email != "" {
 		params.Add("email", request.Email)
 	}
+	for _, attribute := range request.Attributes {
+		params.Add("attribute", attribute)
+	}
+	if request.IncludeDescendants {
+		params.Add("include_descendants", "true")
+	}
+	if request.Selector != "" {
+		params.Add("selector", request.Selector)
+	}
 
-	req, err := client.NewRequest(ctx, http.MethodGet, a.URLWithQuery(ctx, "/v1/account", params),
+	req, err := client.NewRequest(ctx, http.MethodGet, a.URLWithQuery(ctx, "/v1/accounts", params),
 		nil, a.Header(ctx))
 	if err != nil {
 		return nil, err
@@ -96,6 +142,9 @@ func (a *AccountClient) List(ctx context.Context,
 	if err = a.NewDecoder(response.Body).Decode(&result); err != nil {
 		return nil, err
 	}
+	for _, user := range result.Result {
+		a.etags.Store(user.UserID, account.ETag(user.Version))
+	}
 	return &result, nil
 }
 
@@ -105,8 +154,8 @@ func (a *AccountClient) Update(ctx context.Context, user *account.User, request
 		return err
 	}
 	var req *http.Request
-	if req, err = client.NewRequest(ctx, http.MethodPatch, a.URL(ctx, "/v1/account/"+user.ID),
-		body, a.Header(ctx)); err != nil {
+	if req, err = client.NewRequest(ctx, http.MethodPatch, a.URL(ctx, "/v1/accounts/"+user.ID),
+		body, a.header(ctx, user.ID)); err != nil {
 		return err
 	}
 	var response *http.Response
@@ -115,13 +164,20 @@ func (a *AccountClient) Update(ctx context.Context, user *account.User, request
 	}
 	defer response.Body.Close()
 	if response.StatusCode == http.StatusNoContent {
+		if etag := response.Header.Get("ETag"); etag != "" {
+			a.etags.Store(user.ID, etag)
+		}
 		return nil
 	}
+	if response.StatusCode == http.StatusPreconditionFailed {
+		// 缓存的版本已过期，需要重新查询
+		a.etags.Delete(user.ID)
+	}
 	return e.From(response)
 }
 
 func (a *AccountClient) Retrieve(ctx context.Context, user *account.User) (*account.RetrieveResponse, error) {
-	req, err := client.NewRequest(ctx, http.MethodGet, a.URL(ctx, "/v1/account/"+user.ID), nil, a.Header(ctx))
+	req, err := client.NewRequest(ctx, http.MethodGet, a.URL(ctx, "/v1/accounts/"+user.ID), nil, a.Header(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -137,11 +193,15 @@ func (a *AccountClient) Retrieve(ctx context.Context, user *account.User) (*acco
 	if err = a.NewDecoder(response.Body).Decode(&result); err != nil {
 		return nil, err
 	}
+	if etag := response.Header.Get("ETag"); etag != "" {
+		a.etags.Store(user.ID, etag)
+	}
 	return &result, nil
 }
 
 func (a *AccountClient) Delete(ctx context.Context, user *account.User) error {
-	req, err := client.NewRequest(ctx, http.MethodDelete, a.URL(ctx, "/v1/account/"+user.ID), nil, a.Header(ctx))
+	req, err := client.NewRequest(ctx, http.MethodDelete, a.URL(ctx, "/v1/accounts/"+user.ID), nil,
+		a.header(ctx, user.ID))
 	if err != nil {
 		return err
 	}
@@ -151,7 +211,36 @@ func (a *AccountClient) Delete(ctx context.Context, user *account.User) error {
 	}
 	defer response.Body.Close()
 	if response.StatusCode == http.StatusNoContent {
+		a.etags.Delete(user.ID)
 		return nil
 	}
+	if response.StatusCode == http.StatusPreconditionFailed {
+		a.etags.Delete(user.ID)
+	}
 	return e.From(response)
 }
+
+func (a *AccountClient) Login(ctx context.Context, request *account.LoginRequest) (*auth.APIToken, error) {
+	body, err := a.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var req *http.Request
+	if req, err = client.NewRequest(ctx, http.MethodPost, a.URL(ctx, "/v1/accounts/login"),
+		body, a.Header(ctx)); err != nil {
+		return nil, err
+	}
+	var response *http.Response
+	if response, err = a.Do(req); err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, e.From(response)
+	}
+	var result auth.APIToken
+	if err = a.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
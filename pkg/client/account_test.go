@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"caty/pkg/service/account"
+	"caty/pkg/v"
+)
+
+func TestRegisterRetry(t *testing.T) {
+	interval := RetryInterval
+	RetryInterval = 0
+	t.Cleanup(func() { RetryInterval = interval })
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(v.XIdempotencyKey))
+		if len(keys) == 1 {
+			// 首次请求断开连接，模拟网络错误
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_ = conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"account_id":"1","user_id":"10"}`))
+	}))
+	defer server.Close()
+	ctx := v.SetHost(context.Background(), strings.TrimPrefix(server.URL, "http://"))
+	if _, err := NewAccount().Register(ctx, &account.CreateRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) < 2 || keys[0] == "" {
+		t.Fatalf("unexpected idempotency keys %v", keys)
+	}
+	for _, key := range keys[1:] {
+		if key != keys[0] {
+			t.Fatalf("retry with different idempotency keys %v", keys)
+		}
+	}
+}
@@ -21,7 +21,7 @@ type Auth interface {
 
 func NewAuth() Auth {
 	return &AuthClient{
-		Client:     client.NewStandardClient(),
+		Client:     NewTraceClient(client.NewStandardClient()),
 		API:        jsoniter.ConfigCompatibleWithStandardLibrary,
 		URLHandler: NewURLHandler(),
 	}
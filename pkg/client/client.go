@@ -11,6 +11,8 @@ func New(service string) *Service {
 		return &Service{Account: NewAccount()}
 	case AuthService:
 		return &Service{Auth: NewAuth()}
+	case AccessService:
+		return &Service{Access: NewAccess()}
 	default:
 		panic(fmt.Sprintf("you must impl %s", service))
 	}
@@ -19,9 +21,11 @@ func New(service string) *Service {
 type Service struct {
 	Account
 	Auth
+	Access
 }
 
 const (
 	AccountService = "account"
 	AuthService    = "auth"
+	AccessService  = "access"
 )
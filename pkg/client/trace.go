@@ -0,0 +1,42 @@
+// Package client
+package client
+
+import (
+	"net/http"
+
+	"github.com/crochee/lirity/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"caty/pkg/tracex"
+)
+
+// NewTraceClient 为每次请求创建客户端span，并通过 traceparent 向下游传递追踪上下文
+func NewTraceClient(c client.Client) client.Client {
+	return traceClient{Client: c}
+}
+
+type traceClient struct {
+	client.Client
+}
+
+func (t traceClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, span := tracex.Tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(req)...))
+	defer span.End()
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	response, err := t.Client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(response.StatusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(response.StatusCode, trace.SpanKindClient))
+	return response, nil
+}
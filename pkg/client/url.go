@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"caty/pkg/i18n"
 	"caty/pkg/v"
 )
 
@@ -31,6 +32,16 @@ func (d DefaultIP) Header(ctx context.Context) http.Header {
 	if traceID != "" {
 		header.Add(v.XTraceID, traceID)
 	}
+	if key := v.GetIdempotencyKey(ctx); key != "" {
+		header.Add(v.XIdempotencyKey, key)
+	}
+	if token := v.GetAuthToken(ctx); token != "" {
+		header.Add(v.XAuthToken, token)
+	}
+	// 命令行按LANG等环境变量选择错误信息的语言
+	if language := i18n.Environ(); language != "" {
+		header.Add(i18n.AcceptLanguage, language)
+	}
 	return header
 }
 
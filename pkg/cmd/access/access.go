@@ -0,0 +1,24 @@
+// Package access
+package access
+
+import (
+	"github.com/spf13/cobra"
+
+	"caty/pkg/cmd/access/approve"
+	"caty/pkg/cmd/access/deny"
+	"caty/pkg/cmd/access/list"
+	"caty/pkg/cmd/access/request"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "access",
+		Short: "Request and approve time-bound access",
+	}
+
+	cmd.AddCommand(request.NewCmd())
+	cmd.AddCommand(approve.NewCmd())
+	cmd.AddCommand(deny.NewCmd())
+	cmd.AddCommand(list.NewCmd())
+	return cmd
+}
@@ -0,0 +1,61 @@
+// Package deny
+package deny
+
+import (
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/logger"
+	"github.com/crochee/lirity/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"caty/pkg/client"
+	"caty/pkg/service/account"
+	"caty/pkg/v"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deny <id>",
+		Short: "Deny an access request",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  do,
+	}
+	cmd.Flags().StringP("reason", "", "", "审批意见")
+
+	return cmd
+}
+
+func do(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	request := &account.DecisionRequest{}
+	var err error
+	if request.Reason, err = flags.GetString("reason"); err != nil {
+		return err
+	}
+
+	var debug bool
+	if debug, err = flags.GetBool("debug"); err != nil {
+		return err
+	}
+	ctx := v.SetAuthToken(cmd.Context(), viper.GetString("token"))
+	if debug {
+		ctx = logger.With(ctx, logger.New(logger.WithLevel(logger.DEBUG)))
+	}
+	var response *account.AccessResponse
+	if response, err = client.New(client.AccessService).DenyAccess(ctx,
+		&account.Access{ID: args[0]}, request); err != nil {
+		return err
+	}
+	fields := []string{
+		"ID",
+		"UserID",
+		"Service",
+		"Action",
+		"Status",
+		"ApproverID",
+		"GrantID",
+		"DecidedAt",
+	}
+	table.RenderAsTable(lirity.Struct2MapWithTag(response, ""), fields)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package list
+package list
+
+import (
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/logger"
+	"github.com/crochee/lirity/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"caty/pkg/client"
+	"caty/pkg/service/account"
+	"caty/pkg/v"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List access requests",
+		RunE:  do,
+	}
+	cmd.Flags().StringP("user-id", "", "", "根据申请人进行搜索")
+	cmd.Flags().StringP("status", "", "", "根据状态进行搜索 pending,approved,denied")
+
+	return cmd
+}
+
+func do(cmd *cobra.Command, _ []string) error {
+	flags := cmd.Flags()
+	opt := &account.AccessListRequest{}
+	var err error
+	if opt.UserID, err = flags.GetString("user-id"); err != nil {
+		return err
+	}
+	if opt.Status, err = flags.GetString("status"); err != nil {
+		return err
+	}
+
+	var debug bool
+	if debug, err = flags.GetBool("debug"); err != nil {
+		return err
+	}
+	ctx := v.SetAuthToken(cmd.Context(), viper.GetString("token"))
+	if debug {
+		ctx = logger.With(ctx, logger.New(logger.WithLevel(logger.DEBUG)))
+	}
+	var response *account.AccessResponses
+	if response, err = client.New(client.AccessService).ListAccess(ctx, opt); err != nil {
+		return err
+	}
+	listMap := make([]map[string]interface{}, len(response.Result))
+	for index, value := range response.Result {
+		listMap[index] = lirity.Struct2MapWithTag(value, "")
+	}
+	fields := []string{
+		"ID",
+		"UserID",
+		"Service",
+		"Action",
+		"Duration",
+		"Justification",
+		"Status",
+		"ApproverID",
+		"CreatedAt",
+	}
+	table.RenderAsTable(listMap, fields)
+	return nil
+}
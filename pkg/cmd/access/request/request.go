@@ -0,0 +1,88 @@
+// Package request
+package request
+
+import (
+	"fmt"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/logger"
+	"github.com/crochee/lirity/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"caty/pkg/client"
+	"caty/pkg/service/account"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "request",
+		Short: "Request time-bound access to a service",
+		RunE:  do,
+	}
+	cmd.Flags().StringP("service", "", v.ServiceName, "申请的服务")
+	cmd.Flags().StringP("action", "", "read", "申请的操作权限 read,write,delete,admin")
+	cmd.Flags().StringP("justification", "", "", "申请理由")
+	cmd.Flags().StringP("duration", "", "1h", "申请时长，如 4h、30m")
+	_ = cmd.MarkFlagRequired("justification")
+
+	return cmd
+}
+
+func do(cmd *cobra.Command, _ []string) error {
+	flags := cmd.Flags()
+	request := &account.AccessRequest{}
+	var err error
+	if request.Service, err = flags.GetString("service"); err != nil {
+		return err
+	}
+	var action string
+	if action, err = flags.GetString("action"); err != nil {
+		return err
+	}
+	if request.Action, err = parseAction(action); err != nil {
+		return err
+	}
+	if request.Justification, err = flags.GetString("justification"); err != nil {
+		return err
+	}
+	if request.Duration, err = flags.GetString("duration"); err != nil {
+		return err
+	}
+
+	var debug bool
+	if debug, err = flags.GetBool("debug"); err != nil {
+		return err
+	}
+	ctx := v.SetAuthToken(cmd.Context(), viper.GetString("token"))
+	if debug {
+		ctx = logger.With(ctx, logger.New(logger.WithLevel(logger.DEBUG)))
+	}
+	var response *account.AccessResponse
+	if response, err = client.New(client.AccessService).RequestAccess(ctx, request); err != nil {
+		return err
+	}
+	fields := []string{
+		"ID",
+		"UserID",
+		"Service",
+		"Action",
+		"Duration",
+		"Status",
+		"CreatedAt",
+	}
+	table.RenderAsTable(lirity.Struct2MapWithTag(response, ""), fields)
+	return nil
+}
+
+// parseAction 解析操作权限名称
+func parseAction(action string) (uint8, error) {
+	for value, name := range auth.ActionString {
+		if name == action && value != auth.Not {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid action %s, must be one of read,write,delete,admin", action)
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/crochee/lirity/logger"
 	"github.com/crochee/lirity/table"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"caty/pkg/client"
 	"caty/pkg/service/account"
+	"caty/pkg/v"
 )
 
 func NewCmd() *cobra.Command {
@@ -23,6 +25,8 @@ func NewCmd() *cobra.Command {
 	cmd.Flags().StringP("id", "", "", "根据id进行搜索")
 	cmd.Flags().StringP("account", "", "", "根据账户名进行搜索")
 	cmd.Flags().StringP("email", "", "", "根据邮箱进行搜索")
+	cmd.Flags().BoolP("include-descendants", "", false, "包含下级账户的用户")
+	cmd.Flags().StringP("selector", "l", "", "根据标签选择器进行搜索，如 env in (prod,stage),!contractor")
 
 	return cmd
 }
@@ -50,12 +54,22 @@ func do(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 	opt.Email = email
+	var includeDescendants bool
+	if includeDescendants, err = flags.GetBool("include-descendants"); err != nil {
+		return err
+	}
+	opt.IncludeDescendants = includeDescendants
+	var selector string
+	if selector, err = flags.GetString("selector"); err != nil {
+		return err
+	}
+	opt.Selector = selector
 
 	var debug bool
 	if debug, err = flags.GetBool("debug"); err != nil {
 		return err
 	}
-	ctx := cmd.Context()
+	ctx := v.SetAuthToken(cmd.Context(), viper.GetString("token"))
 	if debug {
 		ctx = logger.With(ctx, logger.New(logger.WithLevel(logger.DEBUG)))
 	}
@@ -74,6 +88,7 @@ func do(cmd *cobra.Command, _ []string) error {
 		"Verify",
 		"Email",
 		"Permission",
+		"Labels",
 		"Desc",
 		"CreatedAt",
 		"UpdatedAt",
@@ -8,9 +8,11 @@ import (
 	"github.com/crochee/lirity/logger"
 	"github.com/crochee/lirity/table"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"caty/pkg/client"
 	"caty/pkg/service/account"
+	"caty/pkg/v"
 )
 
 func NewCmd() *cobra.Command {
@@ -29,7 +31,7 @@ func do(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx := cmd.Context()
+	ctx := v.SetAuthToken(cmd.Context(), viper.GetString("token"))
 	if debug {
 		ctx = logger.With(ctx, logger.New(logger.WithLevel(logger.DEBUG)))
 	}
@@ -0,0 +1,115 @@
+// Package login
+package login
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/crochee/lirity/logger"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"caty/pkg/client"
+	"caty/pkg/service/account"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Login and print token",
+		Long: `Login with user id, account name and user name, or a verified email.
+Missing fields and the password will be prompted.`,
+		RunE: do,
+	}
+	cmd.Flags().StringP("user-id", "", "", "用户ID")
+	cmd.Flags().StringP("account", "", "", "账户名，与--name一起使用")
+	cmd.Flags().StringP("name", "", "", "用户名，与--account一起使用")
+	cmd.Flags().StringP("email", "", "", "已认证的邮箱")
+
+	return cmd
+}
+
+func do(cmd *cobra.Command, _ []string) error {
+	flags := cmd.Flags()
+	request := &account.LoginRequest{}
+	var err error
+	if request.UserID, err = flags.GetString("user-id"); err != nil {
+		return err
+	}
+	if request.Account, err = flags.GetString("account"); err != nil {
+		return err
+	}
+	if request.Name, err = flags.GetString("name"); err != nil {
+		return err
+	}
+	if request.Email, err = flags.GetString("email"); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(cmd.InOrStdin())
+	out := cmd.OutOrStdout()
+	if request.UserID == "" && request.Account == "" && request.Name == "" && request.Email == "" {
+		// 未指定登录方式时先询问账户名，留空则使用邮箱登录
+		if request.Account, err = prompt(reader, out, "Account (leave empty to login with email): "); err != nil {
+			return err
+		}
+		if request.Account == "" {
+			if request.Email, err = prompt(reader, out, "Email: "); err != nil {
+				return err
+			}
+		}
+	}
+	if request.Account != "" && request.Name == "" {
+		if request.Name, err = prompt(reader, out, "Name: "); err != nil {
+			return err
+		}
+	}
+	if request.Password, err = promptPassword(reader, out); err != nil {
+		return err
+	}
+
+	var debug bool
+	if debug, err = flags.GetBool("debug"); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if debug {
+		ctx = logger.With(ctx, logger.New(logger.WithLevel(logger.DEBUG)))
+	}
+	token, err := client.New(client.AccountService).Login(ctx, request)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, token.Token)
+	return err
+}
+
+func prompt(reader *bufio.Reader, out io.Writer, label string) (string, error) {
+	if _, err := fmt.Fprint(out, label); err != nil {
+		return "", err
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptPassword 终端输入时不回显密码
+func promptPassword(reader *bufio.Reader, out io.Writer) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt(reader, out, "Password: ")
+	}
+	if _, err := fmt.Fprint(out, "Password: "); err != nil {
+		return "", err
+	}
+	password, err := term.ReadPassword(fd)
+	if err != nil {
+		return "", err
+	}
+	_, err = fmt.Fprintln(out)
+	return string(password), err
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"caty/pkg/cmd/access"
 	"caty/pkg/cmd/account"
+	"caty/pkg/cmd/login"
 	"caty/pkg/v"
 )
 
@@ -37,6 +39,10 @@ func NewCmd() (*cobra.Command, error) {
 	if err := viper.BindPFlag("debug", persistentFlags.Lookup("debug")); err != nil {
 		return nil, err
 	}
+	persistentFlags.StringP("token", "t", "", "X-Auth-Token printed by login (if do not provided, will lookup from config file or environment)")
+	if err := viper.BindPFlag("token", persistentFlags.Lookup("token")); err != nil {
+		return nil, err
+	}
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -45,6 +51,8 @@ func NewCmd() (*cobra.Command, error) {
 	// Register child command
 	rootCmd.AddCommand(newCompletion())
 	rootCmd.AddCommand(account.NewCmd())
+	rootCmd.AddCommand(login.NewCmd())
+	rootCmd.AddCommand(access.NewCmd())
 
 	return rootCmd, nil
 }
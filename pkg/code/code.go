@@ -8,6 +8,13 @@ var (
 	ErrNoAccount = e.Froze(40011000, "用户不存在")
 	ErrNoUpdate  = e.Froze(3041101, "数据无更新")
 
+	ErrIdempotency           = e.Froze(50011002, "幂等请求处理错误")
+	ErrIdempotencyMismatch   = e.Froze(42211003, "幂等键已被不同的请求使用")
+	ErrIdempotencyProcessing = e.Froze(40911004, "相同幂等键的请求正在处理")
+	ErrRecordEvent           = e.Froze(50011005, "记录审计事件错误")
+	ErrRateLimited           = e.Froze(42911006, "请求过于频繁，请稍后重试")
+	ErrRateLimit             = e.Froze(50011007, "限流处理错误")
+
 	// 100~199为账号)
 	ErrRegisterAccount      = e.Froze(50011100, "注册账号错误")
 	ErrUpdateAccount        = e.Froze(50011101, "编辑账号错误")
@@ -16,33 +23,215 @@ var (
 	ErrExistAccount         = e.Froze(40011104, "用户已存在")
 	ErrLoginAccount         = e.Froze(50011105, "用户登录错误")
 	ErrWrongPasswordAccount = e.Froze(40011106, "用户密码错误")
+	ErrRestoreAccount       = e.Froze(50011107, "恢复账号错误")
+	ErrRestoreExpireAccount = e.Froze(40011108, "账号已超过恢复期限")
+	ErrRestorePrimaryFirst  = e.Froze(40011109, "主账号已删除，请先恢复主账号")
+	ErrPreconditionFailed   = e.Froze(41211110, "账号版本不匹配")
+	ErrPreconditionRequired = e.Froze(42811111, "缺少If-Match请求头")
+	ErrInactiveAccount      = e.Froze(40311112, "账号未激活或已停用")
+	ErrTransitionAccount    = e.Froze(40011113, "账号当前状态不允许该操作")
+	ErrNoTransfer           = e.Froze(40011114, "主账号转让不存在")
+	ErrTransferAccount      = e.Froze(50011115, "主账号转让错误")
+	ErrExistTransfer        = e.Froze(40011116, "已存在待接受的主账号转让")
+	ErrExpireTransfer       = e.Froze(40011117, "主账号转让已过期")
+	ErrStatusTransfer       = e.Froze(40011118, "主账号转让已完成或已取消")
+	ErrTargetTransfer       = e.Froze(40011119, "转让目标须为同一账户下已激活的子账号")
+	ErrUnavailableEmail     = e.Froze(40011120, "邮箱不可用，请更换其他邮箱")
+	ErrAmbiguousEmail       = e.Froze(40911121, "邮箱对应多个用户，请使用账户与用户名登录")
+	ErrLoginMethod          = e.Froze(40011122, "须提供用户ID、账户与用户名或邮箱中的一种登录方式")
+	ErrExportAccount        = e.Froze(50011123, "导出用户数据错误")
+	ErrNoErasure            = e.Froze(40011124, "数据擦除请求不存在")
+	ErrErasureAccount       = e.Froze(50011125, "数据擦除错误")
+	ErrExistErasure         = e.Froze(40011126, "已存在待处理的数据擦除请求")
+	ErrStatusErasure        = e.Froze(40011127, "数据擦除请求已完成")
+	ErrPrimaryErasure       = e.Froze(40011128, "主账号须先转让后再擦除数据")
+	ErrDepthAccount         = e.Froze(40011129, "账户层级超过上限")
+	ErrChildAccount         = e.Froze(40011130, "账户存在子账户，须先删除子账户")
+	ErrRestoreParentFirst   = e.Froze(40011131, "上级账户已删除，请先恢复上级账户")
+	ErrNoGrant              = e.Froze(40011132, "限时授权不存在")
+	ErrCreateGrant          = e.Froze(50011133, "创建限时授权错误")
+	ErrRetrieveGrant        = e.Froze(50011134, "查询限时授权错误")
+	ErrDeleteGrant          = e.Froze(50011135, "删除限时授权错误")
+	ErrInvalidGrant         = e.Froze(40011136, "限时授权的有效期无效")
+	ErrNoAccess             = e.Froze(40011137, "权限申请不存在")
+	ErrCreateAccess         = e.Froze(50011138, "提交权限申请错误")
+	ErrRetrieveAccess       = e.Froze(50011139, "查询权限申请错误")
+	ErrDecideAccess         = e.Froze(50011140, "审批权限申请错误")
+	ErrStatusAccess         = e.Froze(40011141, "权限申请已审批")
+	ErrDurationAccess       = e.Froze(40011142, "权限申请的时长无效")
+	ErrNoEmail              = e.Froze(40011143, "用户未设置邮箱")
+	ErrAmbiguousName        = e.Froze(40911144, "账户下有多个同名用户，请使用用户ID登录")
 
 	// 200~299为权限类
 
-	ErrCreateAuth  = e.Froze(50011200, "生成token")
-	ErrParseAuth   = e.Froze(50011201, "解析token错误")
-	ErrInvalidAuth = e.Froze(40011202, "无效token")
-	ErrExpireAuth  = e.Froze(40011203, "过期token")
-	ErrVerifyAuth  = e.Froze(40011204, "错误token")
+	ErrCreateAuth    = e.Froze(50011200, "生成token")
+	ErrParseAuth     = e.Froze(50011201, "解析token错误")
+	ErrInvalidAuth   = e.Froze(40011202, "无效token")
+	ErrExpireAuth    = e.Froze(40011203, "过期token")
+	ErrVerifyAuth    = e.Froze(40011204, "错误token")
+	ErrNoAuth        = e.Froze(40111205, "缺少token")
+	ErrForbiddenAuth = e.Froze(40311206, "权限不足")
+	ErrInactiveAuth  = e.Froze(40111207, "账号状态异常，token已失效")
+
+	// 300~399为用户组类
+
+	ErrNoGroup          = e.Froze(40011300, "用户组不存在")
+	ErrCreateGroup      = e.Froze(50011301, "创建用户组错误")
+	ErrUpdateGroup      = e.Froze(50011302, "编辑用户组错误")
+	ErrRetrieveGroup    = e.Froze(50011303, "查询用户组错误")
+	ErrDeleteGroup      = e.Froze(50011304, "删除用户组错误")
+	ErrExistGroup       = e.Froze(40011305, "用户组已存在")
+	ErrMemberGroup      = e.Froze(50011306, "编辑用户组成员错误")
+	ErrNoMemberGroup    = e.Froze(40011307, "用户不是用户组成员")
+	ErrDiffAccountGroup = e.Froze(40011308, "用户与用户组不属于同一账户")
+
+	// 400~499为用户属性类
+
+	ErrNoProfileSchema       = e.Froze(40011400, "用户属性模式不存在")
+	ErrPutProfileSchema      = e.Froze(50011401, "设置用户属性模式错误")
+	ErrRetrieveProfileSchema = e.Froze(50011402, "查询用户属性模式错误")
+	ErrDeleteProfileSchema   = e.Froze(50011403, "删除用户属性模式错误")
+	ErrInvalidProfileSchema  = e.Froze(40011404, "无效的用户属性模式")
+	ErrInvalidAttributes     = e.Froze(40011405, "用户属性不符合模式")
+
+	// 500~599为变更历史类
+
+	ErrNoHistory       = e.Froze(40011500, "历史版本不存在")
+	ErrRecordHistory   = e.Froze(50011501, "记录变更历史错误")
+	ErrRetrieveHistory = e.Froze(50011502, "查询变更历史错误")
+	ErrRevertHistory   = e.Froze(50011503, "回滚历史版本错误")
+
+	// 600~699为配额类
+
+	ErrExceedQuota   = e.Froze(40311600, "超出账户配额")
+	ErrRetrieveQuota = e.Froze(50011601, "查询账户配额错误")
+	ErrUpdateQuota   = e.Froze(50011602, "设置账户配额错误")
+	ErrInvalidQuota  = e.Froze(40011603, "不支持的配额资源")
+	ErrCreateSession = e.Froze(50011604, "创建登录会话错误")
+	ErrEndedSession  = e.Froze(40111605, "登录会话已结束，请重新登录")
+	ErrEndSession    = e.Froze(50011606, "结束登录会话错误")
+
+	// 700~799为标签类
+
+	ErrInvalidLabel    = e.Froze(40011700, "无效的标签")
+	ErrInvalidSelector = e.Froze(40011701, "无效的标签选择器")
+	ErrUpdateLabel     = e.Froze(50011702, "编辑标签错误")
+	ErrNoBinding       = e.Froze(40011703, "标签授权不存在")
+	ErrCreateBinding   = e.Froze(50011704, "创建标签授权错误")
+	ErrUpdateBinding   = e.Froze(50011705, "编辑标签授权错误")
+	ErrRetrieveBinding = e.Froze(50011706, "查询标签授权错误")
+	ErrDeleteBinding   = e.Froze(50011707, "删除标签授权错误")
+	ErrExistBinding    = e.Froze(40011708, "标签授权已存在")
 )
 
+// codes 全部业务错误码
+var codes = map[e.ErrorCode]struct{}{
+	ErrNoAccount: {},
+	ErrNoUpdate:  {},
+
+	ErrIdempotency:           {},
+	ErrIdempotencyMismatch:   {},
+	ErrIdempotencyProcessing: {},
+	ErrRecordEvent:           {},
+	ErrRateLimited:           {},
+	ErrRateLimit:             {},
+
+	ErrRegisterAccount:      {},
+	ErrUpdateAccount:        {},
+	ErrRetrieveAccount:      {},
+	ErrDeleteAccount:        {},
+	ErrExistAccount:         {},
+	ErrLoginAccount:         {},
+	ErrWrongPasswordAccount: {},
+	ErrRestoreAccount:       {},
+	ErrRestoreExpireAccount: {},
+	ErrRestorePrimaryFirst:  {},
+	ErrPreconditionFailed:   {},
+	ErrPreconditionRequired: {},
+	ErrInactiveAccount:      {},
+	ErrTransitionAccount:    {},
+	ErrNoTransfer:           {},
+	ErrTransferAccount:      {},
+	ErrExistTransfer:        {},
+	ErrExpireTransfer:       {},
+	ErrStatusTransfer:       {},
+	ErrTargetTransfer:       {},
+	ErrUnavailableEmail:     {},
+	ErrAmbiguousEmail:       {},
+	ErrLoginMethod:          {},
+	ErrExportAccount:        {},
+	ErrNoErasure:            {},
+	ErrErasureAccount:       {},
+	ErrExistErasure:         {},
+	ErrStatusErasure:        {},
+	ErrPrimaryErasure:       {},
+	ErrDepthAccount:         {},
+	ErrChildAccount:         {},
+	ErrRestoreParentFirst:   {},
+	ErrNoGrant:              {},
+	ErrCreateGrant:          {},
+	ErrRetrieveGrant:        {},
+	ErrDeleteGrant:          {},
+	ErrInvalidGrant:         {},
+	ErrNoAccess:             {},
+	ErrCreateAccess:         {},
+	ErrRetrieveAccess:       {},
+	ErrDecideAccess:         {},
+	ErrStatusAccess:         {},
+	ErrDurationAccess:       {},
+	ErrNoEmail:              {},
+	ErrAmbiguousName:        {},
+
+	ErrCreateAuth:    {},
+	ErrParseAuth:     {},
+	ErrInvalidAuth:   {},
+	ErrExpireAuth:    {},
+	ErrVerifyAuth:    {},
+	ErrNoAuth:        {},
+	ErrForbiddenAuth: {},
+	ErrInactiveAuth:  {},
+
+	ErrNoGroup:          {},
+	ErrCreateGroup:      {},
+	ErrUpdateGroup:      {},
+	ErrRetrieveGroup:    {},
+	ErrDeleteGroup:      {},
+	ErrExistGroup:       {},
+	ErrMemberGroup:      {},
+	ErrNoMemberGroup:    {},
+	ErrDiffAccountGroup: {},
+
+	ErrNoProfileSchema:       {},
+	ErrPutProfileSchema:      {},
+	ErrRetrieveProfileSchema: {},
+	ErrDeleteProfileSchema:   {},
+	ErrInvalidProfileSchema:  {},
+	ErrInvalidAttributes:     {},
+
+	ErrNoHistory:       {},
+	ErrRecordHistory:   {},
+	ErrRetrieveHistory: {},
+	ErrRevertHistory:   {},
+
+	ErrExceedQuota:   {},
+	ErrRetrieveQuota: {},
+	ErrUpdateQuota:   {},
+	ErrInvalidQuota:  {},
+	ErrCreateSession: {},
+	ErrEndedSession:  {},
+	ErrEndSession:    {},
+
+	ErrInvalidLabel:    {},
+	ErrInvalidSelector: {},
+	ErrUpdateLabel:     {},
+	ErrNoBinding:       {},
+	ErrCreateBinding:   {},
+	ErrUpdateBinding:   {},
+	ErrRetrieveBinding: {},
+	ErrDeleteBinding:   {},
+	ErrExistBinding:    {},
+}
+
 func Loading() error {
-	return e.AddCode(map[e.ErrorCode]struct{}{
-		ErrNoAccount: {},
-		ErrNoUpdate:  {},
-
-		ErrRegisterAccount:      {},
-		ErrUpdateAccount:        {},
-		ErrRetrieveAccount:      {},
-		ErrDeleteAccount:        {},
-		ErrExistAccount:         {},
-		ErrLoginAccount:         {},
-		ErrWrongPasswordAccount: {},
-
-		ErrCreateAuth:  {},
-		ErrParseAuth:   {},
-		ErrInvalidAuth: {},
-		ErrExpireAuth:  {},
-		ErrVerifyAuth:  {},
-	})
+	return e.AddCode(codes)
 }
@@ -0,0 +1,68 @@
+package code
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin/binding"
+
+	"caty/pkg/i18n"
+	"caty/pkg/validator"
+)
+
+func TestCatalogs(t *testing.T) {
+	if err := Loading(); err != nil {
+		t.Fatal(err)
+	}
+	all := map[e.ErrorCode]struct{}{
+		e.ErrInternalServerError: {},
+		e.ErrInvalidParam:        {},
+		e.ErrNotFound:            {},
+		e.ErrNotAllowMethod:      {},
+		e.ErrParseContent:        {},
+	}
+	for errorCode := range codes {
+		all[errorCode] = struct{}{}
+	}
+	// 每个错误码在各语言目录中都须有错误信息，中文目录与代码中的错误信息一致
+	for errorCode := range all {
+		if message := i18n.Message(i18n.ZH, errorCode.Code(), ""); message != errorCode.Message() {
+			t.Errorf("%d zh catalog %q mismatch %q", errorCode.Code(), message, errorCode.Message())
+		}
+		if message := i18n.Message(i18n.EN, errorCode.Code(), ""); message == "" || message == errorCode.Message() {
+			t.Errorf("%d en catalog missing", errorCode.Code())
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if err := validator.Init(); err != nil {
+		t.Fatal(err)
+	}
+	request := struct {
+		Email string `json:"email" binding:"required,email"`
+		Sort  string `form:"sort" binding:"omitempty,sort"`
+	}{Email: "invalid", Sort: "-"}
+	err := binding.Validator.ValidateStruct(&request)
+
+	errorCode := Translate(i18n.EN, e.ErrInvalidParam.WithResult(err))
+	if errorCode.Message() != "Invalid request parameters" {
+		t.Fatalf("unexpected message %s", errorCode.Message())
+	}
+	fields, ok := errorCode.Result().(map[string]string)
+	if !ok || fields["email"] != "email must be a valid email address" ||
+		fields["sort"] != "sort must be a valid sort expression" {
+		t.Fatalf("unexpected result %v", errorCode.Result())
+	}
+
+	errorCode = Translate(i18n.ZH, e.ErrInvalidParam.WithResult(err))
+	if fields, ok = errorCode.Result().(map[string]string); !ok || fields["email"] != "email必须是一个有效的邮箱" {
+		t.Fatalf("unexpected result %v", errorCode.Result())
+	}
+
+	errorCode = Translate(i18n.EN, ErrNoAccount.WithResult(errors.New("record not found")))
+	if errorCode.Message() != "User does not exist" || errorCode.Result() != "record not found" {
+		t.Fatalf("unexpected error %v", errorCode)
+	}
+}
@@ -0,0 +1,41 @@
+package code
+
+import (
+	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"caty/pkg/i18n"
+	"caty/pkg/validator"
+)
+
+// Code 与 e.Code 一致，错误信息按 Accept-Language 翻译
+func Code(ctx *gin.Context, errorCode e.ErrorCode) {
+	locale := i18n.Match(ctx.GetHeader(i18n.AcceptLanguage))
+	ctx.Header("Content-Language", locale)
+	e.Code(ctx, Translate(locale, errorCode))
+}
+
+// Error 与 e.Error 一致，错误信息按 Accept-Language 翻译
+func Error(ctx *gin.Context, err error) {
+	logger.From(ctx.Request.Context()).Sugar().Errorf("%+v", err)
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) {
+		errorCode = e.ErrInternalServerError.WithResult(err)
+	}
+	Code(ctx, errorCode)
+}
+
+// Translate 将错误信息翻译为语言 locale，参数校验错误翻译为各字段的错误信息，其他错误转换为错误描述
+func Translate(locale string, errorCode e.ErrorCode) e.ErrorCode {
+	result := errorCode.WithMessage(i18n.Message(locale, errorCode.Code(), errorCode.Message()))
+	err, ok := errorCode.Result().(error)
+	if !ok || err == nil {
+		return result
+	}
+	if fields, ok := validator.Translate(locale, err); ok {
+		return result.WithResult(fields)
+	}
+	return result.WithResult(err.Error())
+}
@@ -30,6 +30,14 @@ func Setup() {
 	timeCron.Start()
 }
 
+// Close stop cron.Cron and wait for running jobs to complete
+func Close() {
+	if timeCron == nil {
+		return
+	}
+	<-timeCron.Stop().Done()
+}
+
 type cronLogger struct{}
 
 func (c cronLogger) Info(msg string, keysAndValues ...interface{}) {
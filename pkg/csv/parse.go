@@ -7,11 +7,21 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/crochee/lirity/e"
 	"github.com/crochee/lirity/variable"
 	"github.com/json-iterator/go"
 )
 
+// Errors 解析切片时各元素的错误集合
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
 type mapIndexValue struct {
 	data  map[string]interface{}
 	index []*indexValue
@@ -150,7 +160,7 @@ func (p *parse) parse(obj interface{}) ([]*mapIndexValue, error) {
 		return p.parseStruct(obj)
 	case reflect.Slice, reflect.Array:
 		count := value.Len()
-		validateRet := make(e.Errors, 0, count)
+		validateRet := make(Errors, 0, count)
 		tempMap := make([]*mapIndexValue, 0, count)
 		for i := 0; i < count; i++ {
 			if !value.Index(i).CanInterface() {
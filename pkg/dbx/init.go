@@ -6,12 +6,14 @@ import (
 	"github.com/crochee/lirity/db"
 	"github.com/spf13/viper"
 
+	"caty/pkg/metrics"
+
 	"time"
 )
 
 // Init init database
 func Init(ctx context.Context) (err error) {
-	return db.Init(ctx, func(opt *db.Option) {
+	if err = db.Init(ctx, func(opt *db.Option) {
 		opt.Debug = viper.GetString("mode") == "debug"
 		opt.User = viper.GetString("mysql.user")
 		opt.Password = viper.GetString("mysql.password")
@@ -22,5 +24,26 @@ func Init(ctx context.Context) (err error) {
 		opt.MaxOpenConn = viper.GetInt("mysql.max_open_conns")
 		opt.MaxIdleConn = viper.GetInt("mysql.max_idle_conns")
 		opt.ConnMaxLifetime = time.Duration(viper.GetInt("mysql.conn_max_lifetime")) * time.Second
-	})
+	}); err != nil {
+		return
+	}
+	// 数据库操作追踪
+	if err = db.With(ctx).Use(tracePlugin{database: viper.GetString("mysql.database")}); err != nil {
+		return err
+	}
+	sqlDB, err := db.With(ctx).DB.DB()
+	if err != nil {
+		return err
+	}
+	// 连接池指标
+	return metrics.RegisterDB(sqlDB, viper.GetString("mysql.database"))
+}
+
+// Ping 检查数据库连接是否可用
+func Ping(ctx context.Context) error {
+	sqlDB, err := db.With(ctx).DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
@@ -0,0 +1,101 @@
+package dbx
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"caty/pkg/tracex"
+)
+
+const spanKey = "caty:span"
+
+// tracePlugin 为gorm的每次数据库操作创建span
+type tracePlugin struct {
+	database string
+}
+
+func (tracePlugin) Name() string {
+	return "caty:trace"
+}
+
+func (t tracePlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register("caty:trace_before_create",
+		t.before("gorm.create")); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("caty:trace_after_create", t.after); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("caty:trace_before_query",
+		t.before("gorm.query")); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register("caty:trace_after_query", t.after); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("caty:trace_before_update",
+		t.before("gorm.update")); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("caty:trace_after_update", t.after); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("caty:trace_before_delete",
+		t.before("gorm.delete")); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("caty:trace_after_delete", t.after); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("caty:trace_before_row",
+		t.before("gorm.row")); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("caty:trace_after_row", t.after); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("caty:trace_before_raw",
+		t.before("gorm.raw")); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("caty:trace_after_raw", t.after)
+}
+
+func (t tracePlugin) before(name string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// 不追踪没有父span的后台查询，如定时任务
+			return
+		}
+		_, span := tracex.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBNameKey.String(t.database)))
+		tx.InstanceSet(spanKey, span)
+	}
+}
+
+func (tracePlugin) after(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+	span.SetAttributes(
+		semconv.DBStatementKey.String(tx.Statement.SQL.String()),
+		semconv.DBSQLTableKey.String(tx.Statement.Table),
+	)
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(tx.Error)
+		span.SetStatus(codes.Error, tx.Error.Error())
+	}
+}
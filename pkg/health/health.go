@@ -0,0 +1,188 @@
+// Package health 服务存活与就绪检查
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 服务状态
+const (
+	StatusStarting     = "starting"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// 依赖状态
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultTimeout 默认单个依赖检查的超时时间
+const DefaultTimeout = 3 * time.Second
+
+// Timeout 单个依赖检查的超时时间
+func Timeout() time.Duration {
+	if timeout := viper.GetDuration("health.timeout"); timeout > 0 {
+		return timeout
+	}
+	return DefaultTimeout
+}
+
+// DrainDelay 标记为未就绪后等待编排系统摘除流量的时间
+func DrainDelay() time.Duration {
+	return viper.GetDuration("health.drain_delay")
+}
+
+// Checker 依赖检查，返回错误表示依赖不可用
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 函数形式的 Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+const (
+	stateStarting int32 = iota
+	stateReady
+	stateShuttingDown
+)
+
+var (
+	state    int32
+	mutex    sync.RWMutex
+	checkers = make(map[string]Checker)
+)
+
+// Register 注册名为 name 的依赖检查，同名检查会被覆盖
+func Register(name string, checker Checker) {
+	mutex.Lock()
+	checkers[name] = checker
+	mutex.Unlock()
+}
+
+// Unregister 注销名为 name 的依赖检查
+func Unregister(name string) {
+	mutex.Lock()
+	delete(checkers, name)
+	mutex.Unlock()
+}
+
+// SetReady 服务初始化完成，开始执行依赖检查
+func SetReady() {
+	atomic.CompareAndSwapInt32(&state, stateStarting, stateReady)
+}
+
+// SetShuttingDown 服务开始关闭，之后始终未就绪
+func SetShuttingDown() {
+	atomic.StoreInt32(&state, stateShuttingDown)
+}
+
+type CheckResult struct {
+	// 依赖名
+	// Required: true
+	Name string `json:"name"`
+	// 状态 up,down
+	// Required: true
+	Status string `json:"status"`
+	// 耗时
+	// Required: true
+	Latency string `json:"latency"`
+	// 不可用的原因
+	Error string `json:"error,omitempty"`
+}
+
+type Report struct {
+	// 状态 starting,ready,not_ready,shutting_down
+	// Required: true
+	Status string `json:"status"`
+	// 各依赖的检查结果
+	// Required: true
+	Checks []*CheckResult `json:"checks"`
+}
+
+// Ready 报告服务是否就绪，启动中与关闭中不执行依赖检查
+func (r *Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Check 并发执行所有依赖检查，每个检查受 Timeout 限制
+func Check(ctx context.Context) *Report {
+	report := &Report{Checks: []*CheckResult{}}
+	switch atomic.LoadInt32(&state) {
+	case stateStarting:
+		report.Status = StatusStarting
+		return report
+	case stateShuttingDown:
+		report.Status = StatusShuttingDown
+		return report
+	}
+	mutex.RLock()
+	names := make([]string, 0, len(checkers))
+	list := make([]Checker, 0, len(checkers))
+	for name, checker := range checkers {
+		names = append(names, name)
+		list = append(list, checker)
+	}
+	mutex.RUnlock()
+
+	report.Checks = make([]*CheckResult, len(list))
+	timeout := Timeout()
+	var wg sync.WaitGroup
+	for index := range list {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			report.Checks[index] = check(ctx, names[index], list[index], timeout)
+		}(index)
+	}
+	wg.Wait()
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+
+	report.Status = StatusReady
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusNotReady
+			break
+		}
+	}
+	return report
+}
+
+// check 执行单个依赖检查，检查未在 timeout 内返回时视为不可用
+func check(ctx context.Context, name string, checker Checker, timeout time.Duration) *CheckResult {
+	newCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- checker.Check(newCtx)
+	}()
+	var err error
+	select {
+	case err = <-errChan:
+	case <-newCtx.Done():
+		err = newCtx.Err()
+	}
+	result := &CheckResult{
+		Name:    name,
+		Status:  StatusUp,
+		Latency: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestCheck(t *testing.T) {
+	viper.Set("health.timeout", 50*time.Millisecond)
+	defer viper.Set("health.timeout", nil)
+	defer func() {
+		state = stateStarting
+		checkers = make(map[string]Checker)
+	}()
+
+	Register("mysql", CheckerFunc(func(context.Context) error { return nil }))
+	if report := Check(context.Background()); report.Status != StatusStarting || report.Ready() {
+		t.Fatalf("want %s got %s", StatusStarting, report.Status)
+	}
+
+	SetReady()
+	if report := Check(context.Background()); !report.Ready() || len(report.Checks) != 1 {
+		t.Fatalf("want ready got %+v", report)
+	}
+
+	Register("etcd", CheckerFunc(func(context.Context) error { return errors.New("unreachable") }))
+	Register("rabbitmq", CheckerFunc(func(ctx context.Context) error {
+		<-time.After(time.Second)
+		return nil
+	}))
+	report := Check(context.Background())
+	if report.Status != StatusNotReady {
+		t.Fatalf("want %s got %s", StatusNotReady, report.Status)
+	}
+	want := map[string]string{"etcd": StatusDown, "mysql": StatusUp, "rabbitmq": StatusDown}
+	for _, result := range report.Checks {
+		if want[result.Name] != result.Status {
+			t.Fatalf("%s want %s got %s", result.Name, want[result.Name], result.Status)
+		}
+	}
+	if report.Checks[0].Name != "etcd" || report.Checks[2].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("unexpected checks %+v", report.Checks)
+	}
+
+	SetShuttingDown()
+	SetReady()
+	if report = Check(context.Background()); report.Status != StatusShuttingDown {
+		t.Fatalf("want %s got %s", StatusShuttingDown, report.Status)
+	}
+}
@@ -0,0 +1,97 @@
+// Package i18n 错误信息的多语言目录，按 Accept-Language 选择语言
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的语言
+const (
+	ZH = "zh"
+	EN = "en"
+)
+
+// Default 无法匹配请求的语言时使用的语言
+const Default = ZH
+
+// AcceptLanguage 请求语言的请求头
+const AcceptLanguage = "Accept-Language"
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+var (
+	// supported 与 matcher 的语言顺序一致，第一个为默认语言
+	supported = []string{ZH, EN}
+	matcher   = language.NewMatcher([]language.Tag{language.Chinese, language.English})
+	catalogs  = mustLoad()
+)
+
+func mustLoad() map[string]map[int]string {
+	result := make(map[string]map[int]string, len(supported))
+	for _, locale := range supported {
+		data, err := locales.ReadFile(fmt.Sprintf("locales/%s.yaml", locale))
+		if err != nil {
+			panic(err)
+		}
+		catalog := make(map[int]string)
+		if err = yaml.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Errorf("parse locale %s failed.Error:%w", locale, err))
+		}
+		result[locale] = catalog
+	}
+	return result
+}
+
+// Locales 支持的语言
+func Locales() []string {
+	return append([]string(nil), supported...)
+}
+
+// Match 按 Accept-Language 匹配支持的语言，无法匹配时使用 Default
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// Message 语言 locale 下业务错误码 code 的错误信息，目录缺失时依次使用 Default 与 fallback
+func Message(locale string, code int, fallback string) string {
+	if message, ok := catalogs[locale][code]; ok {
+		return message
+	}
+	if message, ok := catalogs[Default][code]; ok {
+		return message
+	}
+	return fallback
+}
+
+// Environ 按 LC_ALL、LC_MESSAGES、LANG 环境变量获取命令行的语言，如 en_US.UTF-8 转换为 en-US
+func Environ() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		if index := strings.IndexAny(value, ".@"); index >= 0 {
+			value = value[:index]
+		}
+		if value == "C" || value == "POSIX" {
+			return ""
+		}
+		return strings.ReplaceAll(value, "_", "-")
+	}
+	return ""
+}
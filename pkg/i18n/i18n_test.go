@@ -0,0 +1,47 @@
+package i18n
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"":                             ZH,
+		"en":                           EN,
+		"en-US,en;q=0.9":               EN,
+		"zh-CN,zh;q=0.9,en;q=0.8":      ZH,
+		"fr-FR,en;q=0.5":               EN,
+		"fr-FR":                        Default,
+		"invalid;;q=language":          Default,
+		"de-DE;q=0.9,en-US;q=0.8,*":    EN,
+		"en-GB;q=0.1,zh-Hans-CN;q=0.9": ZH,
+	}
+	for acceptLanguage, expected := range tests {
+		if locale := Match(acceptLanguage); locale != expected {
+			t.Errorf("%q expected %s, got %s", acceptLanguage, expected, locale)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if message := Message(EN, 11000, ""); message != "User does not exist" {
+		t.Fatalf("unexpected message %s", message)
+	}
+	if message := Message("fr", 11000, ""); message != "用户不存在" {
+		t.Fatalf("unexpected message %s", message)
+	}
+	if message := Message(EN, 99999, "fallback"); message != "fallback" {
+		t.Fatalf("unexpected message %s", message)
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if language := Environ(); language != "en-US" || Match(language) != EN {
+		t.Fatalf("unexpected language %s", language)
+	}
+	t.Setenv("LC_ALL", "C")
+	if language := Environ(); language != "" {
+		t.Fatalf("unexpected language %s", language)
+	}
+}
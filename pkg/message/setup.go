@@ -6,16 +6,25 @@ package message
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 
 	"github.com/ThreeDotsLabs/watermill"
+	wmetrics "github.com/ThreeDotsLabs/watermill/components/metrics"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/crochee/lirity"
 	"github.com/crochee/lirity/mq"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+
+	"caty/pkg/metrics"
 )
 
-var router *message.Router
+var (
+	router *message.Router
+	// client 当前的mq连接，供就绪检查使用
+	client atomic.Value
+)
 
 // Setup run message pub/sub or not
 func Setup(ctx context.Context) error {
@@ -28,14 +37,33 @@ func Setup(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	// 消息发布与处理追踪
+	router.AddPublisherDecorators(TracePublisher)
+	router.AddMiddleware(TraceHandler)
+	// 处理器、发布者与订阅者指标
+	wmetrics.NewPrometheusMetricsBuilder(metrics.Registry, metrics.Namespace, "message").
+		AddPrometheusRouterMetrics(router)
 	conn, err := mq.New(mq.WithURI(viper.GetString("rabbitmq.consumer.resource.URI")))
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
+	client.Store(conn)
 	return router.Run(ctx)
 }
 
+// Ping 检查mq连接是否可用
+func Ping(context.Context) error {
+	conn, ok := client.Load().(*mq.Client)
+	if !ok {
+		return errors.New("rabbitmq is not connected")
+	}
+	if conn.IsClosed() {
+		return errors.New("rabbitmq connection is closed")
+	}
+	return nil
+}
+
 func Close() {
 	if router == nil {
 		return
@@ -0,0 +1,77 @@
+package message
+
+import (
+	"github.com/ThreeDotsLabs/watermill/message"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"caty/pkg/tracex"
+)
+
+// TraceHandler 从消息元数据中恢复追踪上下文并为消息处理创建span，处理器产生的消息延续该追踪
+func TraceHandler(h message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		ctx := otel.GetTextMapPropagator().Extract(msg.Context(), propagation.MapCarrier(msg.Metadata))
+		topic := message.SubscribeTopicFromCtx(msg.Context())
+		ctx, span := tracex.Tracer().Start(ctx, topic+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKey.String("rabbitmq"),
+				semconv.MessagingDestinationKey.String(topic),
+				semconv.MessagingOperationProcess,
+				semconv.MessagingMessageIDKey.String(msg.UUID),
+			))
+		defer span.End()
+		msg.SetContext(ctx)
+		produced, err := h(msg)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return produced, err
+		}
+		for _, producedMsg := range produced {
+			producedMsg.SetContext(ctx)
+		}
+		return produced, nil
+	}
+}
+
+// TracePublisher 为消息发布创建span，并将追踪上下文写入消息元数据
+func TracePublisher(pub message.Publisher) (message.Publisher, error) {
+	return tracePublisher{Publisher: pub}, nil
+}
+
+type tracePublisher struct {
+	message.Publisher
+}
+
+func (t tracePublisher) Publish(topic string, messages ...*message.Message) error {
+	spans := make([]trace.Span, 0, len(messages))
+	for _, msg := range messages {
+		ctx, span := tracex.Tracer().Start(msg.Context(), topic+" send",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKey.String("rabbitmq"),
+				semconv.MessagingDestinationKey.String(topic),
+				semconv.MessagingMessageIDKey.String(msg.UUID),
+			))
+		if msg.Metadata == nil {
+			msg.Metadata = make(message.Metadata)
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(msg.Metadata))
+		msg.SetContext(ctx)
+		spans = append(spans, span)
+	}
+	err := t.Publisher.Publish(topic, messages...)
+	for _, span := range spans {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+	return err
+}
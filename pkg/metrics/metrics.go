@@ -0,0 +1,98 @@
+// Package metrics prometheus监控指标
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+)
+
+// Namespace 指标名前缀
+const Namespace = "caty"
+
+// 计数结果
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Registry 服务的指标注册表，包含Go运行时与进程指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests HTTP请求数，route为路由模板
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+	// HTTPDuration HTTP请求耗时
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	// Logins 登录次数
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "logins_total",
+		Help:      "Total number of logins by result.",
+	}, []string{"result"})
+	// TokensIssued 签发的token数
+	TokensIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "tokens_issued_total",
+		Help:      "Total number of issued tokens by result.",
+	}, []string{"result"})
+	// TokensParsed 解析的token数
+	TokensParsed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "tokens_parsed_total",
+		Help:      "Total number of parsed tokens by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		Logins,
+		TokensIssued,
+		TokensParsed,
+	)
+}
+
+// Result 将错误转换为计数结果
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// RegisterDB 注册数据库连接池指标
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler 指标查询接口
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Addr 独立的管理端口地址，为空时在业务端口提供 /metrics
+func Addr() string {
+	return viper.GetString("metrics.addr")
+}
@@ -7,15 +7,44 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/cors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"caty/config"
+	"caty/pkg/v"
 )
 
-// CrossDomain skip the cross-domain phase
-func CrossDomain() gin.HandlerFunc {
-	c := cors.New(cors.Options{
+// CorsPolicy 跨域策略，路由组的策略中未配置的字段继承默认策略
+type CorsPolicy struct {
+	// Prefix 路由组的路径前缀，如 /v1/auths，仅路由组策略使用
+	Prefix string `mapstructure:"prefix"`
+	// AllowedOrigins 允许的源，每项可包含一个通配符，如 https://*.example.com，* 表示所有源
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// AllowedMethods 允许的请求方法
+	AllowedMethods []string `mapstructure:"allowed_methods"`
+	// AllowedHeaders 允许携带的请求头
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+	// ExposedHeaders 浏览器可读取的响应头
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+	// AllowCredentials 是否允许携带凭证，不能与 * 源同时使用
+	AllowCredentials *bool `mapstructure:"allow_credentials"`
+	// MaxAge 预检结果的缓存时间
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// DefaultCorsPolicy 未配置时的默认跨域策略
+func DefaultCorsPolicy() *CorsPolicy {
+	allowCredentials := false
+	return &CorsPolicy{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{
 			http.MethodHead,
@@ -26,15 +55,152 @@ func CrossDomain() gin.HandlerFunc {
 			http.MethodDelete,
 			http.MethodOptions,
 		},
-		AllowedHeaders: []string{"Content-Type", "X-Trace-Id", "X-Auth-Token"},
-		MaxAge:         24 * 60 * 60,
+		AllowedHeaders: []string{"Content-Type", "If-Match", "If-None-Match", "traceparent", "tracestate",
+			v.XTraceID, v.XAuthToken, v.XIdempotencyKey},
+		ExposedHeaders: []string{"ETag", v.XTraceID, v.XIdempotencyReplayed,
+			v.XRateLimitLimit, v.XRateLimitRemaining, v.XRateLimitReset, v.XRetryAfter},
+		AllowCredentials: &allowCredentials,
+		MaxAge:           24 * time.Hour,
+	}
+}
+
+// inherit 使用 parent 补全未配置的字段
+func (p *CorsPolicy) inherit(parent *CorsPolicy) {
+	if p.AllowedOrigins == nil {
+		p.AllowedOrigins = parent.AllowedOrigins
+	}
+	if p.AllowedMethods == nil {
+		p.AllowedMethods = parent.AllowedMethods
+	}
+	if p.AllowedHeaders == nil {
+		p.AllowedHeaders = parent.AllowedHeaders
+	}
+	if p.ExposedHeaders == nil {
+		p.ExposedHeaders = parent.ExposedHeaders
+	}
+	if p.AllowCredentials == nil {
+		p.AllowCredentials = parent.AllowCredentials
+	}
+	if p.MaxAge == 0 {
+		p.MaxAge = parent.MaxAge
+	}
+}
+
+// Validate 校验跨域策略
+func (p *CorsPolicy) Validate() error {
+	for _, origin := range p.AllowedOrigins {
+		if origin == "*" && p.AllowCredentials != nil && *p.AllowCredentials {
+			return fmt.Errorf("cors policy %q must not allow credentials with origin *", p.Prefix)
+		}
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("cors policy %q origin %s has more than one wildcard", p.Prefix, origin)
+		}
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("cors policy %q max_age must not be negative", p.Prefix)
+	}
+	return nil
+}
+
+func (p *CorsPolicy) cors() *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins:   p.AllowedOrigins,
+		AllowedMethods:   p.AllowedMethods,
+		AllowedHeaders:   p.AllowedHeaders,
+		ExposedHeaders:   p.ExposedHeaders,
+		AllowCredentials: *p.AllowCredentials,
+		MaxAge:           int(p.MaxAge.Seconds()),
 	})
-	return func(ctx *gin.Context) {
-		c.HandlerFunc(ctx.Writer, ctx.Request)
-		if ctx.Request.Method == http.MethodOptions &&
-			ctx.GetHeader("Access-Control-Request-Method") != "" {
-			// Abort processing next Gin middlewares.
-			ctx.AbortWithStatus(http.StatusOK)
+}
+
+// CorsPolicies 读取配置 v 中的默认跨域策略与路由组策略，路由组按前缀由长到短排列
+func CorsPolicies(v *viper.Viper) (*CorsPolicy, []*CorsPolicy, error) {
+	policy := &CorsPolicy{}
+	if err := v.UnmarshalKey("cors", policy); err != nil {
+		return nil, nil, err
+	}
+	policy.Prefix = ""
+	policy.inherit(DefaultCorsPolicy())
+	if err := policy.Validate(); err != nil {
+		return nil, nil, err
+	}
+	var groups []*CorsPolicy
+	if err := v.UnmarshalKey("cors.groups", &groups); err != nil {
+		return nil, nil, err
+	}
+	for _, group := range groups {
+		if !strings.HasPrefix(group.Prefix, "/") {
+			return nil, nil, fmt.Errorf("cors group prefix %q must start with /", group.Prefix)
+		}
+		group.inherit(policy)
+		if err := group.Validate(); err != nil {
+			return nil, nil, err
 		}
 	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Prefix) > len(groups[j].Prefix) })
+	return policy, groups, nil
+}
+
+type corsGroup struct {
+	prefix string
+	cors   *cors.Cors
+}
+
+type corsRules struct {
+	cors   *cors.Cors
+	groups []corsGroup
+}
+
+// match 按请求路径选择最长前缀匹配的路由组策略，无匹配时使用默认策略
+func (r *corsRules) match(path string) *cors.Cors {
+	for _, group := range r.groups {
+		if path == group.prefix || strings.HasPrefix(path, strings.TrimSuffix(group.prefix, "/")+"/") {
+			return group.cors
+		}
+	}
+	return r.cors
+}
+
+type crossDomain struct {
+	rules atomic.Value
+}
+
+// reload 从配置 v 重新读取跨域策略，配置错误时保留原有策略
+func (c *crossDomain) reload(v *viper.Viper) error {
+	policy, groups, err := CorsPolicies(v)
+	if err != nil {
+		return err
+	}
+	rules := &corsRules{
+		cors:   policy.cors(),
+		groups: make([]corsGroup, 0, len(groups)),
+	}
+	for _, group := range groups {
+		rules.groups = append(rules.groups, corsGroup{prefix: group.Prefix, cors: group.cors()})
+	}
+	c.rules.Store(rules)
+	return nil
+}
+
+func (c *crossDomain) handle(ctx *gin.Context) {
+	c.rules.Load().(*corsRules).match(ctx.Request.URL.Path).HandlerFunc(ctx.Writer, ctx.Request)
+	if ctx.Request.Method == http.MethodOptions &&
+		ctx.GetHeader("Access-Control-Request-Method") != "" {
+		// Abort processing next Gin middlewares.
+		ctx.AbortWithStatus(http.StatusOK)
+	}
+}
+
+// CrossDomain 按配置的跨域策略处理跨域请求，配置文件变更后自动生效，启动时配置错误会panic
+func CrossDomain() gin.HandlerFunc {
+	c := &crossDomain{}
+	if err := c.reload(viper.GetViper()); err != nil {
+		panic(err)
+	}
+	config.OnChange(func(v *viper.Viper) {
+		if err := c.reload(v); err != nil {
+			zap.S().Errorf("reload cors policy failed, keep the previous one.Error:%+v", err)
+		}
+	})
+	return c.handle
 }
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func corsRequest(router *gin.Engine, method, path, origin string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, nil)
+	request.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	return w
+}
+
+func TestCrossDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 使用独立的配置，不修改全局配置，也不注册全局的配置变更回调
+	cfg := viper.New()
+	cfg.Set("cors", map[string]interface{}{
+		"allowed_origins":   []string{"https://*.example.com"},
+		"allow_credentials": true,
+		"groups": []map[string]interface{}{
+			{"prefix": "/v1/auths", "allowed_origins": []string{"https://login.example.com"}},
+		},
+	})
+	c := &crossDomain{}
+	if err := c.reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(c.handle)
+	router.GET("/v1/accounts", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/v1/auths", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	w := corsRequest(router, http.MethodGet, "/v1/accounts", "https://console.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://console.example.com" ||
+		w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		w.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	w = corsRequest(router, http.MethodGet, "/v1/accounts", "https://evil.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	w = corsRequest(router, http.MethodOptions, "/v1/accounts", "https://console.example.com")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Max-Age") != "86400" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	// 路由组策略覆盖默认策略
+	w = corsRequest(router, http.MethodGet, "/v1/auths", "https://console.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	w = corsRequest(router, http.MethodGet, "/v1/auths", "https://login.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://login.example.com" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+
+	// 热加载后新策略生效，错误的配置不会替换原有策略
+	cfg = viper.New()
+	cfg.Set("cors", map[string]interface{}{"allowed_origins": []string{"*"}, "allow_credentials": true})
+	if err := c.reload(cfg); err == nil {
+		t.Fatal("expected error for wildcard origin with credentials")
+	}
+	w = corsRequest(router, http.MethodGet, "/v1/accounts", "https://evil.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	cfg = viper.New()
+	cfg.Set("cors", map[string]interface{}{"allowed_origins": []string{"https://evil.com"}})
+	if err := c.reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	w = corsRequest(router, http.MethodGet, "/v1/accounts", "https://evil.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://evil.com" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/logger"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/idempotency"
+	"caty/pkg/v"
+)
+
+// Idempotency 携带Idempotency-Key的POST请求在保留期限内只处理一次，重复请求返回首次的响应
+func Idempotency(ctx *gin.Context) {
+	key := ctx.GetHeader(v.XIdempotencyKey)
+	if key == "" || ctx.Request.Method != http.MethodPost {
+		ctx.Next()
+		return
+	}
+	if len(key) > idempotency.MaxKeyLength {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(
+			fmt.Sprintf("%s's length is more than %d", v.XIdempotencyKey, idempotency.MaxKeyLength)))
+		return
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		code.Code(ctx, e.ErrInvalidParam.WithResult(err))
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	path := ctx.Request.URL.Path
+	record, replay, err := idempotency.Begin(ctx.Request.Context(),
+		idempotency.Caller(ctx.Request.Context(), ctx.ClientIP()), key, ctx.Request.Method, path,
+		idempotency.Fingerprint(ctx.Request.Method, path, body))
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	if replay {
+		header, err := idempotency.Header(record)
+		if err != nil {
+			code.Error(ctx, err)
+			return
+		}
+		for key, values := range header {
+			ctx.Writer.Header()[key] = values
+		}
+		ctx.Header(v.XIdempotencyReplayed, "true")
+		ctx.Abort()
+		if len(record.Body) == 0 {
+			ctx.Status(record.Status)
+			return
+		}
+		ctx.Data(record.Status, record.ContentType, record.Body)
+		return
+	}
+	// 之前的中间件设置的响应头（如X-Trace-Id、RateLimit-*）与本次请求相关，不保存
+	inherited := ctx.Writer.Header().Clone()
+	writer := &bodyWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = writer
+	defer func() {
+		if r := recover(); r != nil {
+			release(ctx, record)
+			panic(r)
+		}
+	}()
+	ctx.Next()
+	// 服务端错误不保存，允许使用相同的幂等键重试
+	if writer.Status() >= http.StatusInternalServerError {
+		release(ctx, record)
+		return
+	}
+	if err = idempotency.Complete(ctx.Request.Context(), record, writer.Status(),
+		handlerHeader(writer.Header(), inherited), writer.body.Bytes()); err != nil {
+		logger.From(ctx.Request.Context()).Sugar().Errorf("%+v", err)
+	}
+}
+
+func release(ctx *gin.Context, record *model.Idempotency) {
+	if err := idempotency.Release(ctx.Request.Context(), record); err != nil {
+		logger.From(ctx.Request.Context()).Sugar().Errorf("%+v", err)
+	}
+}
+
+// handlerHeader 返回处理器设置的响应头
+func handlerHeader(header, inherited http.Header) http.Header {
+	result := http.Header{}
+	for key, values := range header {
+		if _, ok := inherited[key]; ok || key == "Content-Length" || key == "Date" {
+			continue
+		}
+		result[key] = values
+	}
+	return result
+}
+
+// bodyWriter 记录响应内容
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (b *bodyWriter) Write(data []byte) (int, error) {
+	b.body.Write(data)
+	return b.ResponseWriter.Write(data)
+}
+
+func (b *bodyWriter) WriteString(s string) (int, error) {
+	b.body.WriteString(s)
+	return b.ResponseWriter.WriteString(s)
+}
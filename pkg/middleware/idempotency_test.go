@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/idempotency"
+	"caty/pkg/v"
+)
+
+func TestIdempotency(t *testing.T) {
+	if err := code.Loading(); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	var handled int
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Header(v.XTraceID, "trace")
+	}, Idempotency)
+	router.POST("/v1/accounts", func(ctx *gin.Context) {
+		handled++
+		ctx.Header("ETag", `"1"`)
+		ctx.Header("Location", "/v1/accounts/1")
+		ctx.JSON(http.StatusOK, gin.H{"id": "1"})
+	})
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/accounts", strings.NewReader(body))
+		req.Header.Set(v.XIdempotencyKey, "key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	query := regexp.QuoteMeta("SELECT * FROM `idempotency` WHERE caller =? AND idempotency_key =? AND method =? AND path =?")
+	columns := []string{"id", "caller", "idempotency_key", "method", "path", "fingerprint", "status",
+		"content_type", "headers", "body", "expired_at", "created_at"}
+	fingerprint := idempotency.Fingerprint(http.MethodPost, "/v1/accounts", []byte(`{"name":"caty"}`))
+	now := time.Now()
+
+	// 首次请求登记并保存响应
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WithArgs("ip:192.0.2.1", "key", http.MethodPost, "/v1/accounts").
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `idempotency`")).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `idempotency` SET")).
+		WithArgs([]byte(`{"id":"1"}`), "application/json; charset=utf-8",
+			`{"Content-Type":["application/json; charset=utf-8"],"Etag":["\"1\""],"Location":["/v1/accounts/1"]}`,
+			http.StatusOK, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if w := request(`{"name":"caty"}`); w.Code != http.StatusOK || w.Header().Get(v.XIdempotencyReplayed) != "" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	// 重复请求返回首次的响应
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "ip:192.0.2.1", "key",
+		http.MethodPost, "/v1/accounts", fingerprint, http.StatusOK, "application/json; charset=utf-8",
+		`{"Etag":["\"1\""],"Location":["/v1/accounts/1"]}`, []byte(`{"id":"1"}`), now.Add(time.Hour), now))
+	mock.ExpectCommit()
+	w := request(`{"name":"caty"}`)
+	if w.Code != http.StatusOK || w.Header().Get(v.XIdempotencyReplayed) != "true" || w.Body.String() != `{"id":"1"}` ||
+		w.Header().Get("ETag") != `"1"` || w.Header().Get("Location") != "/v1/accounts/1" {
+		t.Fatalf("unexpected response %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	// 相同幂等键不同请求体
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "ip:192.0.2.1", "key",
+		http.MethodPost, "/v1/accounts", fingerprint, http.StatusOK, "application/json; charset=utf-8",
+		"{}", []byte(`{"id":"1"}`), now.Add(time.Hour), now))
+	mock.ExpectRollback()
+	if w = request(`{"name":"other"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	// 相同幂等键的请求正在处理
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `idempotency`")).
+		WillReturnError(errors.New("Error 1062: Duplicate entry"))
+	mock.ExpectRollback()
+	if w = request(`{"name":"caty"}`); w.Code != http.StatusConflict {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if handled != 1 {
+		t.Fatalf("handler called %d times", handled)
+	}
+}
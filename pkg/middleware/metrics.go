@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/metrics"
+)
+
+// Metrics 按路由模板统计请求数与耗时，未匹配的路由统一记为 unmatched 以免标签基数膨胀
+func Metrics(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(ctx.Writer.Status())
+	metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+	metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route, status).
+		Observe(time.Since(start).Seconds())
+}
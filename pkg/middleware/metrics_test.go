@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"caty/pkg/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics)
+	router.GET("/v1/accounts/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+	for _, path := range []string{"/v1/accounts/1", "/v1/accounts/2", "/none"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet,
+		"/v1/accounts/:id", "204")); got != 2 {
+		t.Fatalf("want 2 got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet,
+		"unmatched", "404")); got != 1 {
+		t.Fatalf("want 1 got %v", got)
+	}
+}
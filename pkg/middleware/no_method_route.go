@@ -7,14 +7,16 @@ package middleware
 import (
 	"github.com/crochee/lirity/e"
 	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
 )
 
 // NoRoute 404
 func NoRoute(ctx *gin.Context) {
-	e.Code(ctx, e.ErrNotFound)
+	code.Code(ctx, e.ErrNotFound)
 }
 
 // NoMethod 405
 func NoMethod(ctx *gin.Context) {
-	e.Code(ctx, e.ErrNotAllowMethod)
+	code.Code(ctx, e.ErrNotAllowMethod)
 }
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/logger"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/openapi"
+)
+
+// OpenAPI debug模式下按内嵌的OpenAPI文档校验请求与响应，未启用或非debug模式时直接放行，文档无法加载时panic
+func OpenAPI() gin.HandlerFunc {
+	if !gin.IsDebugging() || !openapi.Validate() {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+	doc, err := openapi.Spec()
+	if err != nil {
+		panic(err)
+	}
+	return NewOpenAPI(doc)
+}
+
+// NewOpenAPI 按文档 doc 校验请求与响应，请求不符合文档时返回参数错误，响应不符合文档时仅记录日志
+func NewOpenAPI(doc *openapi3.T) gin.HandlerFunc {
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	return func(ctx *gin.Context) {
+		route := openapi.FindRoute(doc, ctx.Request.Method, ctx.FullPath())
+		if route == nil {
+			ctx.Next()
+			return
+		}
+		pathParams := make(map[string]string, len(ctx.Params))
+		for _, param := range ctx.Params {
+			pathParams[param.Key] = param.Value
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    ctx.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(ctx.Request.Context(), input); err != nil {
+			code.Code(ctx, e.ErrInvalidParam.WithResult(err.Error()))
+			return
+		}
+		writer := &bodyWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+		if err := openapi3filter.ValidateResponse(ctx.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+			Options:                options,
+		}); err != nil {
+			logger.From(ctx.Request.Context()).Sugar().Warnf("%s %s response does not match the openapi spec.Error:%v",
+				ctx.Request.Method, route.Path, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/openapi"
+)
+
+func TestOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc, err := openapi.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(NewOpenAPI(doc))
+	router.POST("/v1/accounts/login", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/v1/groups", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	// 未写入文档的路由不校验
+	router.POST("/undocumented", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodPost, "/v1/accounts/login", `{"user_id":"1","password":"secret"}`, http.StatusOK},
+		{http.MethodPost, "/v1/accounts/login", `{"user_id":"1"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/accounts/login", `{"user_id":1,"password":"secret"}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/groups?size=20", "", http.StatusOK},
+		{http.MethodGet, "/v1/groups?size=twenty", "", http.StatusBadRequest},
+		{http.MethodPost, "/undocumented", `{}`, http.StatusOK},
+	} {
+		request := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		if w.Code != tc.code {
+			t.Errorf("%s %s %s expected %d, got %d %s", tc.method, tc.path, tc.body, tc.code, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/ratelimit"
+	"caty/pkg/v"
+)
+
+// rateLimitReport 上下文中记录已返回的最严格的限流结果
+const rateLimitReport = "rate_limit_report"
+
+// RateLimit 按配置的规则对请求限流，未启用时直接放行，规则或存储配置错误时panic
+// 按客户端IP限流的中间件须在 Token 之前使用，避免携带伪造token的请求绕过限流并消耗token校验；
+// 按用户限流的中间件须在 Token 之后使用，只信任已校验的token中的用户
+func RateLimit() (byIP, byUser gin.HandlerFunc) {
+	if !ratelimit.Enabled() {
+		next := func(ctx *gin.Context) {
+			ctx.Next()
+		}
+		return next, next
+	}
+	store, ipRules, userRules, err := ratelimit.Load()
+	if err != nil {
+		panic(err)
+	}
+	return NewRateLimit(store, ipRules), NewRateLimit(store, userRules)
+}
+
+// NewRateLimit 使用指定的存储与规则限流，多条规则匹配时任一规则拒绝即拒绝
+func NewRateLimit(store ratelimit.Store, rules []*ratelimit.Rule) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			ctx.Next()
+			return
+		}
+		report := ratelimit.Apply(ctx.Request.Context(), store, rules, ctx.Request.Method, route,
+			func(key string) string {
+				return limitKey(ctx, key)
+			})
+		if report == nil {
+			ctx.Next()
+			return
+		}
+		// 多个限流中间件时响应头返回最严格的结果
+		if previous, ok := ctx.Get(rateLimitReport); ok && !ratelimit.Restrictive(report, previous.(*ratelimit.Result)) {
+			ctx.Next()
+			return
+		}
+		ctx.Set(rateLimitReport, report)
+		ctx.Header(v.XRateLimitLimit, strconv.Itoa(report.Limit))
+		ctx.Header(v.XRateLimitRemaining, strconv.Itoa(report.Remaining))
+		ctx.Header(v.XRateLimitReset, ratelimit.CeilSeconds(report.Reset))
+		if !report.Allowed {
+			ctx.Header(v.XRetryAfter, ratelimit.CeilSeconds(report.RetryAfter))
+			code.Code(ctx, code.ErrRateLimited)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// limitKey 请求在限流维度上的取值，只使用已校验的token中的用户，缺少token时按客户端IP限流
+func limitKey(ctx *gin.Context, key string) string {
+	if key == ratelimit.KeyUser {
+		if token := auth.GetToken(ctx.Request.Context()); token != nil {
+			return "user:" + token.UserID
+		}
+	}
+	return "ip:" + ctx.ClientIP()
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/ratelimit"
+	"caty/pkg/v"
+)
+
+func TestRateLimit(t *testing.T) {
+	if err := code.Loading(); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	rule := &ratelimit.Rule{Route: "/v1/accounts", Method: http.MethodPost, Key: ratelimit.KeyIP,
+		Requests: 1, Period: time.Minute}
+	if err := rule.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(NewRateLimit(ratelimit.NewMemoryStore(), []*ratelimit.Rule{rule}))
+	router.POST("/v1/accounts", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/v1/accounts", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/accounts", nil))
+	if w.Code != http.StatusOK || w.Header().Get(v.XRateLimitLimit) != "1" ||
+		w.Header().Get(v.XRateLimitRemaining) != "0" || w.Header().Get(v.XRateLimitReset) != "60" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/accounts", nil))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get(v.XRetryAfter) != "60" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	// 未经校验的查询参数不改变限流维度
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/accounts?ak=other", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	// 未匹配规则的请求不限流
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/accounts", nil))
+	if w.Code != http.StatusOK || w.Header().Get(v.XRateLimitLimit) != "" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitStages(t *testing.T) {
+	if err := code.Loading(); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	ipRule := &ratelimit.Rule{Route: "/v1/accounts", Key: ratelimit.KeyIP, Requests: 1, Period: time.Minute}
+	userRule := &ratelimit.Rule{Route: "/v1/accounts", Key: ratelimit.KeyUser, Requests: 10, Period: time.Minute}
+	for _, rule := range []*ratelimit.Rule{ipRule, userRule} {
+		if err := rule.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := ratelimit.NewMemoryStore()
+	router := gin.New()
+	// 按IP限流在校验token之前，无效token的请求同样计入
+	router.Use(NewRateLimit(store, []*ratelimit.Rule{ipRule}), func(ctx *gin.Context) {
+		code.Code(ctx, code.ErrNoAuth)
+	}, NewRateLimit(store, []*ratelimit.Rule{userRule}))
+	router.GET("/v1/accounts", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/accounts", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get(v.XRateLimitRemaining) != "0" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/accounts", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/pkg/errors"
 
 	"caty/internal"
+	"caty/pkg/code"
 )
 
 // Recovery panic logx
@@ -52,7 +53,7 @@ func Recovery(ctx *gin.Context) {
 			if brokenPipe {
 				extra = fmt.Sprintf("broken pipe or connection reset by peer;%v", r)
 			}
-			e.Code(ctx, e.ErrInternalServerError.WithResult(extra))
+			code.Code(ctx, e.ErrInternalServerError.WithResult(extra))
 		}
 	}()
 	ctx.Next()
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// Token parse X-Auth-Token and add token to context, all_tenants=true 时请求跨租户访问
+func Token(ctx *gin.Context) {
+	apiToken := ctx.GetHeader(v.XAuthToken)
+	if apiToken == "" {
+		ctx.Next()
+		return
+	}
+	claims, err := auth.Parse(ctx.Request.Context(), &auth.APIToken{Token: apiToken})
+	if err != nil {
+		code.Error(ctx, err)
+		return
+	}
+	ctx.Set("token", claims.Token)
+	requestCtx := auth.SetToken(ctx.Request.Context(), claims.Token)
+	if allTenants, _ := strconv.ParseBool(ctx.Query("all_tenants")); allTenants {
+		requestCtx = auth.SetAllTenants(requestCtx, true)
+	}
+	ctx.Request = ctx.Request.WithContext(requestCtx)
+
+	ctx.Next()
+}
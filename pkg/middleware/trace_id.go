@@ -7,20 +7,55 @@ package middleware
 import (
 	"github.com/crochee/lirity/id"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
 
+	"caty/pkg/tracex"
 	"caty/pkg/v"
 )
 
-// TraceID add trace_id
+// TraceID add trace_id，并为请求创建服务端span
+// 上游携带 traceparent 时延续其追踪，否则以 X-Trace-Id 作为新追踪的ID；未携带 X-Trace-Id 时使用追踪ID
 func TraceID(ctx *gin.Context) {
+	requestCtx := otel.GetTextMapPropagator().Extract(ctx.Request.Context(),
+		propagation.HeaderCarrier(ctx.Request.Header))
 	tracedID := ctx.Request.Header.Get(v.XTraceID)
 	if tracedID == "" {
-		tracedID = id.UV4()
+		if spanContext := trace.SpanContextFromContext(requestCtx); spanContext.HasTraceID() {
+			tracedID = spanContext.TraceID().String()
+		} else {
+			tracedID = id.UV4()
+		}
 	}
+	if traceID, ok := tracex.ParseTraceID(tracedID); ok {
+		requestCtx = tracex.WithTraceID(requestCtx, traceID)
+	}
+
+	spanName := ctx.FullPath()
+	if spanName == "" {
+		spanName = "HTTP " + ctx.Request.Method
+	}
+	requestCtx, span := tracex.Tracer().Start(requestCtx, spanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(v.ServiceName, ctx.FullPath(),
+			ctx.Request)...),
+		trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", ctx.Request)...),
+	)
+	defer span.End()
+
 	ctx.Request.Header.Set(v.XTraceID, tracedID)  // 请求头
 	ctx.Writer.Header().Set(v.XTraceID, tracedID) // 响应头
 
-	ctx.Request = ctx.Request.WithContext(v.SetTraceID(ctx.Request.Context(), tracedID))
+	ctx.Request = ctx.Request.WithContext(v.SetTraceID(requestCtx, tracedID))
 
 	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+	if len(ctx.Errors) > 0 {
+		span.SetAttributes(semconv.ExceptionMessageKey.String(ctx.Errors.String()))
+	}
 }
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"caty/pkg/tracex"
+	"caty/pkg/v"
+)
+
+func TestTraceID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder),
+		sdktrace.WithIDGenerator(tracex.NewIDGenerator())))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TraceID)
+	router.GET("/v1/accounts/:id", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, v.GetTraceID(ctx.Request.Context()))
+	})
+
+	// 延续上游 traceparent 的追踪
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get(v.XTraceID); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("want trace id from traceparent got %s", got)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "/v1/accounts/:id" ||
+		spans[0].Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+
+	// 以 X-Trace-Id 作为新追踪的ID
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/1", nil)
+	req.Header.Set(v.XTraceID, "6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	router.ServeHTTP(w, req)
+	if got := w.Body.String(); got != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Fatalf("want X-Trace-Id kept got %s", got)
+	}
+	spans = recorder.Ended()
+	if len(spans) != 2 || spans[1].SpanContext().TraceID().String() != "6ba7b8109dad11d180b400c04fd430c8" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
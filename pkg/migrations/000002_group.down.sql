@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `group_user`;
+DROP TABLE IF EXISTS `user_group`;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS `user_group` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `name` varchar(255) COLLATE utf8mb4_bin NOT NULL COMMENT '用户组名',
+    `permission` json NOT NULL COMMENT '权限文本',
+    `desc` json NOT NULL COMMENT '详细描述',
+    `deleted` bigint(20) unsigned NOT NULL COMMENT '软删除记录id',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_account_id_name_deleted` (`account_id`,`name`,`deleted`),
+    KEY `idx_user_group_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='用户组信息表';
+
+CREATE TABLE IF NOT EXISTS `group_user` (
+    `id` bigint(20) unsigned NOT NULL,
+    `group_id` bigint(20) unsigned NOT NULL COMMENT '用户组ID',
+    `user_id` bigint(20) unsigned NOT NULL COMMENT '用户ID',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_group_id_user_id` (`group_id`,`user_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='用户组成员表';
@@ -0,0 +1 @@
+ALTER TABLE `user` ADD COLUMN `version` bigint(20) unsigned NOT NULL DEFAULT 1 COMMENT '版本号' AFTER `desc`;
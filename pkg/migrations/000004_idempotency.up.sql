@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `idempotency` (
+    `id` bigint(20) unsigned NOT NULL,
+    `caller` varchar(128) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '调用方',
+    `idempotency_key` varchar(255) COLLATE utf8mb4_bin NOT NULL COMMENT '幂等键',
+    `method` varchar(10) COLLATE utf8mb4_bin NOT NULL COMMENT '请求方法',
+    `path` varchar(255) COLLATE utf8mb4_bin NOT NULL COMMENT '请求路径',
+    `fingerprint` char(64) COLLATE utf8mb4_bin NOT NULL COMMENT '请求指纹',
+    `status` int(11) NOT NULL DEFAULT 0 COMMENT '响应状态码',
+    `content_type` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '响应类型',
+    `headers` json NOT NULL COMMENT '响应头',
+    `body` mediumblob COMMENT '响应内容',
+    `expired_at` datetime(3) NOT NULL COMMENT '过期时间',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_caller_key_method_path` (`caller`,`idempotency_key`,`method`,`path`),
+    KEY `idx_expired_at` (`expired_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='幂等请求记录表';
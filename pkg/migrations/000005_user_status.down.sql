@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `event`;
+
+ALTER TABLE `user` DROP COLUMN `status_reason`, DROP COLUMN `status`;
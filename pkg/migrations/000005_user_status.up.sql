@@ -0,0 +1,17 @@
+ALTER TABLE `user`
+    ADD COLUMN `status` varchar(20) COLLATE utf8mb4_bin NOT NULL DEFAULT 'active' COMMENT '状态' AFTER `version`,
+    ADD COLUMN `status_reason` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '状态变更原因' AFTER `status`;
+
+CREATE TABLE IF NOT EXISTS `event` (
+    `id` bigint(20) unsigned NOT NULL,
+    `type` varchar(64) COLLATE utf8mb4_bin NOT NULL COMMENT '事件类型',
+    `resource_type` varchar(64) COLLATE utf8mb4_bin NOT NULL COMMENT '资源类型',
+    `resource_id` bigint(20) unsigned NOT NULL COMMENT '资源ID',
+    `actor_id` bigint(20) unsigned NOT NULL COMMENT '操作人ID',
+    `trace_id` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '请求追踪ID',
+    `payload` json NOT NULL COMMENT '事件内容',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_type` (`type`),
+    KEY `idx_resource` (`resource_type`,`resource_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='审计事件表';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `profile_schema`;
+
+ALTER TABLE `user` DROP COLUMN `attributes`;
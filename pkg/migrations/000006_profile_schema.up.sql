@@ -0,0 +1,13 @@
+ALTER TABLE `user` ADD COLUMN `attributes` json DEFAULT NULL COMMENT '用户属性' AFTER `desc`;
+UPDATE `user` SET `attributes` = JSON_OBJECT() WHERE `attributes` IS NULL;
+ALTER TABLE `user` MODIFY COLUMN `attributes` json NOT NULL COMMENT '用户属性';
+
+CREATE TABLE IF NOT EXISTS `profile_schema` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `schema` json NOT NULL COMMENT '用户属性JSON Schema',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_account_id` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='用户属性模式表';
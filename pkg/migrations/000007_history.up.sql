@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `history` (
+    `id` bigint(20) unsigned NOT NULL,
+    `resource_type` varchar(64) COLLATE utf8mb4_bin NOT NULL COMMENT '资源类型',
+    `resource_id` bigint(20) unsigned NOT NULL COMMENT '资源ID',
+    `version` bigint(20) unsigned NOT NULL COMMENT '历史版本号',
+    `action` varchar(20) COLLATE utf8mb4_bin NOT NULL COMMENT '操作类型',
+    `changes` json NOT NULL COMMENT '字段变更',
+    `actor_id` bigint(20) unsigned NOT NULL COMMENT '操作人ID',
+    `trace_id` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '请求追踪ID',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_resource_version` (`resource_type`,`resource_id`,`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='变更历史表';
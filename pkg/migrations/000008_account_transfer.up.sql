@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `account_transfer` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `from_user_id` bigint(20) unsigned NOT NULL COMMENT '原主账号用户ID',
+    `to_user_id` bigint(20) unsigned NOT NULL COMMENT '目标用户ID',
+    `status` varchar(20) COLLATE utf8mb4_bin NOT NULL COMMENT '转让状态',
+    `actor_id` bigint(20) unsigned NOT NULL COMMENT '发起人ID',
+    `expired_at` datetime(3) NOT NULL COMMENT '过期时间',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_account_id` (`account_id`),
+    KEY `idx_to_user_id` (`to_user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='主账号转让表';
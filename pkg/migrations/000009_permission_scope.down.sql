@@ -0,0 +1,2 @@
+-- 无法区分原有的 * 权限与 caty 权限，不可回滚，全局管理员需使用 migrate grant-admin 重新授予
+SELECT 1;
@@ -0,0 +1,12 @@
+-- 全局 * 权限仅限全局管理员，原有用户与用户组的 * 权限收敛为 caty 服务权限
+UPDATE `user`
+SET `permission` = JSON_REMOVE(JSON_SET(`permission`, '$.caty',
+    GREATEST(CAST(JSON_EXTRACT(`permission`, '$."*"') AS UNSIGNED),
+             COALESCE(CAST(JSON_EXTRACT(`permission`, '$.caty') AS UNSIGNED), 0))), '$."*"')
+WHERE JSON_CONTAINS_PATH(`permission`, 'one', '$."*"');
+
+UPDATE `user_group`
+SET `permission` = JSON_REMOVE(JSON_SET(`permission`, '$.caty',
+    GREATEST(CAST(JSON_EXTRACT(`permission`, '$."*"') AS UNSIGNED),
+             COALESCE(CAST(JSON_EXTRACT(`permission`, '$.caty') AS UNSIGNED), 0))), '$."*"')
+WHERE JSON_CONTAINS_PATH(`permission`, 'one', '$."*"');
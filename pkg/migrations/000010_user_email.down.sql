@@ -0,0 +1 @@
+ALTER TABLE `user` DROP INDEX `idx_email`;
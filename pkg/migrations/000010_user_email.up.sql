@@ -0,0 +1 @@
+ALTER TABLE `user` ADD INDEX `idx_email` (`email`);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `account_erasure` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `user_id` bigint(20) unsigned NOT NULL COMMENT '用户ID',
+    `status` varchar(20) COLLATE utf8mb4_bin NOT NULL COMMENT '擦除状态',
+    `actor_id` bigint(20) unsigned NOT NULL COMMENT '申请人ID',
+    `report` json NOT NULL COMMENT '完成报告',
+    `completed_at` datetime(3) DEFAULT NULL COMMENT '完成时间',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_account_id` (`account_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='用户数据擦除表';
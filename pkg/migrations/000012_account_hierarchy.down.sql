@@ -0,0 +1,6 @@
+ALTER TABLE `account`
+    DROP INDEX `idx_path`,
+    DROP INDEX `idx_parent_id`,
+    DROP COLUMN `depth`,
+    DROP COLUMN `path`,
+    DROP COLUMN `parent_id`;
@@ -0,0 +1,9 @@
+ALTER TABLE `account`
+    ADD COLUMN `parent_id` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT '上级账户ID' AFTER `name`,
+    ADD COLUMN `path` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '账户路径' AFTER `parent_id`,
+    ADD COLUMN `depth` tinyint(3) unsigned NOT NULL DEFAULT 1 COMMENT '账户层级深度' AFTER `path`,
+    ADD INDEX `idx_parent_id` (`parent_id`),
+    ADD INDEX `idx_path` (`path`);
+
+-- 已有账户均为根账户，account_id 语义不变
+UPDATE `account` SET `path` = CONCAT('/', `id`, '/');
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `account_quota` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `resource` varchar(64) COLLATE utf8mb4_bin NOT NULL COMMENT '配额资源',
+    `limit` bigint(20) NOT NULL COMMENT '配额上限，0表示不限制',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_account_id_resource` (`account_id`,`resource`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='账户配额表';
+
+CREATE TABLE IF NOT EXISTS `session` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `user_id` bigint(20) unsigned NOT NULL COMMENT '用户ID',
+    `trace_id` varchar(64) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '请求追踪ID',
+    `expired_at` datetime(3) NOT NULL COMMENT '过期时间',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_account_id_expired_at` (`account_id`,`expired_at`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='登录会话表';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `label_binding`;
+
+ALTER TABLE `user` DROP COLUMN `labels`;
@@ -0,0 +1,19 @@
+ALTER TABLE `user` ADD COLUMN `labels` json DEFAULT NULL COMMENT '标签' AFTER `attributes`;
+UPDATE `user` SET `labels` = JSON_OBJECT() WHERE `labels` IS NULL;
+ALTER TABLE `user` MODIFY COLUMN `labels` json NOT NULL COMMENT '标签';
+
+CREATE TABLE IF NOT EXISTS `label_binding` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `name` varchar(255) COLLATE utf8mb4_bin NOT NULL COMMENT '授权名',
+    `selector` varchar(1024) COLLATE utf8mb4_bin NOT NULL COMMENT '标签选择器',
+    `permission` json NOT NULL COMMENT '权限文本',
+    `desc` json NOT NULL COMMENT '详细描述',
+    `deleted` bigint(20) unsigned NOT NULL COMMENT '软删除记录id',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_account_id_name_deleted` (`account_id`,`name`,`deleted`),
+    KEY `idx_label_binding_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='标签授权表';
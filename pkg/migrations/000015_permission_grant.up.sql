@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `permission_grant` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `user_id` bigint(20) unsigned NOT NULL COMMENT '用户ID',
+    `permission` json NOT NULL COMMENT '权限文本',
+    `reason` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '授权原因',
+    `actor_id` bigint(20) unsigned NOT NULL COMMENT '授权人ID',
+    `not_before` datetime(3) NOT NULL COMMENT '生效时间',
+    `not_after` datetime(3) NOT NULL COMMENT '失效时间',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_account_id` (`account_id`),
+    KEY `idx_user_id_not_after` (`user_id`,`not_after`),
+    KEY `idx_not_after` (`not_after`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='限时授权表';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `access_request` (
+    `id` bigint(20) unsigned NOT NULL,
+    `account_id` bigint(20) unsigned NOT NULL COMMENT '账号ID',
+    `user_id` bigint(20) unsigned NOT NULL COMMENT '申请人ID',
+    `service` varchar(64) COLLATE utf8mb4_bin NOT NULL COMMENT '申请的服务',
+    `action` tinyint(3) unsigned NOT NULL COMMENT '申请的操作权限',
+    `justification` varchar(1024) COLLATE utf8mb4_bin NOT NULL COMMENT '申请理由',
+    `duration` bigint(20) NOT NULL COMMENT '申请时长，单位秒',
+    `status` varchar(20) COLLATE utf8mb4_bin NOT NULL COMMENT '申请状态',
+    `approver_id` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT '审批人ID',
+    `decision_reason` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '审批意见',
+    `grant_id` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT '批准后创建的限时授权ID',
+    `decided_at` datetime(3) DEFAULT NULL COMMENT '审批时间',
+    `created_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) COMMENT '创建时间',
+    `updated_at` datetime(3) NOT NULL DEFAULT current_timestamp(3) ON UPDATE current_timestamp(3) COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_account_id_status` (`account_id`,`status`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='权限申请表';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `rate_limit` (
+    `id` bigint(20) unsigned NOT NULL,
+    `bucket_key` char(64) COLLATE utf8mb4_bin NOT NULL COMMENT '令牌桶标识的sha256',
+    `tokens` double NOT NULL COMMENT '剩余令牌数',
+    `refilled_at` datetime(6) NOT NULL COMMENT '上次补充令牌的时间',
+    `expired_at` datetime(3) NOT NULL COMMENT '令牌桶回满的时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_bucket_key` (`bucket_key`),
+    KEY `idx_expired_at` (`expired_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin COMMENT='限流令牌桶表';
@@ -0,0 +1,3 @@
+ALTER TABLE `user`
+    DROP INDEX `idx_verified_email`,
+    DROP COLUMN `verified_email`;
@@ -0,0 +1,5 @@
+ALTER TABLE `user`
+    ADD COLUMN `verified_email` varchar(50) COLLATE utf8mb4_bin GENERATED ALWAYS AS (
+        IF(`email` <> '' AND `verify` & 1 <> 0 AND `status` = 'active' AND `deleted` = 0, `email`, NULL)
+    ) VIRTUAL COMMENT '已认证且已激活用户的邮箱' AFTER `verify`,
+    ADD UNIQUE KEY `idx_verified_email` (`verified_email`);
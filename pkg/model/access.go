@@ -0,0 +1,39 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+// 权限申请状态
+const (
+	AccessPending  = "pending"
+	AccessApproved = "approved"
+	AccessDenied   = "denied"
+)
+
+type AccessRequest struct {
+	ID            uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID     uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id_status;comment:账号ID"`
+	UserID        uint64 `json:"user_id" gorm:"column:user_id;not null;index:idx_user_id;comment:申请人ID"`
+	Service       string `json:"service" gorm:"column:service;type:varchar(64);not null;comment:申请的服务"`
+	Action        uint8  `json:"action" gorm:"column:action;not null;comment:申请的操作权限"`
+	Justification string `json:"justification" gorm:"column:justification;type:varchar(1024);not null;comment:申请理由"`
+	Duration      int64  `json:"duration" gorm:"column:duration;not null;comment:申请时长，单位秒"`
+	Status        string `json:"status" gorm:"column:status;type:varchar(20);not null;index:idx_account_id_status;comment:申请状态"`
+
+	ApproverID     uint64     `json:"approver_id" gorm:"column:approver_id;not null;default:0;comment:审批人ID"`
+	DecisionReason string     `json:"decision_reason" gorm:"column:decision_reason;type:varchar(255);not null;default:'';comment:审批意见"`
+	GrantID        uint64     `json:"grant_id" gorm:"column:grant_id;not null;default:0;comment:批准后创建的限时授权ID"`
+	DecidedAt      *time.Time `json:"decided_at" gorm:"column:decided_at;comment:审批时间"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;not null;default:current_timestamp() on update current_timestamp();comment:更新时间"`
+	db.SnowID
+}
+
+func (AccessRequest) TableName() string {
+	return "access_request"
+}
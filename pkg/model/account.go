@@ -9,6 +9,10 @@ import "github.com/crochee/lirity/db"
 type Account struct {
 	ID   uint64 `json:"id,string" gorm:"primary_key:id"`
 	Name string `json:"name" gorm:"column:name;type:varchar(255);not null;index:idx_name_deleted,unique;comment:用户名"`
+	// 账户层级，根账户的上级账户ID为0，路径形如 /1/5/
+	ParentID uint64 `json:"parent_id" gorm:"column:parent_id;not null;default:0;index:idx_parent_id;comment:上级账户ID"`
+	Path     string `json:"path" gorm:"column:path;type:varchar(255);not null;default:'';index:idx_path;comment:账户路径"`
+	Depth    uint8  `json:"depth" gorm:"column:depth;not null;default:1;comment:账户层级深度"`
 
 	Deleted db.Deleted `json:"deleted" gorm:"not null;index:idx_name_deleted,unique;comment:软删除记录id"`
 	db.Base
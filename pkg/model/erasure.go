@@ -0,0 +1,32 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+// 数据擦除状态
+const (
+	ErasurePending   = "pending"
+	ErasureCompleted = "completed"
+)
+
+type Erasure struct {
+	ID        uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id;comment:账号ID"`
+	UserID    uint64 `json:"user_id" gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID"`
+	Status    string `json:"status" gorm:"column:status;type:varchar(20);not null;comment:擦除状态"`
+	ActorID   uint64 `json:"actor_id" gorm:"column:actor_id;not null;comment:申请人ID"`
+	Report    string `json:"report" gorm:"column:report;type:json;not null;comment:完成报告"`
+
+	CompletedAt *time.Time `json:"completed_at" gorm:"column:completed_at;comment:完成时间"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"column:updated_at;not null;default:current_timestamp() on update current_timestamp();comment:更新时间"`
+	db.SnowID
+}
+
+func (Erasure) TableName() string {
+	return "account_erasure"
+}
@@ -0,0 +1,25 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type Event struct {
+	ID           uint64 `json:"id,string" gorm:"primary_key:id"`
+	Type         string `json:"type" gorm:"column:type;type:varchar(64);not null;index:idx_type;comment:事件类型"`
+	ResourceType string `json:"resource_type" gorm:"column:resource_type;type:varchar(64);not null;index:idx_resource;comment:资源类型"`
+	ResourceID   uint64 `json:"resource_id" gorm:"column:resource_id;not null;index:idx_resource;comment:资源ID"`
+	ActorID      uint64 `json:"actor_id" gorm:"column:actor_id;not null;comment:操作人ID"`
+	TraceID      string `json:"trace_id" gorm:"column:trace_id;type:varchar(64);not null;default:'';comment:请求追踪ID"`
+	Payload      string `json:"payload" gorm:"column:payload;type:json;not null;comment:事件内容"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	db.SnowID
+}
+
+func (Event) TableName() string {
+	return "event"
+}
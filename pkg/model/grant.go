@@ -0,0 +1,27 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type Grant struct {
+	ID         uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID  uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id;comment:账号ID"`
+	UserID     uint64 `json:"user_id" gorm:"column:user_id;not null;index:idx_user_id_not_after;comment:用户ID"`
+	Permission string `json:"permission" gorm:"column:permission;type:json;not null;comment:权限文本"`
+	Reason     string `json:"reason" gorm:"column:reason;type:varchar(255);not null;default:'';comment:授权原因"`
+	ActorID    uint64 `json:"actor_id" gorm:"column:actor_id;not null;comment:授权人ID"`
+
+	NotBefore time.Time `json:"not_before" gorm:"column:not_before;not null;comment:生效时间"`
+	NotAfter  time.Time `json:"not_after" gorm:"column:not_after;not null;index:idx_user_id_not_after;index:idx_not_after;comment:失效时间"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;not null;default:current_timestamp() on update current_timestamp();comment:更新时间"`
+	db.SnowID
+}
+
+func (Grant) TableName() string {
+	return "permission_grant"
+}
@@ -0,0 +1,37 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type Group struct {
+	ID         uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID  uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id_name_deleted,unique;comment:账号ID"`
+	Name       string `json:"name" gorm:"column:name;type:varchar(255);not null;index:idx_account_id_name_deleted,unique;comment:用户组名"`
+	Permission string `json:"permission" gorm:"column:permission;type:json;not null;comment:权限文本"`
+
+	Desc string `json:"desc" gorm:"column:desc;type:json;not null;comment:详细描述"`
+
+	Deleted db.Deleted `json:"deleted" gorm:"not null;index:idx_account_id_name_deleted,unique;comment:软删除记录id"`
+	db.Base
+}
+
+func (Group) TableName() string {
+	return "user_group"
+}
+
+type GroupUser struct {
+	ID      uint64 `json:"id,string" gorm:"primary_key:id"`
+	GroupID uint64 `json:"group_id" gorm:"column:group_id;not null;index:idx_group_id_user_id,unique;comment:用户组ID"`
+	UserID  uint64 `json:"user_id" gorm:"column:user_id;not null;index:idx_group_id_user_id,unique;index:idx_user_id;comment:用户ID"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	db.SnowID
+}
+
+func (GroupUser) TableName() string {
+	return "group_user"
+}
@@ -0,0 +1,26 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type History struct {
+	ID           uint64 `json:"id,string" gorm:"primary_key:id"`
+	ResourceType string `json:"resource_type" gorm:"column:resource_type;type:varchar(64);not null;index:idx_resource_version,unique;comment:资源类型"`
+	ResourceID   uint64 `json:"resource_id" gorm:"column:resource_id;not null;index:idx_resource_version,unique;comment:资源ID"`
+	Version      uint64 `json:"version" gorm:"column:version;not null;index:idx_resource_version,unique;comment:历史版本号"`
+	Action       string `json:"action" gorm:"column:action;type:varchar(20);not null;comment:操作类型"`
+	Changes      string `json:"changes" gorm:"column:changes;type:json;not null;comment:字段变更"`
+	ActorID      uint64 `json:"actor_id" gorm:"column:actor_id;not null;comment:操作人ID"`
+	TraceID      string `json:"trace_id" gorm:"column:trace_id;type:varchar(64);not null;default:'';comment:请求追踪ID"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	db.SnowID
+}
+
+func (History) TableName() string {
+	return "history"
+}
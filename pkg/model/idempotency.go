@@ -0,0 +1,32 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type Idempotency struct {
+	ID uint64 `json:"id,string" gorm:"primary_key:id"`
+	// Caller 调用方，携带token时为用户，否则为客户端IP，不同调用方的幂等键互不影响
+	Caller      string `json:"caller" gorm:"column:caller;type:varchar(128);not null;default:'';index:idx_caller_key_method_path,unique;comment:调用方"`
+	Key         string `json:"key" gorm:"column:idempotency_key;type:varchar(255);not null;index:idx_caller_key_method_path,unique;comment:幂等键"`
+	Method      string `json:"method" gorm:"column:method;type:varchar(10);not null;index:idx_caller_key_method_path,unique;comment:请求方法"`
+	Path        string `json:"path" gorm:"column:path;type:varchar(255);not null;index:idx_caller_key_method_path,unique;comment:请求路径"`
+	Fingerprint string `json:"fingerprint" gorm:"column:fingerprint;type:char(64);not null;comment:请求指纹"`
+	// Status 为0表示请求处理中
+	Status      int    `json:"status" gorm:"column:status;not null;default:0;comment:响应状态码"`
+	ContentType string `json:"content_type" gorm:"column:content_type;type:varchar(255);not null;default:'';comment:响应类型"`
+	// Headers 处理器设置的响应头，如ETag、Location、Warning，重放时一并返回
+	Headers string `json:"headers" gorm:"column:headers;type:json;not null;comment:响应头"`
+	Body    []byte `json:"body" gorm:"column:body;type:mediumblob;comment:响应内容"`
+
+	ExpiredAt time.Time `json:"expired_at" gorm:"column:expired_at;not null;index:idx_expired_at;comment:过期时间"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	db.SnowID
+}
+
+func (Idempotency) TableName() string {
+	return "idempotency"
+}
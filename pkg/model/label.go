@@ -0,0 +1,21 @@
+// Package model
+package model
+
+import "github.com/crochee/lirity/db"
+
+type LabelBinding struct {
+	ID         uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID  uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id_name_deleted,unique;comment:账号ID"`
+	Name       string `json:"name" gorm:"column:name;type:varchar(255);not null;index:idx_account_id_name_deleted,unique;comment:授权名"`
+	Selector   string `json:"selector" gorm:"column:selector;type:varchar(1024);not null;comment:标签选择器"`
+	Permission string `json:"permission" gorm:"column:permission;type:json;not null;comment:权限文本"`
+
+	Desc string `json:"desc" gorm:"column:desc;type:json;not null;comment:详细描述"`
+
+	Deleted db.Deleted `json:"deleted" gorm:"not null;index:idx_account_id_name_deleted,unique;comment:软删除记录id"`
+	db.Base
+}
+
+func (LabelBinding) TableName() string {
+	return "label_binding"
+}
@@ -0,0 +1,22 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type ProfileSchema struct {
+	ID        uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id,unique;comment:账号ID"`
+	Schema    string `json:"schema" gorm:"column:schema;type:json;not null;comment:用户属性JSON Schema"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;not null;default:current_timestamp() on update current_timestamp();comment:更新时间"`
+	db.SnowID
+}
+
+func (ProfileSchema) TableName() string {
+	return "profile_schema"
+}
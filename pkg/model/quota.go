@@ -0,0 +1,23 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type Quota struct {
+	ID        uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id_resource,unique;comment:账号ID"`
+	Resource  string `json:"resource" gorm:"column:resource;type:varchar(64);not null;index:idx_account_id_resource,unique;comment:配额资源"`
+	Limit     int64  `json:"limit" gorm:"column:limit;not null;comment:配额上限，0表示不限制"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;not null;default:current_timestamp() on update current_timestamp();comment:更新时间"`
+	db.SnowID
+}
+
+func (Quota) TableName() string {
+	return "account_quota"
+}
@@ -0,0 +1,21 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type RateLimit struct {
+	ID         uint64    `json:"id,string" gorm:"primary_key:id"`
+	Key        string    `json:"key" gorm:"column:bucket_key;type:char(64);not null;index:idx_bucket_key,unique;comment:令牌桶标识的sha256"`
+	Tokens     float64   `json:"tokens" gorm:"column:tokens;not null;comment:剩余令牌数"`
+	RefilledAt time.Time `json:"refilled_at" gorm:"column:refilled_at;type:datetime(6);not null;comment:上次补充令牌的时间"`
+	ExpiredAt  time.Time `json:"expired_at" gorm:"column:expired_at;not null;index:idx_expired_at;comment:令牌桶回满的时间"`
+	db.SnowID
+}
+
+func (RateLimit) TableName() string {
+	return "rate_limit"
+}
@@ -0,0 +1,23 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+type Session struct {
+	ID        uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id_expired_at;comment:账号ID"`
+	UserID    uint64 `json:"user_id" gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID"`
+	TraceID   string `json:"trace_id" gorm:"column:trace_id;type:varchar(64);not null;default:'';comment:请求追踪ID"`
+
+	ExpiredAt time.Time `json:"expired_at" gorm:"column:expired_at;not null;index:idx_account_id_expired_at;comment:过期时间"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	db.SnowID
+}
+
+func (Session) TableName() string {
+	return "session"
+}
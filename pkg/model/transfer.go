@@ -0,0 +1,33 @@
+// Package model
+package model
+
+import (
+	"time"
+
+	"github.com/crochee/lirity/db"
+)
+
+// 转让状态
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferCancelled = "cancelled"
+)
+
+type Transfer struct {
+	ID         uint64 `json:"id,string" gorm:"primary_key:id"`
+	AccountID  uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id;comment:账号ID"`
+	FromUserID uint64 `json:"from_user_id" gorm:"column:from_user_id;not null;comment:原主账号用户ID"`
+	ToUserID   uint64 `json:"to_user_id" gorm:"column:to_user_id;not null;index:idx_to_user_id;comment:目标用户ID"`
+	Status     string `json:"status" gorm:"column:status;type:varchar(20);not null;comment:转让状态"`
+	ActorID    uint64 `json:"actor_id" gorm:"column:actor_id;not null;comment:发起人ID"`
+
+	ExpiredAt time.Time `json:"expired_at" gorm:"column:expired_at;not null;comment:过期时间"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp();comment:创建时间"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;not null;default:current_timestamp() on update current_timestamp();comment:更新时间"`
+	db.SnowID
+}
+
+func (Transfer) TableName() string {
+	return "account_transfer"
+}
@@ -5,17 +5,38 @@ package model
 
 import "github.com/crochee/lirity/db"
 
+// 用户状态
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusDisabled  = "disabled"
+	StatusErased    = "erased"
+)
+
+// 身份认证标记，按位记录
+const (
+	VerifyEmail uint8 = 1 << iota
+)
+
 type User struct {
 	ID             uint64 `json:"id,string" gorm:"primary_key:id"`
 	AccountID      uint64 `json:"account_id" gorm:"column:account_id;not null;index:idx_account_id_name_primary_deleted,unique;comment:账号ID"`
 	Name           string `json:"name" gorm:"column:name;type:varchar(255);not null;index:idx_account_id_name_primary_deleted,unique;comment:用户名"`
 	Password       string `json:"password" gorm:"column:password;type:varchar(50);not null;comment:密码"`
-	Email          string `json:"email" gorm:"column:email;type:varchar(50);not null;comment:邮箱"`
+	Email          string `json:"email" gorm:"column:email;type:varchar(50);not null;index:idx_email;comment:邮箱"`
 	Permission     string `json:"permission" gorm:"column:permission;type:json;not null;comment:权限文本"`
 	Verify         uint8  `json:"verify" gorm:"column:verify;not null;comment:身份认证"`
 	PrimaryAccount bool   `json:"primary_account" gorm:"column:primary_account;not null;index:idx_account_id_name_primary_deleted,unique,comment:是否主账号"`
+	// VerifiedEmail 已认证且已激活用户的邮箱，由数据库生成，唯一索引保证邮箱登录只匹配一个用户
+	VerifiedEmail *string `json:"-" gorm:"->;column:verified_email;type:varchar(50);index:idx_verified_email,unique;comment:已认证且已激活用户的邮箱"`
+
+	Desc       string `json:"desc" gorm:"column:desc;type:json;not null;comment:详细描述"`
+	Attributes string `json:"attributes" gorm:"column:attributes;type:json;not null;comment:用户属性"`
+	Labels     string `json:"labels" gorm:"column:labels;type:json;not null;comment:标签"`
+	Version    uint64 `json:"version" gorm:"column:version;not null;default:1;comment:版本号"`
 
-	Desc string `json:"desc" gorm:"column:desc;type:json;not null;comment:详细描述"`
+	Status       string `json:"status" gorm:"column:status;type:varchar(20);not null;default:active;comment:状态"`
+	StatusReason string `json:"status_reason" gorm:"column:status_reason;type:varchar(255);not null;default:'';comment:状态变更原因"`
 
 	Deleted db.Deleted `json:"deleted" gorm:"not null;index:idx_account_id_name_primary_deleted,unique;comment:软删除记录id"`
 	db.Base
@@ -0,0 +1,172 @@
+// Package netx 服务监听地址、对外通告地址与TLS配置
+package netx
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/crochee/lirity"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"caty/internal/host"
+	"caty/pkg/tlsx"
+)
+
+// UnixPrefix unix socket监听地址的前缀，如 unix:///var/run/caty.sock
+const UnixPrefix = "unix://"
+
+// Config 监听配置
+type Config struct {
+	// Listen 监听地址，支持 host:port、[ipv6]:port 与 unix:///path
+	Listen []string
+	// Advertise 注册到注册中心的地址 host:port，为空时由监听地址推导
+	Advertise string
+	// Interface release模式下监听全部地址时，从该网卡获取通告的IP
+	Interface string
+	TLS       TLS
+}
+
+// TLS 显式开启TLS，开启后证书无法加载时启动失败
+type TLS struct {
+	Enable bool
+	tlsx.Config
+}
+
+// Load 读取 key 下的监听配置，未配置监听地址时监听 defaultAddr
+func Load(key, defaultAddr string) (*Config, error) {
+	cfg := &Config{
+		Listen:    viper.GetStringSlice(key + ".listen"),
+		Advertise: viper.GetString(key + ".advertise"),
+		Interface: viper.GetString(key + ".interface"),
+		TLS: TLS{
+			Enable: viper.GetBool(key + ".tls.enable"),
+			Config: tlsx.Config{
+				Ca:   lirity.FileOrContent(viper.GetString(key + ".tls.ca")),
+				Cert: lirity.FileOrContent(viper.GetString(key + ".tls.cert")),
+				Key:  lirity.FileOrContent(viper.GetString(key + ".tls.key")),
+			},
+		},
+	}
+	if len(cfg.Listen) == 0 {
+		cfg.Listen = []string{defaultAddr}
+	}
+	if cfg.Interface == "" {
+		cfg.Interface = "eth0"
+	}
+	if cfg.TLS.Ca == "" {
+		cfg.TLS.Ca = "ca.pem"
+	}
+	if cfg.TLS.Cert == "" {
+		cfg.TLS.Cert = "server.pem"
+	}
+	if cfg.TLS.Key == "" {
+		cfg.TLS.Key = "server-key.pem"
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return cfg, nil
+}
+
+// Validate 校验监听地址与通告地址
+func (c *Config) Validate() error {
+	for _, address := range c.Listen {
+		if strings.HasPrefix(address, UnixPrefix) {
+			if strings.TrimPrefix(address, UnixPrefix) == "" {
+				return fmt.Errorf("invalid listen address %q", address)
+			}
+			continue
+		}
+		if _, port, err := net.SplitHostPort(address); err != nil {
+			return fmt.Errorf("invalid listen address %q.Error:%w", address, err)
+		} else if _, err = strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("invalid listen port %q", address)
+		}
+	}
+	if c.Advertise != "" {
+		if _, _, err := net.SplitHostPort(c.Advertise); err != nil {
+			return fmt.Errorf("invalid advertise address %q.Error:%w", c.Advertise, err)
+		}
+	}
+	return nil
+}
+
+// TLSConfig 未开启TLS时返回nil
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if !c.TLS.Enable {
+		return nil, nil
+	}
+	cfg, err := tlsx.TLSConfig(tls.RequireAndVerifyClientCert, c.TLS.Config)
+	if err != nil {
+		return nil, fmt.Errorf("load tls config failed.Error:%w", err)
+	}
+	return cfg, nil
+}
+
+// Endpoint 对外通告的地址，未配置 Advertise 时使用第一个tcp监听地址，
+// release模式下监听全部地址时使用网卡 Interface 的IP
+func (c *Config) Endpoint() (string, error) {
+	if c.Advertise != "" {
+		return c.Advertise, nil
+	}
+	for _, address := range c.Listen {
+		if strings.HasPrefix(address, UnixPrefix) {
+			continue
+		}
+		ip, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return "", err
+		}
+		if unspecified(ip) && gin.Mode() == gin.ReleaseMode {
+			if ip, err = host.IP(c.Interface); err != nil {
+				ip = "0.0.0.0"
+			}
+		}
+		return net.JoinHostPort(ip, port), nil
+	}
+	return "", errors.New("advertise is required when only listening on unix sockets")
+}
+
+func unspecified(ip string) bool {
+	if ip == "" {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsUnspecified()
+}
+
+// Listeners 监听全部地址，任一地址监听失败时关闭已监听的地址
+func Listeners(addresses []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addresses))
+	for _, address := range addresses {
+		listener, err := Listen(address)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// Listen 监听地址 address，unix socket文件已存在时先删除
+func Listen(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, UnixPrefix) {
+		return net.Listen("tcp", address)
+	}
+	path := strings.TrimPrefix(address, UnixPrefix)
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,87 @@
+package netx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoad(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	cfg, err := Load("http", ":8120")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Listen) != 1 || cfg.Listen[0] != ":8120" || cfg.TLS.Enable || cfg.TLS.Cert != "server.pem" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	for _, listen := range [][]string{{"8120"}, {"unix://"}, {"[::1]:port"}, {":70000"}} {
+		viper.Set("http.listen", listen)
+		if _, err = Load("http", ":8120"); err == nil {
+			t.Fatalf("expected error for %v", listen)
+		}
+	}
+	viper.Set("http.listen", []string{"[::1]:8120", "unix:///tmp/caty.sock"})
+	viper.Set("http.advertise", "caty")
+	if _, err = Load("http", ":8120"); err == nil {
+		t.Fatal("expected error for advertise")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		cfg      Config
+		endpoint string
+	}{
+		{Config{Listen: []string{":8120"}}, ":8120"},
+		{Config{Listen: []string{"unix:///tmp/caty.sock", "[::1]:8120"}}, "[::1]:8120"},
+		{Config{Listen: []string{"127.0.0.1:8120"}, Advertise: "caty.example.com:443"}, "caty.example.com:443"},
+	}
+	for _, test := range tests {
+		endpoint, err := test.cfg.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if endpoint != test.endpoint {
+			t.Fatalf("expected %s, got %s", test.endpoint, endpoint)
+		}
+	}
+	if _, err := (&Config{Listen: []string{"unix:///tmp/caty.sock"}}).Endpoint(); err == nil {
+		t.Fatal("expected error when only listening on unix sockets")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	cfg := &Config{}
+	cfg.TLS.Cert = "not-exist.pem"
+	if tlsConfig, err := cfg.TLSConfig(); err != nil || tlsConfig != nil {
+		t.Fatalf("unexpected %v %v", tlsConfig, err)
+	}
+	// 显式开启TLS时证书缺失应当报错，不再降级为明文
+	cfg.TLS.Enable = true
+	cfg.TLS.Ca = "not-exist.pem"
+	if _, err := cfg.TLSConfig(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestListeners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "caty.sock")
+	addresses := []string{"127.0.0.1:0", UnixPrefix + path}
+	listeners, err := Listeners(addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listeners[1].Addr().Network() != "unix" {
+		t.Fatalf("unexpected network %s", listeners[1].Addr().Network())
+	}
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+	// 残留的socket文件不影响再次监听
+	if listeners, err = Listeners(addresses[1:]); err != nil {
+		t.Fatal(err)
+	}
+	_ = listeners[0].Close()
+}
@@ -0,0 +1,83 @@
+// Package openapi 加载内嵌的OpenAPI文档并按gin路由查找对应的接口定义
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/spf13/viper"
+
+	"caty/docs"
+)
+
+// Validate 是否在debug模式下按文档校验请求与响应
+func Validate() bool {
+	return viper.GetBool("openapi.validate")
+}
+
+var (
+	loadOnce sync.Once
+	spec     *openapi3.T
+	loadErr  error
+)
+
+// Spec 将内嵌的 swagger 2.0 文档转换为 OpenAPI 3 文档，仅转换一次
+func Spec() (*openapi3.T, error) {
+	loadOnce.Do(func() {
+		spec, loadErr = load(docs.Swagger)
+	})
+	return spec, loadErr
+}
+
+func load(data []byte) (*openapi3.T, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal(data, &doc2); err != nil {
+		return nil, err
+	}
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, err
+	}
+	// 鉴权由 middleware.Token 与各接口自行处理，校验时不检查安全要求
+	doc3.Security = nil
+	if err = openapi3.NewLoader().ResolveRefsIn(doc3, nil); err != nil {
+		return nil, err
+	}
+	return doc3, nil
+}
+
+// Path 将gin的路由模板转换为文档中的路径，如 /v1/accounts/:id 转换为 /v1/accounts/{id}
+func Path(fullPath string) string {
+	segments := strings.Split(fullPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// FindRoute 查找gin路由模板 fullPath 与请求方法 method 对应的接口定义，未定义时返回nil
+func FindRoute(doc *openapi3.T, method, fullPath string) *routers.Route {
+	path := Path(fullPath)
+	pathItem := doc.Paths.Find(path)
+	if pathItem == nil {
+		return nil
+	}
+	operation := pathItem.GetOperation(method)
+	if operation == nil {
+		return nil
+	}
+	return &routers.Route{
+		Spec:      doc,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    method,
+		Operation: operation,
+	}
+}
@@ -0,0 +1,16 @@
+// Package router
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"caty/api/v1/account"
+)
+
+func registerAccess(v1Router *gin.RouterGroup) {
+	v1Router.POST("/access-requests", account.RequestAccess)
+	v1Router.GET("/access-requests", account.ListAccess)
+	v1Router.GET("/access-requests/:id", account.RetrieveAccess)
+	v1Router.POST("/access-requests/:id/approve", account.ApproveAccess)
+	v1Router.POST("/access-requests/:id/deny", account.DenyAccess)
+}
@@ -15,5 +15,28 @@ func registerAccount(v1Router *gin.RouterGroup) {
 	v1Router.PATCH("/accounts/:id", account.Update)
 	v1Router.GET("/accounts/:id", account.Retrieve)
 	v1Router.DELETE("/accounts/:id", account.Delete)
+	v1Router.POST("/accounts/:id/restore", account.Restore)
+	v1Router.POST("/accounts/:id/suspend", account.Suspend)
+	v1Router.POST("/accounts/:id/reactivate", account.Reactivate)
+	v1Router.POST("/accounts/:id/confirm-email", account.ConfirmEmail)
+	v1Router.GET("/accounts/:id/effective-permissions", account.EffectivePermission)
+	v1Router.GET("/accounts/:id/history", account.History)
+	v1Router.POST("/accounts/:id/history/:version/revert", account.Revert)
+	v1Router.POST("/accounts/:id/transfers", account.InitiateTransfer)
+	v1Router.GET("/accounts/:id/data-export", account.Export)
+	v1Router.DELETE("/accounts/:id/sessions", account.RevokeSessions)
+	v1Router.GET("/accounts/:id/quotas", account.Quotas)
+	v1Router.PUT("/accounts/:id/quotas", account.PutQuotas)
+	v1Router.PUT("/accounts/:id/labels", account.PutLabels)
+	v1Router.POST("/accounts/:id/grants", account.CreateGrant)
+	v1Router.GET("/accounts/:id/grants", account.Grants)
+	v1Router.POST("/accounts/:id/erasures", account.RequestErasure)
 	v1Router.POST("/accounts/login", account.Login)
+	v1Router.POST("/accounts/logout", account.Logout)
+	v1Router.GET("/transfers/:id", account.RetrieveTransfer)
+	v1Router.POST("/transfers/:id/accept", account.AcceptTransfer)
+	v1Router.POST("/transfers/:id/cancel", account.CancelTransfer)
+	v1Router.GET("/erasures/:id", account.RetrieveErasure)
+	v1Router.DELETE("/grants/:id", account.RevokeGrant)
+	v1Router.POST("/erasures/:id/complete", account.CompleteErasure)
 }
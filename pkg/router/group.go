@@ -0,0 +1,19 @@
+// Package router
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"caty/api/v1/group"
+)
+
+func registerGroup(v1Router *gin.RouterGroup) {
+	v1Router.POST("/groups", group.Create)
+	v1Router.GET("/groups", group.List)
+	v1Router.PATCH("/groups/:id", group.Update)
+	v1Router.GET("/groups/:id", group.Retrieve)
+	v1Router.DELETE("/groups/:id", group.Delete)
+	v1Router.GET("/groups/:id/users", group.ListUsers)
+	v1Router.PUT("/groups/:id/users/:user_id", group.AddUser)
+	v1Router.DELETE("/groups/:id/users/:user_id", group.RemoveUser)
+}
@@ -0,0 +1,16 @@
+// Package router
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"caty/api/v1/label"
+)
+
+func registerLabel(v1Router *gin.RouterGroup) {
+	v1Router.POST("/label-bindings", label.Create)
+	v1Router.GET("/label-bindings", label.List)
+	v1Router.PATCH("/label-bindings/:id", label.Update)
+	v1Router.GET("/label-bindings/:id", label.Retrieve)
+	v1Router.DELETE("/label-bindings/:id", label.Delete)
+}
@@ -0,0 +1,14 @@
+// Package router
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"caty/api/v1/profile"
+)
+
+func registerProfile(v1Router *gin.RouterGroup) {
+	v1Router.PUT("/profile-schemas/:id", profile.Put)
+	v1Router.GET("/profile-schemas/:id", profile.Retrieve)
+	v1Router.DELETE("/profile-schemas/:id", profile.Delete)
+}
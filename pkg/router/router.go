@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/viper"
 
 	"caty/api"
+	"caty/pkg/metrics"
 	"caty/pkg/middleware"
 	"caty/pkg/v"
 )
@@ -36,20 +37,37 @@ func New() *gin.Engine {
 	router.NoRoute(middleware.NoRoute)
 	router.NoMethod(middleware.NoMethod)
 
+	limitIP, limitUser := middleware.RateLimit()
 	router.Use(middleware.TraceID,
 		middleware.RequestLogger(
 			logger.New(
 				logger.WithLevel(viper.GetString("level")),
 				logger.WithWriter(logger.SetWriter(viper.GetString("path"))))),
 		middleware.Log,
+		middleware.Metrics,
 		middleware.Recovery,
+		limitIP,
+		middleware.OpenAPI(),
+		middleware.Token,
+		limitUser,
+		middleware.Idempotency,
 	)
 
 	router.GET("/version", api.Version)
+	router.GET("/healthz", api.Healthz)
+	router.GET("/readyz", api.Readyz)
+	router.GET("/docs/*filepath", api.Docs)
+	if metrics.Addr() == "" {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
 	v1Router := router.Group("/" + v.V1API)
 
 	registerAccount(v1Router)
 	registerAuth(v1Router)
+	registerGroup(v1Router)
+	registerProfile(v1Router)
+	registerLabel(v1Router)
+	registerAccess(v1Router)
 
 	return router
 }
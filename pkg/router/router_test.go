@@ -0,0 +1,66 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"caty/pkg/openapi"
+)
+
+// 不属于业务接口、无需写入文档的路由
+var undocumented = map[string]bool{
+	"GET /metrics":        true,
+	"GET /docs/*filepath": true,
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	doc, err := openapi.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	specified := make(map[string]bool)
+	for path, pathItem := range doc.Paths {
+		for method := range pathItem.Operations() {
+			specified[method+" "+path] = true
+		}
+	}
+	registered := make(map[string]bool)
+	var missing []string
+	for _, route := range New().Routes() {
+		if undocumented[route.Method+" "+route.Path] {
+			continue
+		}
+		key := route.Method + " " + openapi.Path(route.Path)
+		registered[key] = true
+		if !specified[key] {
+			missing = append(missing, key)
+		}
+	}
+	var stale []string
+	for key := range specified {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	if len(missing) != 0 {
+		t.Errorf("routes without operation in docs/swagger.json, run make swag: %v", missing)
+	}
+	if len(stale) != 0 {
+		t.Errorf("operations in docs/swagger.json without route: %v", stale)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	router := New()
+	for _, path := range []string{"/docs/swagger.json", "/docs/", "/docs/swagger-initializer.js"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("GET %s unexpected response %d", path, w.Code)
+		}
+	}
+}
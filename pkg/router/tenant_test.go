@@ -0,0 +1,129 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/spf13/viper"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// subtree 管理员可访问所属账户及其下级账户
+const subtree = "account_id IN (SELECT d.id FROM `account` AS d JOIN `account` AS p ON d.path LIKE CONCAT(p.path, '%') " +
+	"WHERE p.id =? AND p.deleted = 0 AND d.deleted = 0)"
+
+// tenantToken 账户 accountID 的管理员 userID 的token
+func tenantToken(t *testing.T, accountID, userID string) string {
+	viper.Set("auth.secret", "test")
+	token, err := auth.Create(context.Background(), &auth.TokenClaims{Token: &auth.Token{
+		AccountID:  accountID,
+		UserID:     userID,
+		Permission: map[string]uint8{v.ServiceName: auth.Admin},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Token
+}
+
+// TestCrossTenant 账户2的管理员无法通过接口查询、修改账户1的用户
+// 请求经过完整的路由与中间件，数据库由sqlmock模拟，只校验查询带有租户过滤条件及接口的响应，不连接真实数据库
+func TestCrossTenant(t *testing.T) {
+	if err := code.Loading(); err != nil {
+		t.Fatal(err)
+	}
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := New()
+	tokens := map[string]string{
+		"10": tenantToken(t, "1", "10"),
+		"20": tenantToken(t, "2", "20"),
+	}
+	// request 以用户 userID 的token请求，expect 登记解析token后的数据库查询
+	request := func(method, path, body, userID string, expect func()) *httptest.ResponseRecorder {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `status` FROM `user` WHERE id =?")).
+			WithArgs(userID, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		if expect != nil {
+			expect()
+		}
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(v.XAuthToken, tokens[userID])
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assertCode := func(w *httptest.ResponseRecorder, want e.ErrorCode) {
+		t.Helper()
+		var errorCode e.ErrorCode
+		if !errors.As(e.From(w.Result()), &errorCode) || errorCode.Code() != want.Code() {
+			t.Fatalf("want %v got %d %s", want, w.Code, w.Body.String())
+		}
+	}
+	scoped := func(userID, accountID string, transaction bool) func() {
+		return func() {
+			if transaction {
+				mock.ExpectBegin()
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+				WithArgs(userID, accountID, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			if transaction {
+				mock.ExpectRollback()
+			}
+		}
+	}
+
+	// 账户2查询账户1的用户
+	assertCode(request(http.MethodGet, "/v1/accounts/10", "", "20", scoped("10", "2", false)), code.ErrNoAccount)
+
+	// 账户2修改账户1的用户
+	assertCode(request(http.MethodPatch, "/v1/accounts/10",
+		`{"old_password":"password1","password":"passwordpassword1","permission":"{}"}`, "20",
+		scoped("10", "2", true)), code.ErrNoAccount)
+
+	// 账户2删除账户1的用户
+	assertCode(request(http.MethodDelete, "/v1/accounts/10", "", "20", scoped("10", "2", true)), code.ErrNoAccount)
+
+	// 账户2在账户1下创建用户
+	assertCode(request(http.MethodPost, "/v1/accounts", `{"account_id":"1","account":"caty","password":"passwordpassword1"}`,
+		"20", func() {
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM (SELECT d.id")).
+				WithArgs("2", "1").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		}), code.ErrForbiddenAuth)
+
+	// 账户2列出账户1的用户时只在自己的账户范围内查询
+	if w := request(http.MethodGet, "/v1/accounts?account-id=1", "", "20", func() {
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE account_id = ? AND "+subtree)).
+			WithArgs("1", "2", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}); w.Code != http.StatusOK ||
+		strings.Contains(w.Body.String(), `"id"`) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	// 账户2无法跨租户访问
+	if w := request(http.MethodGet, "/v1/accounts/10?all_tenants=true", "", "20", nil); w.Code != http.StatusForbidden {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,354 @@
+// Package account
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+)
+
+// 权限申请事件
+const (
+	EventAccessRequested = "user.access_requested"
+	EventAccessApproved  = "user.access_approved"
+	EventAccessDenied    = "user.access_denied"
+)
+
+// DefaultAccessMaxDuration 默认权限申请的最长时长
+const DefaultAccessMaxDuration = 72 * time.Hour
+
+// AccessMaxDuration 权限申请的最长时长
+func AccessMaxDuration() time.Duration {
+	if duration := viper.GetDuration("account.access_max_duration"); duration > 0 {
+		return duration
+	}
+	return DefaultAccessMaxDuration
+}
+
+type AccessRequest struct {
+	// 申请的服务，如 caty
+	// Required: true
+	Service string `json:"service" binding:"required,max=64"`
+	// 申请的操作权限 1:read,2:write,3:delete,4:admin
+	// Required: true
+	Action uint8 `json:"action" binding:"required,min=1,max=4"`
+	// 申请理由
+	// Required: true
+	Justification string `json:"justification" binding:"required,max=1024"`
+	// 申请时长，如 4h、30m，不超过配置的最长时长
+	// Required: true
+	Duration string `json:"duration" binding:"required"`
+}
+
+type Access struct {
+	// 权限申请ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type DecisionRequest struct {
+	// 审批意见
+	Reason string `json:"reason" binding:"omitempty,max=255"`
+}
+
+type AccessListRequest struct {
+	model.Page
+	// 申请人
+	// in: query
+	UserID string `json:"user_id" form:"user_id" binding:"omitempty,numeric"`
+	// 申请状态 pending,approved,denied
+	// in: query
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=pending approved denied"`
+}
+
+type AccessResponse struct {
+	// 权限申请ID
+	ID string `json:"id"`
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 申请人
+	UserID string `json:"user_id"`
+	// 申请的服务
+	Service string `json:"service"`
+	// 申请的操作权限
+	Action uint8 `json:"action"`
+	// 申请理由
+	Justification string `json:"justification"`
+	// 申请时长
+	Duration string `json:"duration"`
+	// 状态 pending,approved,denied
+	Status string `json:"status"`
+	// 审批人
+	ApproverID string `json:"approver_id"`
+	// 审批意见
+	DecisionReason string `json:"decision_reason"`
+	// 批准后创建的限时授权ID
+	GrantID string `json:"grant_id"`
+	// 审批时间
+	DecidedAt *time.Time `json:"decided_at"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AccessResponses struct {
+	model.Page
+	// 结果集
+	Result []*AccessResponse `json:"result"`
+}
+
+// RequestAccess 当前用户申请限时的服务操作权限，批准后生成限时授权
+func RequestAccess(ctx context.Context, request *AccessRequest) (*AccessResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	duration, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		return nil, errors.WithStack(code.ErrDurationAccess.WithResult(err.Error()))
+	}
+	if duration <= 0 || duration > AccessMaxDuration() {
+		return nil, errors.WithStack(code.ErrDurationAccess.WithResult(
+			"duration must be positive and at most " + AccessMaxDuration().String()))
+	}
+	accessModel := &model.AccessRequest{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Model(user).Where("id =?", token.UserID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrCreateAccess.WithResult(err))
+		}
+		now := tx.NowFunc()
+		accessModel = &model.AccessRequest{
+			AccountID:     user.AccountID,
+			UserID:        user.ID,
+			Service:       request.Service,
+			Action:        request.Action,
+			Justification: request.Justification,
+			Duration:      int64(duration / time.Second),
+			Status:        model.AccessPending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := tx.Model(accessModel).Create(accessModel).Error; err != nil {
+			return errors.WithStack(code.ErrCreateAccess.WithResult(err))
+		}
+		return event.Record(ctx, tx, EventAccessRequested, event.ResourceUser, user.ID,
+			newAccessResponse(accessModel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newAccessResponse(accessModel), nil
+}
+
+// ListAccess 查询权限申请，管理员可查询账户内所有申请，其他用户可查询本人的申请以及本人可审批的申请
+func ListAccess(ctx context.Context, request *AccessListRequest) (*AccessResponses, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).Model(&model.AccessRequest{}).Scopes(scope)
+	if !isAdmin(token) {
+		if services := approvableServices(token); len(services) > 0 {
+			query = query.Where("(user_id =? OR service IN ?)", token.UserID, services)
+		} else {
+			query = query.Where("user_id =?", token.UserID)
+		}
+	}
+	if request.UserID != "" {
+		query = query.Where("user_id =?", request.UserID)
+	}
+	if request.Status != "" {
+		query = query.Where("status =?", request.Status)
+	}
+	query = model.HandlePage(query, request.Page)
+	var accessList []*model.AccessRequest
+	if err = query.Find(&accessList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveAccess.WithResult(err))
+	}
+	responses := &AccessResponses{
+		Page: model.Page{
+			Index: request.Index,
+			Size:  request.Size,
+			Total: len(accessList),
+		},
+		Result: make([]*AccessResponse, 0, len(accessList)),
+	}
+	for _, access := range accessList {
+		responses.Result = append(responses.Result, newAccessResponse(access))
+	}
+	return responses, nil
+}
+
+// RetrieveAccess 申请人、可审批人或管理员查询权限申请
+func RetrieveAccess(ctx context.Context, request *Access) (*AccessResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	access := &model.AccessRequest{}
+	if err = db.With(ctx).Model(access).Scopes(scope).Where("id =?", request.ID).
+		First(access).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoAccess.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRetrieveAccess.WithResult(err))
+	}
+	if FormatUint(access.UserID) != token.UserID && !isAdmin(token) && verifyApprover(token, access) != nil {
+		return nil, errors.WithStack(code.ErrNoAccess)
+	}
+	return newAccessResponse(access), nil
+}
+
+// ApproveAccess 批准权限申请，为申请人创建从当前时间开始、持续申请时长的限时授权
+func ApproveAccess(ctx context.Context, request *Access, decision *DecisionRequest) (*AccessResponse, error) {
+	return decideAccess(ctx, request, decision, true)
+}
+
+// DenyAccess 拒绝权限申请
+func DenyAccess(ctx context.Context, request *Access, decision *DecisionRequest) (*AccessResponse, error) {
+	return decideAccess(ctx, request, decision, false)
+}
+
+// decideAccess 审批权限申请，审批人须拥有所申请服务的管理员权限且不能审批本人的申请
+func decideAccess(ctx context.Context, request *Access, decision *DecisionRequest,
+	approve bool) (*AccessResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	access := &model.AccessRequest{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(access).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(access).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccess.WithResult(err))
+			}
+			return errors.WithStack(code.ErrDecideAccess.WithResult(err))
+		}
+		if err := verifyApprover(token, access); err != nil {
+			return err
+		}
+		if access.Status != model.AccessPending {
+			return errors.WithStack(code.ErrStatusAccess.WithResult(access.Status))
+		}
+		now := tx.NowFunc()
+		eventType := EventAccessDenied
+		updates := map[string]interface{}{
+			"status":          model.AccessDenied,
+			"approver_id":     auth.ActorID(ctx),
+			"decision_reason": decision.Reason,
+			"decided_at":      now,
+		}
+		if approve {
+			permission, err := json.Marshal(map[string]uint8{access.Service: access.Action})
+			if err != nil {
+				return errors.WithStack(e.ErrInternalServerError.WithResult(err))
+			}
+			grantModel := &model.Grant{
+				AccountID:  access.AccountID,
+				UserID:     access.UserID,
+				Permission: lirity.String(permission),
+				Reason:     access.Justification,
+				ActorID:    auth.ActorID(ctx),
+				NotBefore:  now,
+				NotAfter:   now.Add(time.Duration(access.Duration) * time.Second),
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			if err = createGrant(ctx, tx, grantModel); err != nil {
+				return err
+			}
+			eventType = EventAccessApproved
+			updates["status"] = model.AccessApproved
+			updates["grant_id"] = grantModel.ID
+		}
+		query := tx.Model(&model.AccessRequest{}).Where("id =? AND status =?", access.ID, model.AccessPending).
+			Updates(updates)
+		if err := query.Error; err != nil {
+			return errors.WithStack(code.ErrDecideAccess.WithResult(err))
+		}
+		if query.RowsAffected == 0 {
+			return errors.WithStack(code.ErrStatusAccess)
+		}
+		if err := tx.Model(access).Where("id =?", access.ID).First(access).Error; err != nil {
+			return errors.WithStack(code.ErrDecideAccess.WithResult(err))
+		}
+		return event.Record(ctx, tx, eventType, event.ResourceUser, access.UserID, newAccessResponse(access))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newAccessResponse(access), nil
+}
+
+// verifyApprover 校验 token 能否审批权限申请，申请全局 * 权限时须为全局管理员
+func verifyApprover(token *auth.Token, access *model.AccessRequest) error {
+	if FormatUint(access.UserID) == token.UserID {
+		return errors.WithStack(code.ErrForbiddenAuth.WithResult("can not decide own access request"))
+	}
+	if err := auth.VerifyAuth(token.Permission, access.Service, auth.Admin); err != nil {
+		return errors.WithStack(code.ErrForbiddenAuth.WithResult(err.Error()))
+	}
+	return nil
+}
+
+// approvableServices token 拥有管理员权限、可审批其权限申请的服务
+func approvableServices(token *auth.Token) []string {
+	services := make([]string, 0, len(token.Permission))
+	for service, action := range token.Permission {
+		if action >= auth.Admin {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+func newAccessResponse(access *model.AccessRequest) *AccessResponse {
+	return &AccessResponse{
+		ID:             FormatUint(access.ID),
+		AccountID:      FormatUint(access.AccountID),
+		UserID:         FormatUint(access.UserID),
+		Service:        access.Service,
+		Action:         access.Action,
+		Justification:  access.Justification,
+		Duration:       (time.Duration(access.Duration) * time.Second).String(),
+		Status:         access.Status,
+		ApproverID:     FormatUint(access.ApproverID),
+		DecisionReason: access.DecisionReason,
+		GrantID:        FormatUint(access.GrantID),
+		DecidedAt:      access.DecidedAt,
+		CreatedAt:      access.CreatedAt,
+		UpdatedAt:      access.UpdatedAt,
+	}
+}
@@ -0,0 +1,56 @@
+package account
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+func TestVerifyApprover(t *testing.T) {
+	access := &model.AccessRequest{UserID: 20, Service: v.ServiceName, Action: auth.Write}
+	if err := verifyApprover(&auth.Token{UserID: "10",
+		Permission: map[string]uint8{v.ServiceName: auth.Admin}}, access); err != nil {
+		t.Fatal(err)
+	}
+	assertCode(t, verifyApprover(&auth.Token{UserID: "20",
+		Permission: map[string]uint8{v.ServiceName: auth.Admin}}, access), code.ErrForbiddenAuth)
+	assertCode(t, verifyApprover(&auth.Token{UserID: "10",
+		Permission: map[string]uint8{v.ServiceName: auth.Write}}, access), code.ErrForbiddenAuth)
+	assertCode(t, verifyApprover(&auth.Token{UserID: "10",
+		Permission: map[string]uint8{"other": auth.Admin}}, access), code.ErrForbiddenAuth)
+}
+
+func TestRequestAccessInvalidDuration(t *testing.T) {
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Read}, false)
+	for _, duration := range []string{"", "abc", "-1h", "0s", AccessMaxDuration().String() + "1s"} {
+		_, err := RequestAccess(ctx, &AccessRequest{
+			Service:       v.ServiceName,
+			Action:        auth.Write,
+			Justification: "deploy",
+			Duration:      duration,
+		})
+		assertCode(t, err, code.ErrDurationAccess)
+	}
+}
+
+func TestDecideAccessNotPending(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `access_request` WHERE id =? AND "+subtree)).
+		WithArgs("5", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "user_id", "service", "action", "status"}).
+			AddRow(5, 1, 20, v.ServiceName, auth.Write, model.AccessDenied))
+	mock.ExpectRollback()
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	_, err := ApproveAccess(ctx, &Access{ID: "5"}, &DecisionRequest{})
+	assertCode(t, err, code.ErrStatusAccess)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
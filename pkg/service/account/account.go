@@ -17,25 +17,36 @@ import (
 	"github.com/crochee/lirity/variable"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"caty/pkg/code"
 	"caty/pkg/model"
 	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/service/label"
+	"caty/pkg/service/profile"
+	"caty/pkg/service/quota"
+	"caty/pkg/v"
 )
 
 type CreateRequest struct {
 	// 用户名
 	// Required: true
 	Account string `json:"account" binding:"required"`
-	// 账户ID
+	// 账户ID，指定时在该账户下注册子账号
 	AccountID string `json:"account_id" binding:"omitempty,numeric"`
+	// 上级账户ID，指定时在该账户下创建下级账户并注册其主账号
+	ParentID string `json:"parent_id" binding:"omitempty,numeric,excluded_with=AccountID"`
 	// 邮箱
 	Email string `json:"email" binding:"omitempty,email"`
 	// 密码
 	// Required: true
 	Password string `json:"password" binding:"required,alphanum"`
 	// 描述信息
-	Desc string `json:"desc" binding:"required,json"`
+	Desc string `json:"desc" binding:"omitempty,json"`
+	// 用户属性，按账户的用户属性模式校验并补全默认值
+	Attributes map[string]interface{} `json:"attributes"`
 }
 
 type CreateResponseResult struct {
@@ -45,6 +56,8 @@ type CreateResponseResult struct {
 	Verify uint8 `json:"verify"`
 	// 账户ID
 	AccountID string `json:"account_id"`
+	// 上级账户ID，根账户为0
+	ParentID string `json:"parent_id"`
 	// 账户
 	Account string `json:"account"`
 	// 用户
@@ -55,19 +68,39 @@ type CreateResponseResult struct {
 	Permission string `json:"permission"`
 	// 描述
 	Desc string `json:"desc"`
+	// 用户属性
+	Attributes map[string]interface{} `json:"attributes"`
 	// 创建时间
 	CreatedAt time.Time `json:"created_at"`
 	// 更新时间
 	UpdatedAt time.Time `json:"updated_at"`
+	// 使用已废弃属性的告警，通过Warning响应头返回
+	Warnings []string `json:"-"`
 }
 
 // Create 注册账户
 func Create(ctx context.Context, request *CreateRequest) (*CreateResponseResult, error) {
 	actionMap := map[string]uint8{
-		auth.AllService: auth.Admin,
+		v.ServiceName: auth.Admin,
 	}
 	if request.AccountID != "" {
-		actionMap[auth.AllService] = auth.Read
+		// 在已有账户下注册子账号须为该账户的管理员
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+		if err := verifyTenant(ctx, db.With(ctx).DB, request.AccountID); err != nil {
+			return nil, err
+		}
+		actionMap[v.ServiceName] = auth.Read
+	}
+	if request.ParentID != "" {
+		// 创建下级账户须为上级账户或其祖先账户的管理员
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+		if err := verifyTenant(ctx, db.With(ctx).DB, request.ParentID); err != nil {
+			return nil, err
+		}
 	}
 	permission, err := json.Marshal(actionMap)
 	if err != nil {
@@ -79,9 +112,20 @@ func Create(ctx context.Context, request *CreateRequest) (*CreateResponseResult,
 		Email:      request.Email,
 		Permission: lirity.String(permission),
 		Desc:       request.Desc,
+		Labels:     "{}",
+		Version:    1,
+		Status:     model.StatusActive,
+	}
+	if userModel.Desc == "" {
+		userModel.Desc = "{}"
+	}
+	attributes := request.Attributes
+	if attributes == nil {
+		attributes = make(map[string]interface{})
 	}
+	var warnings []string
+	accountModel := &model.Account{}
 	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
-		accountModel := &model.Account{}
 		if request.AccountID != "" {
 			if err = tx.Model(accountModel).Where("id =?", request.AccountID).
 				First(accountModel).Error; err != nil {
@@ -90,17 +134,36 @@ func Create(ctx context.Context, request *CreateRequest) (*CreateResponseResult,
 				}
 				return errors.WithStack(code.ErrRegisterAccount.WithResult(err))
 			}
+			if err = quota.Check(tx, accountModel.ID, quota.ResourceUsers); err != nil {
+				return err
+			}
 		} else {
-			accountModel.Name = request.Account
-			if err = tx.Model(accountModel).Create(accountModel).Error; err != nil {
-				if strings.Contains(err.Error(), db.ErrDuplicate) {
-					return errors.WithStack(code.ErrExistAccount.WithResult(err))
+			if request.ParentID != "" {
+				if accountModel, err = createChildAccount(tx, request.ParentID, request.Account); err != nil {
+					return err
+				}
+			} else {
+				accountModel.Name = request.Account
+				if err = createAccount(tx, accountModel, ""); err != nil {
+					return err
 				}
-				return errors.WithStack(code.ErrRegisterAccount.WithResult(err))
+			}
+			if err = history.Record(ctx, tx, event.ResourceAccount, accountModel.ID, history.ActionCreate,
+				history.Diff(nil, accountFields(accountModel))); err != nil {
+				return err
 			}
 			userModel.PrimaryAccount = true
 		}
 		userModel.AccountID = accountModel.ID
+		if err = verifyEmail(tx, userModel.Email, 0); err != nil {
+			return err
+		}
+		if warnings, err = profile.Apply(tx, accountModel.ID, attributes); err != nil {
+			return err
+		}
+		if userModel.Attributes, err = marshalAttributes(attributes); err != nil {
+			return err
+		}
 		if err = tx.Model(userModel).Create(userModel).Error; err != nil {
 			if strings.Contains(err.Error(), db.ErrDuplicate) {
 				return errors.WithStack(code.ErrExistAccount.WithResult(err))
@@ -110,13 +173,15 @@ func Create(ctx context.Context, request *CreateRequest) (*CreateResponseResult,
 		if err = tx.Model(userModel).First(userModel).Error; err != nil {
 			return errors.WithStack(code.ErrRegisterAccount.WithResult(err))
 		}
-		return nil
+		return history.Record(ctx, tx, event.ResourceUser, userModel.ID, history.ActionCreate,
+			history.Diff(nil, userFields(userModel)))
 	})
 	if err != nil {
 		return nil, err
 	}
 	return &CreateResponseResult{
 		AccountID:      FormatUint(userModel.AccountID),
+		ParentID:       FormatUint(accountModel.ParentID),
 		Account:        userModel.Name,
 		UserID:         FormatUint(userModel.ID),
 		Email:          userModel.Email,
@@ -124,8 +189,10 @@ func Create(ctx context.Context, request *CreateRequest) (*CreateResponseResult,
 		Verify:         userModel.Verify,
 		PrimaryAccount: userModel.PrimaryAccount,
 		Desc:           userModel.Desc,
+		Attributes:     attributes,
 		CreatedAt:      userModel.CreatedAt,
 		UpdatedAt:      userModel.UpdatedAt,
+		Warnings:       warnings,
 	}, nil
 }
 
@@ -150,10 +217,19 @@ type UpdateRequest struct {
 	Permission string `json:"permission" binding:"omitempty,json"`
 	// 描述信息
 	Desc string `json:"desc" binding:"omitempty,json"`
+	// 用户属性，与已有属性合并，值为null时删除该属性
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+type UpdateResult struct {
+	// 编辑后的ETag
+	ETag string
+	// 使用已废弃属性的告警
+	Warnings []string
 }
 
-// Update 编辑账户
-func Update(ctx context.Context, user *User, request *UpdateRequest) error {
+// Update 编辑账户，返回编辑后的ETag
+func Update(ctx context.Context, user *User, precondition *Precondition, request *UpdateRequest) (*UpdateResult, error) {
 	updates := make(map[string]interface{})
 	if request.Account != "" {
 		updates["name"] = request.Account
@@ -165,23 +241,79 @@ func Update(ctx context.Context, user *User, request *UpdateRequest) error {
 		updates["password"] = request.Password
 	}
 	if request.Permission != "" {
+		if err := verifyPermissionUpdate(ctx, request.Permission); err != nil {
+			return nil, err
+		}
 		updates["permission"] = request.Permission
 	}
 	if request.Desc != "" {
 		updates["desc"] = request.Desc
 	}
-	if len(updates) == 0 {
-		return errors.WithStack(code.ErrNoUpdate)
+	if len(updates) == 0 && request.Attributes == nil {
+		return nil, errors.WithStack(code.ErrNoUpdate)
 	}
-	query := db.With(ctx).Model(&model.User{}).Where("id=? AND password=?",
-		user.ID, request.OldPassword).Updates(updates)
-	if err := query.Error; err != nil {
-		return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if query.RowsAffected == 0 {
-		return errors.WithStack(code.ErrNoUpdate)
+	result := &UpdateResult{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		userModel := &model.User{}
+		if err := tx.Model(userModel).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", user.ID).First(userModel).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		if err := precondition.Verify(userModel.Version); err != nil {
+			return err
+		}
+		if request.Email != "" && request.Email != userModel.Email {
+			if err := verifyEmail(tx, request.Email, userModel.ID); err != nil {
+				return err
+			}
+			// 更换邮箱后需重新认证
+			updates["verify"] = userModel.Verify &^ model.VerifyEmail
+		}
+		if request.Attributes != nil {
+			attributes := unmarshalAttributes(userModel.Attributes)
+			for key, value := range request.Attributes {
+				if value == nil {
+					delete(attributes, key)
+					continue
+				}
+				attributes[key] = value
+			}
+			var err error
+			if result.Warnings, err = profile.Apply(tx, userModel.AccountID, attributes); err != nil {
+				return err
+			}
+			if updates["attributes"], err = marshalAttributes(attributes); err != nil {
+				return err
+			}
+		}
+		updates["version"] = gorm.Expr("`version` + 1")
+		query := tx.Model(&model.User{}).Where("id =? AND password =? AND version =?",
+			user.ID, request.OldPassword, userModel.Version).Updates(updates)
+		if err := query.Error; err != nil {
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		if query.RowsAffected == 0 {
+			return errors.WithStack(code.ErrNoUpdate)
+		}
+		updated := &model.User{}
+		if err := tx.Model(updated).Where("id =?", userModel.ID).First(updated).Error; err != nil {
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		result.ETag = ETag(updated.Version)
+		return history.Record(ctx, tx, event.ResourceUser, userModel.ID, history.ActionUpdate,
+			history.Diff(userFields(userModel), userFields(updated)))
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return result, nil
 }
 
 type RetrievesRequest struct {
@@ -198,6 +330,15 @@ type RetrievesRequest struct {
 	// 邮箱
 	// in: query
 	Email string `json:"email" form:"email" binding:"omitempty,email"`
+	// 用户属性过滤，格式为key=value，可指定多个
+	// in: query
+	Attributes []string `json:"attribute" form:"attribute" binding:"omitempty,dive,contains=="`
+	// 标签选择器，如 env in (prod,stage),!contractor
+	// in: query
+	Selector string `json:"selector" form:"selector" binding:"omitempty"`
+	// 是否包含下级账户的用户，未指定账户ID时为调用方所属账户
+	// in: query
+	IncludeDescendants bool `json:"include_descendants" form:"include_descendants"`
 }
 
 type RetrieveResponses struct {
@@ -221,6 +362,16 @@ type RetrieveResponse struct {
 	Permission string `json:"permission"`
 	// 描述
 	Desc string `json:"desc"`
+	// 用户属性
+	Attributes map[string]interface{} `json:"attributes"`
+	// 标签
+	Labels map[string]string `json:"labels"`
+	// 版本号，与响应头ETag对应
+	Version uint64 `json:"version"`
+	// 状态 active,suspended,disabled,erased
+	Status string `json:"status"`
+	// 状态变更原因
+	StatusReason string `json:"status_reason"`
 	// 创建时间
 	CreatedAt time.Time `json:"created_at"`
 	// 更新时间
@@ -229,12 +380,22 @@ type RetrieveResponse struct {
 
 // List 查询、获取账户信息
 func List(ctx context.Context, request *RetrievesRequest) (*RetrieveResponses, error) {
-	query := db.With(ctx).Model(&model.User{})
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).Model(&model.User{}).Scopes(scope)
 	if request.ID != "" {
 		query = query.Where("id = ?", request.ID)
 	} else {
-		if request.AccountID != "" {
+		switch {
+		case request.AccountID != "" && request.IncludeDescendants:
+			query = query.Where("account_id IN (?)", descendants(query, request.AccountID))
+		case request.AccountID != "":
 			query = query.Where("account_id = ?", request.AccountID)
+		case !request.IncludeDescendants && !auth.AllTenants(ctx):
+			// 默认只查询调用方所属账户，不展开下级账户
+			query = query.Where("account_id = ?", auth.GetToken(ctx).AccountID)
 		}
 		if request.Account != "" {
 			query = query.Where("name = ?", request.Account)
@@ -242,6 +403,20 @@ func List(ctx context.Context, request *RetrievesRequest) (*RetrieveResponses, e
 		if request.Email != "" {
 			query = query.Where("email = ?", request.Email)
 		}
+		for _, attribute := range request.Attributes {
+			key, value, err := parseAttributeFilter(attribute)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("JSON_UNQUOTE(JSON_EXTRACT(`attributes`, ?)) = ?", "$."+key, value)
+		}
+		if request.Selector != "" {
+			selector, err := label.Parse(request.Selector)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Scopes(selector.Scope("`labels`"))
+		}
 	}
 	query = model.HandlePage(query, request.Page)
 	var userList []*model.User
@@ -258,15 +433,20 @@ func List(ctx context.Context, request *RetrievesRequest) (*RetrieveResponses, e
 	}
 	for _, user := range userList {
 		responses.Result = append(responses.Result, &RetrieveResponse{
-			AccountID:  FormatUint(user.AccountID),
-			Account:    user.Name,
-			UserID:     FormatUint(user.ID),
-			Email:      user.Email,
-			Permission: user.Permission,
-			Verify:     user.Verify,
-			Desc:       user.Desc,
-			CreatedAt:  user.CreatedAt,
-			UpdatedAt:  user.UpdatedAt,
+			AccountID:    FormatUint(user.AccountID),
+			Account:      user.Name,
+			UserID:       FormatUint(user.ID),
+			Email:        user.Email,
+			Permission:   user.Permission,
+			Verify:       user.Verify,
+			Desc:         user.Desc,
+			Attributes:   unmarshalAttributes(user.Attributes),
+			Labels:       label.Unmarshal(user.Labels),
+			Version:      user.Version,
+			Status:       user.Status,
+			StatusReason: user.StatusReason,
+			CreatedAt:    user.CreatedAt,
+			UpdatedAt:    user.UpdatedAt,
 		})
 	}
 	return responses, nil
@@ -274,58 +454,110 @@ func List(ctx context.Context, request *RetrievesRequest) (*RetrieveResponses, e
 
 // Retrieve 查询、获取指定账户信息
 func Retrieve(ctx context.Context, request *User) (*RetrieveResponse, error) {
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
 	user := &model.User{}
-	if err := db.With(ctx).Model(user).Where("id =?", request.ID).First(user).Error; err != nil {
+	if err = db.With(ctx).Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
 		if errors.Is(err, db.NotFound) {
 			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
 		}
 		return nil, errors.WithStack(code.ErrRetrieveAccount.WithResult(err))
 	}
 	return &RetrieveResponse{
-		AccountID:  FormatUint(user.AccountID),
-		Account:    user.Name,
-		UserID:     FormatUint(user.ID),
-		Email:      user.Email,
-		Permission: user.Permission,
-		Verify:     user.Verify,
-		Desc:       user.Desc,
-		CreatedAt:  user.CreatedAt,
-		UpdatedAt:  user.UpdatedAt,
+		AccountID:    FormatUint(user.AccountID),
+		Account:      user.Name,
+		UserID:       FormatUint(user.ID),
+		Email:        user.Email,
+		Permission:   user.Permission,
+		Verify:       user.Verify,
+		Desc:         user.Desc,
+		Attributes:   unmarshalAttributes(user.Attributes),
+		Labels:       label.Unmarshal(user.Labels),
+		Version:      user.Version,
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
 	}, nil
 }
 
-// Delete 删除账户
-func Delete(ctx context.Context, request *User) error {
+type DeleteRequest struct {
+	// 是否彻底删除，仅管理员可用
+	// in: query
+	Purge bool `json:"purge" form:"purge"`
+}
+
+// Delete 管理员删除账户，删除主账号时级联删除其下所有子账号
+func Delete(ctx context.Context, request *User, precondition *Precondition) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
 	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
 		user := &model.User{}
-		query := tx.Model(user).Where("id =?", request.ID)
-		if err := query.First(user).Error; err != nil {
+		if err := tx.Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(user).Error; err != nil {
 			if errors.Is(err, db.NotFound) {
 				return errors.WithStack(code.ErrNoAccount.WithResult(err))
 			}
 			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
 		}
+		if err := precondition.Verify(user.Version); err != nil {
+			return err
+		}
+		// 同一批次删除的记录使用相同的删除时间，便于恢复时识别
+		now := tx.NowFunc()
+		userIDs := []uint64{user.ID}
 		if user.PrimaryAccount {
-			accountModel := &model.Account{}
-			queryAccountDel := tx.Model(accountModel).Where("id =?", user.AccountID).Delete(accountModel)
+			var children int64
+			if err := tx.Model(&model.Account{}).Where("parent_id =?", user.AccountID).
+				Count(&children).Error; err != nil {
+				return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+			}
+			if children > 0 {
+				return errors.WithStack(code.ErrChildAccount)
+			}
+			queryAccountDel := tx.Model(&model.Account{}).Where("id =?", user.AccountID).
+				Updates(softDeleted(now))
 			if err := queryAccountDel.Error; err != nil {
 				return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
 			}
 			if queryAccountDel.RowsAffected == 0 {
 				return errors.WithStack(code.ErrNoAccount)
 			}
+			if err := history.Record(ctx, tx, event.ResourceAccount, user.AccountID,
+				history.ActionDelete, nil); err != nil {
+				return err
+			}
+			if err := tx.Model(&model.User{}).Where("account_id =?", user.AccountID).
+				Pluck("id", &userIDs).Error; err != nil {
+				return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+			}
 		}
-		queryDel := query.Delete(user)
+		queryDel := tx.Model(&model.User{}).Where("id IN ?", userIDs).Updates(softDeleted(now))
 		if err := queryDel.Error; err != nil {
 			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
 		}
 		if queryDel.RowsAffected == 0 {
 			return errors.WithStack(code.ErrNoAccount)
 		}
-		return nil
+		return recordUsers(ctx, tx, userIDs, history.ActionDelete)
 	})
 }
 
+// softDeleted 软删除需要更新的字段，deleted 记为自身id以释放唯一索引
+func softDeleted(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"deleted":    gorm.Expr("`id`"),
+		"deleted_at": now,
+	}
+}
+
 const PasswordMaxLength = 15
 
 // ValidPassword 密码校验
@@ -5,50 +5,142 @@ package account
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
 	"github.com/crochee/lirity/db"
-	"github.com/crochee/lirity/e"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 
 	"caty/pkg/code"
+	"caty/pkg/metrics"
 	"caty/pkg/model"
 	"caty/pkg/service/auth"
+	"caty/pkg/service/quota"
+	"caty/pkg/v"
 )
 
 type LoginRequest struct {
-	// 用户账号
-	// Required: true
-	UserID string `json:"user_id" binding:"required,numeric"`
+	// 用户ID
+	UserID string `json:"user_id" binding:"omitempty,numeric"`
+	// 账户名，与用户名一起使用
+	Account string `json:"account" binding:"required_with=Name"`
+	// 用户名，与账户名一起使用
+	Name string `json:"name" binding:"required_with=Account"`
+	// 已认证的邮箱
+	Email string `json:"email" binding:"omitempty,email"`
 	// 密码
 	// Required: true
 	Password string `json:"password" binding:"required,alphanum"`
 }
 
-// Login 用户登录
-func Login(ctx context.Context, request *LoginRequest) (*auth.APIToken, error) {
-	user := &model.User{}
-	if err := db.With(ctx).Model(user).Where("id =?",
-		request.UserID).First(user).Error; err != nil {
-		if errors.Is(err, db.NotFound) {
-			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
-		}
-		return nil, errors.WithStack(code.ErrLoginAccount.WithResult(err))
+// Login 用户登录，支持用户ID、账户名与用户名或已认证的邮箱
+func Login(ctx context.Context, request *LoginRequest) (apiToken *auth.APIToken, err error) {
+	defer func() {
+		metrics.Logins.WithLabelValues(metrics.Result(err)).Inc()
+	}()
+	user, err := loginUser(db.With(ctx).DB, request)
+	if err != nil {
+		return nil, err
 	}
 	if user.Password != request.Password {
 		return nil, errors.WithStack(code.ErrWrongPasswordAccount)
 	}
+	if user.Status != model.StatusActive {
+		return nil, errors.WithStack(code.ErrInactiveAccount.WithResult(user.Status))
+	}
+	permission, sources, err := effectivePermission(db.With(ctx).DB, user)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	// 限时授权失效后须重新登录，token不得晚于最早失效的限时授权过期
+	expiry := tokenExpiry(now, sources)
+	session, err := createSession(ctx, user, expiry)
+	if err != nil {
+		return nil, err
+	}
 	token := &auth.TokenClaims{
-		Now: time.Now().Unix(),
+		Now:       now.Unix(),
+		ExpiresAt: expiry.Unix(),
 		Token: &auth.Token{
 			AccountID:  FormatUint(user.AccountID),
 			UserID:     FormatUint(user.ID),
-			Permission: make(map[string]uint8),
+			Permission: permission,
+			SessionID:  FormatUint(session.ID),
 		},
 	}
-	if err := json.Unmarshal([]byte(user.Permission), &token.Token.Permission); err != nil {
-		return nil, errors.WithStack(e.ErrInternalServerError.WithResult(err))
-	}
 	return auth.Create(ctx, token)
 }
+
+// loginUser 按登录方式查询用户，邮箱只匹配已认证且已激活的用户
+func loginUser(tx *gorm.DB, request *LoginRequest) (*model.User, error) {
+	query := tx.Model(&model.User{})
+	switch {
+	case request.UserID != "" && request.Account == "" && request.Email == "":
+		query = query.Where("id =?", request.UserID)
+	case request.Account != "" && request.UserID == "" && request.Email == "":
+		query = query.Where("account_id = (?) AND name =?",
+			tx.Model(&model.Account{}).Select("id").Where("name =?", request.Account), request.Name)
+	case request.Email != "" && request.UserID == "" && request.Account == "":
+		query = query.Where("email =? AND verify & ? <> 0 AND status =?",
+			request.Email, model.VerifyEmail, model.StatusActive)
+	default:
+		return nil, errors.WithStack(code.ErrLoginMethod)
+	}
+	var userList []*model.User
+	// 多查询一条用于判断是否对应多个用户，账户下的主账号与子账号可以同名
+	if err := query.Limit(2).Find(&userList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrLoginAccount.WithResult(err))
+	}
+	switch len(userList) {
+	case 0:
+		return nil, errors.WithStack(code.ErrNoAccount)
+	case 1:
+		return userList[0], nil
+	default:
+		if request.Email != "" {
+			return nil, errors.WithStack(code.ErrAmbiguousEmail)
+		}
+		return nil, errors.WithStack(code.ErrAmbiguousName)
+	}
+}
+
+// verifyEmail 校验邮箱未被其他已激活的用户使用，邮箱在所有账户间唯一，
+// 返回的错误不区分原因，避免泄露其他账户的邮箱
+func verifyEmail(tx *gorm.DB, email string, userID uint64) error {
+	if email == "" {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.User{}).Where("email =? AND status =? AND id <>?",
+		email, model.StatusActive, userID).Count(&count).Error; err != nil {
+		return errors.WithStack(code.ErrRetrieveAccount.WithResult(err))
+	}
+	if count > 0 {
+		return errors.WithStack(code.ErrUnavailableEmail)
+	}
+	return nil
+}
+
+// createSession 记录有效期至 expiredAt 的登录会话，账户的有效会话数受配额限制，
+// 校验配额时锁定账户记录，同一账户的并发登录串行执行
+func createSession(ctx context.Context, user *model.User, expiredAt time.Time) (*model.Session, error) {
+	sessionModel := &model.Session{
+		AccountID: user.AccountID,
+		UserID:    user.ID,
+		TraceID:   v.GetTraceID(ctx),
+		ExpiredAt: expiredAt,
+	}
+	if err := db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := quota.Check(tx, user.AccountID, quota.ResourceSessions); err != nil {
+			return err
+		}
+		if err := tx.Model(sessionModel).Create(sessionModel).Error; err != nil {
+			return errors.WithStack(code.ErrCreateSession.WithResult(err))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return sessionModel, nil
+}
@@ -0,0 +1,56 @@
+package account
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+)
+
+func TestLoginMethod(t *testing.T) {
+	list := []*LoginRequest{
+		{},
+		{UserID: "1", Email: "a@b.com"},
+		{Account: "caty", Name: "admin", Email: "a@b.com"},
+		{UserID: "1", Account: "caty", Name: "admin"},
+	}
+	mockDB(t)
+	for _, request := range list {
+		_, err := loginUser(db.With(context.Background()).DB, request)
+		assertCode(t, err, code.ErrLoginMethod)
+	}
+}
+
+func TestLoginByEmail(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (email =? AND verify & ? <> 0 AND status =?)")).
+		WithArgs("a@b.com", model.VerifyEmail, model.StatusActive, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	_, err := loginUser(db.With(context.Background()).DB, &LoginRequest{Email: "a@b.com"})
+	assertCode(t, err, code.ErrAmbiguousEmail)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (account_id = (SELECT `id` FROM `account` WHERE name =? AND `account`.`deleted` = ?) AND name =?)")).
+		WithArgs("caty", 0, "admin", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	_, err = loginUser(db.With(context.Background()).DB, &LoginRequest{Account: "caty", Name: "admin"})
+	assertCode(t, err, code.ErrAmbiguousName)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (account_id = (SELECT `id` FROM `account` WHERE name =? AND `account`.`deleted` = ?) AND name =?)")).
+		WithArgs("caty", 0, "admin", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	user, err := loginUser(db.With(context.Background()).DB, &LoginRequest{Account: "caty", Name: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 {
+		t.Fatalf("want user 1 got %d", user.ID)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package account
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/e"
+	"github.com/pkg/errors"
+)
+
+// attributeKey 可用于过滤的属性名，避免拼接JSON路径时注入
+var attributeKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseAttributeFilter 解析key=value格式的属性过滤条件
+func parseAttributeFilter(filter string) (string, string, error) {
+	index := strings.IndexByte(filter, '=')
+	if index < 0 || !attributeKey.MatchString(filter[:index]) {
+		return "", "", errors.WithStack(e.ErrInvalidParam.WithResult(
+			fmt.Sprintf("invalid attribute filter %s", filter)))
+	}
+	return filter[:index], filter[index+1:], nil
+}
+
+func marshalAttributes(attributes map[string]interface{}) (string, error) {
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return "", errors.WithStack(e.ErrInternalServerError.WithResult(err))
+	}
+	return lirity.String(data), nil
+}
+
+func unmarshalAttributes(data string) map[string]interface{} {
+	attributes := make(map[string]interface{})
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &attributes)
+	}
+	return attributes
+}
@@ -0,0 +1,63 @@
+// Package account
+package account
+
+import (
+	"context"
+	"strings"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/v"
+)
+
+// ConfirmEmail 管理员确认用户当前的邮箱已认证，认证后可使用邮箱登录，修改邮箱后须重新认证
+func ConfirmEmail(ctx context.Context, request *User) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		if user.Email == "" {
+			return errors.WithStack(code.ErrNoEmail)
+		}
+		if user.Verify&model.VerifyEmail != 0 {
+			return nil
+		}
+		if err := verifyEmail(tx, user.Email, user.ID); err != nil {
+			return err
+		}
+		verify := user.Verify | model.VerifyEmail
+		if err := tx.Model(&model.User{}).Where("id =?", user.ID).Updates(map[string]interface{}{
+			"verify":  verify,
+			"version": gorm.Expr("`version` + 1"),
+		}).Error; err != nil {
+			// 并发认证相同邮箱时由唯一索引 idx_verified_email 拒绝
+			if strings.Contains(err.Error(), db.ErrDuplicate) {
+				return errors.WithStack(code.ErrUnavailableEmail)
+			}
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		return history.Record(ctx, tx, event.ResourceUser, user.ID, history.ActionUpdate, history.Diff(
+			map[string]interface{}{"verify": user.Verify},
+			map[string]interface{}{"verify": verify}))
+	})
+}
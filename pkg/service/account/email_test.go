@@ -0,0 +1,64 @@
+package account
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+func TestConfirmEmail(t *testing.T) {
+	mock := mockDB(t)
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	columns := []string{"id", "account_id", "email", "verify", "status"}
+	expectUser := func(email string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+			WithArgs("20", "1", 0).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(20, 1, email, 0, model.StatusActive))
+	}
+	expectUnique := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE (email =? AND status =? AND id <>?)")).
+			WithArgs("a@b.com", model.StatusActive, 20, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+
+	expectUser("a@b.com")
+	expectUnique()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `user` SET")).
+		WithArgs(model.VerifyEmail, sqlmock.AnyArg(), 20, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(`version`), 0) FROM `history`")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `history`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := ConfirmEmail(ctx, &User{ID: "20"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发认证相同邮箱时由唯一索引拒绝
+	expectUser("a@b.com")
+	expectUnique()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `user` SET")).
+		WillReturnError(errors.New("Error 1062: Duplicate entry 'a@b.com' for key 'idx_verified_email'"))
+	mock.ExpectRollback()
+	assertCode(t, ConfirmEmail(ctx, &User{ID: "20"}), code.ErrUnavailableEmail)
+
+	expectUser("")
+	mock.ExpectRollback()
+	assertCode(t, ConfirmEmail(ctx, &User{ID: "20"}), code.ErrNoEmail)
+
+	// 非管理员无法认证邮箱
+	assertCode(t, ConfirmEmail(tenantContext(map[string]uint8{v.ServiceName: auth.Read}, false),
+		&User{ID: "20"}), code.ErrForbiddenAuth)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,341 @@
+// Package account
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/service/idempotency"
+	"caty/pkg/v"
+)
+
+// 数据擦除事件
+const (
+	EventErasureRequested = "account.erasure_requested"
+	EventErasureCompleted = "account.erasure_completed"
+)
+
+// erasedFields 数据擦除时匿名化的个人信息字段
+var erasedFields = []string{"name", "email", "desc", "attributes", "labels", "password"}
+
+// erasedPayloadFields 审计事件内容中可能包含个人信息的自由文本字段，
+// 分别来自状态变更原因、限时授权原因与权限申请的申请理由、审批意见
+var erasedPayloadFields = []string{"reason", "justification", "decision_reason"}
+
+type Erasure struct {
+	// 数据擦除请求ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type ErasureReport struct {
+	// 已匿名化的字段
+	Fields []string `json:"fields"`
+	// 已擦除个人信息的变更历史记录数
+	HistoryScrubbed int `json:"history_scrubbed"`
+	// 已擦除自由文本的审计事件数
+	EventsScrubbed int `json:"events_scrubbed"`
+	// 已擦除理由的限时授权与权限申请数
+	ReasonsScrubbed int64 `json:"reasons_scrubbed"`
+	// 已清除响应内容的幂等记录数
+	IdempotencyScrubbed int64 `json:"idempotency_scrubbed"`
+	// 保留的审计事件数
+	EventsRetained int64 `json:"events_retained"`
+	// 擦除前的用户状态
+	PreviousStatus string `json:"previous_status"`
+}
+
+type ErasureResponse struct {
+	// 数据擦除请求ID
+	ID string `json:"id"`
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 用户
+	UserID string `json:"user_id"`
+	// 状态 pending,completed
+	Status string `json:"status"`
+	// 申请人
+	ActorID string `json:"actor_id"`
+	// 完成报告
+	Report *ErasureReport `json:"report,omitempty"`
+	// 完成时间
+	CompletedAt *time.Time `json:"completed_at"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type erasurePayload struct {
+	ErasureID string `json:"erasure_id"`
+	UserID    string `json:"user_id"`
+}
+
+// RequestErasure 申请擦除用户的个人数据，仅本人或管理员可申请，主账号须先转让
+func RequestErasure(ctx context.Context, request *User) (*ErasureResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	erasureModel := &model.Erasure{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Unscoped().Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		if user.Status == model.StatusErased {
+			return errors.WithStack(code.ErrStatusErasure)
+		}
+		if user.PrimaryAccount {
+			return errors.WithStack(code.ErrPrimaryErasure)
+		}
+		var count int64
+		if err := tx.Model(&model.Erasure{}).Where("user_id =? AND status =?",
+			user.ID, model.ErasurePending).Count(&count).Error; err != nil {
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		if count > 0 {
+			return errors.WithStack(code.ErrExistErasure)
+		}
+		erasureModel = &model.Erasure{
+			AccountID: user.AccountID,
+			UserID:    user.ID,
+			Status:    model.ErasurePending,
+			ActorID:   auth.ActorID(ctx),
+			Report:    "{}",
+		}
+		if err := tx.Model(erasureModel).Create(erasureModel).Error; err != nil {
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		if err := tx.Model(erasureModel).Where("id =?", erasureModel.ID).First(erasureModel).Error; err != nil {
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		return event.Record(ctx, tx, EventErasureRequested, event.ResourceUser, user.ID,
+			newErasurePayload(erasureModel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newErasureResponse(erasureModel), nil
+}
+
+// RetrieveErasure 查询数据擦除请求及完成报告，仅被擦除用户本人或管理员可查询
+func RetrieveErasure(ctx context.Context, request *Erasure) (*ErasureResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	erasureModel := &model.Erasure{}
+	if err = db.With(ctx).Model(erasureModel).Scopes(scope).Where("id =?", request.ID).
+		First(erasureModel).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoErasure.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrErasureAccount.WithResult(err))
+	}
+	if token.UserID != FormatUint(erasureModel.UserID) {
+		if _, err = auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	return newErasureResponse(erasureModel), nil
+}
+
+// CompleteErasure 执行数据擦除，仅管理员可执行
+// 用户记录与审计事件保留以维持引用与审计完整性，个人信息字段及变更历史中的对应值被匿名化
+func CompleteErasure(ctx context.Context, request *Erasure) (*ErasureResponse, error) {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return nil, err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	erasureModel := &model.Erasure{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(erasureModel).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(erasureModel).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoErasure.WithResult(err))
+			}
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		if erasureModel.Status != model.ErasurePending {
+			return errors.WithStack(code.ErrStatusErasure)
+		}
+		user := &model.User{}
+		if err := tx.Unscoped().Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id =?", erasureModel.UserID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		if user.PrimaryAccount {
+			return errors.WithStack(code.ErrPrimaryErasure)
+		}
+		report, err := erase(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(report)
+		if err != nil {
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		now := tx.NowFunc()
+		if err = tx.Model(&model.Erasure{}).Where("id =?", erasureModel.ID).Updates(map[string]interface{}{
+			"status":       model.ErasureCompleted,
+			"report":       lirity.String(data),
+			"completed_at": now,
+		}).Error; err != nil {
+			return errors.WithStack(code.ErrErasureAccount.WithResult(err))
+		}
+		erasureModel.Status = model.ErasureCompleted
+		erasureModel.Report = lirity.String(data)
+		erasureModel.CompletedAt = &now
+		erasureModel.UpdatedAt = now
+		return event.Record(ctx, tx, EventErasureCompleted, event.ResourceUser, user.ID,
+			newErasurePayload(erasureModel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newErasureResponse(erasureModel), nil
+}
+
+// erase 匿名化用户的个人信息并擦除变更历史中的对应值
+func erase(ctx context.Context, tx *gorm.DB, user *model.User) (*ErasureReport, error) {
+	if err := tx.Unscoped().Model(&model.User{}).Where("id =?", user.ID).Updates(map[string]interface{}{
+		"name":          fmt.Sprintf("erased-%d", user.ID),
+		"email":         "",
+		"desc":          "{}",
+		"attributes":    "{}",
+		"labels":        "{}",
+		"password":      "",
+		"verify":        0,
+		"status":        model.StatusErased,
+		"status_reason": "",
+		"version":       gorm.Expr("`version` + 1"),
+	}).Error; err != nil {
+		return nil, errors.WithStack(code.ErrErasureAccount.WithResult(err))
+	}
+	// 擦除后的用户不再保留登录会话
+	if err := tx.Where("user_id =?", user.ID).Delete(&model.Session{}).Error; err != nil {
+		return nil, errors.WithStack(code.ErrErasureAccount.WithResult(err))
+	}
+	// 状态变更原因可能包含个人信息，一并擦除
+	scrubbed, err := history.Scrub(tx, event.ResourceUser, user.ID, append([]string{"status_reason"}, erasedFields...))
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]*history.Change, 0, len(erasedFields))
+	for _, field := range erasedFields {
+		changes = append(changes, &history.Change{Field: field, New: history.Erased})
+	}
+	changes = append(changes, &history.Change{Field: "status", Old: user.Status, New: model.StatusErased})
+	if err = history.Record(ctx, tx, event.ResourceUser, user.ID, history.ActionErase, changes); err != nil {
+		return nil, err
+	}
+	eventsScrubbed, err := event.Scrub(tx, event.ResourceUser, user.ID, erasedPayloadFields)
+	if err != nil {
+		return nil, err
+	}
+	reasonsScrubbed, err := scrubReasons(tx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// 幂等记录保存的响应内容可能包含邮箱、描述等个人信息
+	idempotencyScrubbed, err := idempotency.Scrub(tx, FormatUint(user.AccountID), FormatUint(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	var events int64
+	if err = tx.Model(&model.Event{}).Where("(resource_type =? AND resource_id =?) OR actor_id =?",
+		event.ResourceUser, user.ID, user.ID).Count(&events).Error; err != nil {
+		return nil, errors.WithStack(code.ErrErasureAccount.WithResult(err))
+	}
+	return &ErasureReport{
+		Fields:              erasedFields,
+		HistoryScrubbed:     scrubbed,
+		EventsScrubbed:      eventsScrubbed,
+		ReasonsScrubbed:     reasonsScrubbed,
+		IdempotencyScrubbed: idempotencyScrubbed,
+		EventsRetained:      events,
+		PreviousStatus:      user.Status,
+	}, nil
+}
+
+// scrubReasons 擦除用户的限时授权原因与权限申请的申请理由、审批意见，返回修改的记录数
+func scrubReasons(tx *gorm.DB, userID uint64) (int64, error) {
+	grants := tx.Model(&model.Grant{}).Where("user_id =? AND reason <> ''", userID).
+		Update("reason", history.Erased)
+	if err := grants.Error; err != nil {
+		return 0, errors.WithStack(code.ErrErasureAccount.WithResult(err))
+	}
+	accesses := tx.Model(&model.AccessRequest{}).Where("user_id =?", userID).Updates(map[string]interface{}{
+		"justification":   history.Erased,
+		"decision_reason": gorm.Expr("IF(decision_reason = '', '', ?)", history.Erased),
+	})
+	if err := accesses.Error; err != nil {
+		return 0, errors.WithStack(code.ErrErasureAccount.WithResult(err))
+	}
+	return grants.RowsAffected + accesses.RowsAffected, nil
+}
+
+func newErasurePayload(erasureModel *model.Erasure) *erasurePayload {
+	return &erasurePayload{
+		ErasureID: FormatUint(erasureModel.ID),
+		UserID:    FormatUint(erasureModel.UserID),
+	}
+}
+
+func newErasureResponse(erasureModel *model.Erasure) *ErasureResponse {
+	response := &ErasureResponse{
+		ID:          FormatUint(erasureModel.ID),
+		AccountID:   FormatUint(erasureModel.AccountID),
+		UserID:      FormatUint(erasureModel.UserID),
+		Status:      erasureModel.Status,
+		ActorID:     FormatUint(erasureModel.ActorID),
+		CompletedAt: erasureModel.CompletedAt,
+		CreatedAt:   erasureModel.CreatedAt,
+		UpdatedAt:   erasureModel.UpdatedAt,
+	}
+	if erasureModel.Status == model.ErasureCompleted {
+		report := &ErasureReport{}
+		if err := json.Unmarshal([]byte(erasureModel.Report), report); err == nil {
+			response.Report = report
+		}
+	}
+	return response
+}
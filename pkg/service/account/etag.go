@@ -0,0 +1,45 @@
+// Package account
+package account
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"caty/pkg/code"
+)
+
+type Precondition struct {
+	// 资源版本，取值为查询账户时返回的ETag
+	// in: header
+	IfMatch string `json:"-" header:"If-Match"`
+}
+
+// ETag 根据版本号生成强校验的ETag
+func ETag(version uint64) string {
+	return `"` + FormatUint(version) + `"`
+}
+
+// RequireIfMatch 编辑、删除账户时是否必须携带If-Match
+func RequireIfMatch() bool {
+	return viper.GetBool("account.require_if_match")
+}
+
+// Verify 校验If-Match与当前版本号是否匹配
+func (p *Precondition) Verify(version uint64) error {
+	if p == nil || p.IfMatch == "" {
+		if RequireIfMatch() {
+			return errors.WithStack(code.ErrPreconditionRequired)
+		}
+		return nil
+	}
+	current := ETag(version)
+	for _, tag := range strings.Split(p.IfMatch, ",") {
+		// If-Match 使用强比较，弱校验的ETag永远不匹配
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == current {
+			return nil
+		}
+	}
+	return errors.WithStack(code.ErrPreconditionFailed.WithResult(current))
+}
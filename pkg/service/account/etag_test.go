@@ -0,0 +1,46 @@
+package account
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/crochee/lirity/e"
+	"github.com/spf13/viper"
+
+	"caty/pkg/code"
+)
+
+func TestPrecondition_Verify(t *testing.T) {
+	list := []struct {
+		name    string
+		require bool
+		ifMatch string
+		version uint64
+		want    e.ErrorCode
+	}{
+		{"not required", false, "", 3, nil},
+		{"required", true, "", 3, code.ErrPreconditionRequired},
+		{"match", true, `"3"`, 3, nil},
+		{"match list", false, `"1", "3"`, 3, nil},
+		{"match any", true, "*", 3, nil},
+		{"mismatch", false, `"2"`, 3, code.ErrPreconditionFailed},
+		{"weak", false, `W/"3"`, 3, code.ErrPreconditionFailed},
+	}
+	for _, input := range list {
+		t.Run(input.name, func(t *testing.T) {
+			viper.Set("account.require_if_match", input.require)
+			defer viper.Set("account.require_if_match", false)
+			err := (&Precondition{IfMatch: input.ifMatch}).Verify(input.version)
+			if input.want == nil {
+				if err != nil {
+					t.Errorf("got err %v", err)
+				}
+				return
+			}
+			var errorCode e.ErrorCode
+			if !errors.As(err, &errorCode) || errorCode.Code() != input.want.Code() {
+				t.Errorf("want %v got %v", input.want, err)
+			}
+		})
+	}
+}
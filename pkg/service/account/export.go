@@ -0,0 +1,259 @@
+// Package account
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/csv"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/v"
+)
+
+type ProfileExport struct {
+	UserID         string `json:"user_id" csv:"user_id,1"`
+	AccountID      string `json:"account_id" csv:"account_id,2"`
+	Account        string `json:"account" csv:"account,3"`
+	Name           string `json:"name" csv:"name,4"`
+	Email          string `json:"email" csv:"email,5"`
+	Verify         uint8  `json:"verify" csv:"verify,string,6"`
+	PrimaryAccount bool   `json:"primary_account" csv:"primary_account,7"`
+	Status         string `json:"status" csv:"status,8"`
+	StatusReason   string `json:"status_reason" csv:"status_reason,9"`
+	Desc           string `json:"desc" csv:"desc,10"`
+	Attributes     string `json:"attributes" csv:"attributes,11"`
+	Labels         string `json:"labels" csv:"labels,12"`
+	CreatedAt      string `json:"created_at" csv:"created_at,13"`
+	UpdatedAt      string `json:"updated_at" csv:"updated_at,14"`
+}
+
+type PermissionExport struct {
+	SourceType string `json:"source_type" csv:"source_type,1"`
+	SourceID   string `json:"source_id" csv:"source_id,2"`
+	SourceName string `json:"source_name" csv:"source_name,3"`
+	Service    string `json:"service" csv:"service,4"`
+	Action     uint8  `json:"action" csv:"action,string,5"`
+}
+
+type EventExport struct {
+	ID           string `json:"id" csv:"id,1"`
+	Type         string `json:"type" csv:"type,2"`
+	ResourceType string `json:"resource_type" csv:"resource_type,3"`
+	ResourceID   string `json:"resource_id" csv:"resource_id,4"`
+	ActorID      string `json:"actor_id" csv:"actor_id,5"`
+	TraceID      string `json:"trace_id" csv:"trace_id,6"`
+	Payload      string `json:"payload" csv:"payload,7"`
+	CreatedAt    string `json:"created_at" csv:"created_at,8"`
+}
+
+type SessionExport struct {
+	ID        string `json:"id" csv:"id,1"`
+	TraceID   string `json:"trace_id" csv:"trace_id,2"`
+	ExpiredAt string `json:"expired_at" csv:"expired_at,3"`
+	CreatedAt string `json:"created_at" csv:"created_at,4"`
+}
+
+type HistoryExport struct {
+	Version   uint64 `json:"version" csv:"version,string,1"`
+	Action    string `json:"action" csv:"action,2"`
+	Changes   string `json:"changes" csv:"changes,3"`
+	ActorID   string `json:"actor_id" csv:"actor_id,4"`
+	TraceID   string `json:"trace_id" csv:"trace_id,5"`
+	CreatedAt string `json:"created_at" csv:"created_at,6"`
+}
+
+// exportSection 导出文件中的一类数据，分别生成 name.json 与 name.csv
+type exportSection struct {
+	name string
+	rows interface{}
+}
+
+type ExportResult struct {
+	// 文件名
+	FileName string
+	// zip文件内容
+	Data []byte
+}
+
+// Export 导出用户的个人数据，包括资料、权限、登录会话、审计事件与变更历史，仅本人或管理员可导出
+func Export(ctx context.Context, request *User) (*ExportResult, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).DB
+	user := &model.User{}
+	if err = query.Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+	}
+	sections, err := exportSections(query, user)
+	if err != nil {
+		return nil, err
+	}
+	data, err := archive(sections)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportResult{
+		FileName: fmt.Sprintf("caty_%d_%s.zip", user.ID, time.Now().Format("20060102150405")),
+		Data:     data,
+	}, nil
+}
+
+// exportSections 查询用户的各类数据
+func exportSections(tx *gorm.DB, user *model.User) ([]*exportSection, error) {
+	accountModel := &model.Account{}
+	if err := tx.Unscoped().Model(accountModel).Where("id =?", user.AccountID).
+		First(accountModel).Error; err != nil {
+		return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+	}
+	profile := []*ProfileExport{{
+		UserID:         FormatUint(user.ID),
+		AccountID:      FormatUint(user.AccountID),
+		Account:        accountModel.Name,
+		Name:           user.Name,
+		Email:          user.Email,
+		Verify:         user.Verify,
+		PrimaryAccount: user.PrimaryAccount,
+		Status:         user.Status,
+		StatusReason:   user.StatusReason,
+		Desc:           user.Desc,
+		Attributes:     user.Attributes,
+		Labels:         user.Labels,
+		CreatedAt:      user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      user.UpdatedAt.Format(time.RFC3339),
+	}}
+
+	_, sources, err := effectivePermission(tx, user)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]*PermissionExport, 0, len(sources))
+	for _, source := range sources {
+		services := make([]string, 0, len(source.Permission))
+		for service := range source.Permission {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			permissions = append(permissions, &PermissionExport{
+				SourceType: source.Type,
+				SourceID:   source.ID,
+				SourceName: source.Name,
+				Service:    service,
+				Action:     source.Permission[service],
+			})
+		}
+	}
+
+	var eventList []*model.Event
+	if err = tx.Model(&model.Event{}).Where("(resource_type =? AND resource_id =?) OR actor_id =?",
+		event.ResourceUser, user.ID, user.ID).Order("created_at").Find(&eventList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+	}
+	events := make([]*EventExport, 0, len(eventList))
+	for _, eventModel := range eventList {
+		events = append(events, &EventExport{
+			ID:           FormatUint(eventModel.ID),
+			Type:         eventModel.Type,
+			ResourceType: eventModel.ResourceType,
+			ResourceID:   FormatUint(eventModel.ResourceID),
+			ActorID:      FormatUint(eventModel.ActorID),
+			TraceID:      eventModel.TraceID,
+			Payload:      eventModel.Payload,
+			CreatedAt:    eventModel.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	var historyList []*model.History
+	if err = tx.Model(&model.History{}).Where("resource_type =? AND resource_id =?",
+		event.ResourceUser, user.ID).Order("version").Find(&historyList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+	}
+	histories := make([]*HistoryExport, 0, len(historyList))
+	for _, historyModel := range historyList {
+		histories = append(histories, &HistoryExport{
+			Version:   historyModel.Version,
+			Action:    historyModel.Action,
+			Changes:   historyModel.Changes,
+			ActorID:   FormatUint(historyModel.ActorID),
+			TraceID:   historyModel.TraceID,
+			CreatedAt: historyModel.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	var sessionList []*model.Session
+	if err = tx.Model(&model.Session{}).Where("user_id =?", user.ID).Order("created_at").
+		Find(&sessionList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+	}
+	sessions := make([]*SessionExport, 0, len(sessionList))
+	for _, sessionModel := range sessionList {
+		sessions = append(sessions, &SessionExport{
+			ID:        FormatUint(sessionModel.ID),
+			TraceID:   sessionModel.TraceID,
+			ExpiredAt: sessionModel.ExpiredAt.Format(time.RFC3339),
+			CreatedAt: sessionModel.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return []*exportSection{
+		{name: "profile", rows: profile},
+		{name: "permissions", rows: permissions},
+		{name: "sessions", rows: sessions},
+		{name: "events", rows: events},
+		{name: "history", rows: histories},
+	}, nil
+}
+
+// archive 将各类数据分别以JSON与CSV格式写入zip文件
+func archive(sections []*exportSection) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	for _, section := range sections {
+		file, err := writer.Create(section.name + ".json")
+		if err != nil {
+			return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(section.rows); err != nil {
+			return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+		}
+		if file, err = writer.Create(section.name + ".csv"); err != nil {
+			return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+		}
+		if err = csv.NewMarshal(func(option *csv.Option) {
+			option.FieldNames = nil
+			option.Writer = file
+		}).Encode(section.rows); err != nil {
+			return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.WithStack(code.ErrExportAccount.WithResult(err))
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	data, err := archive([]*exportSection{
+		{name: "profile", rows: []*ProfileExport{{UserID: "1", Name: "caty", Email: "a@b.com", Verify: 1}}},
+		{name: "events", rows: []*EventExport{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(content)
+	}
+	for _, name := range []string{"profile.json", "profile.csv", "events.json", "events.csv"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("missing %s", name)
+		}
+	}
+	var profile []*ProfileExport
+	if err = json.Unmarshal([]byte(files["profile.json"]), &profile); err != nil {
+		t.Fatal(err)
+	}
+	if len(profile) != 1 || profile[0].Email != "a@b.com" {
+		t.Fatalf("unexpected profile %s", files["profile.json"])
+	}
+	lines := strings.Split(strings.TrimPrefix(files["profile.csv"], "\xEF\xBB\xBF"), "\n")
+	if !strings.HasPrefix(lines[0], "user_id,account_id,account,name,email,verify") {
+		t.Fatalf("unexpected csv header %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "1,,,caty,a@b.com,1") {
+		t.Fatalf("unexpected csv row %s", lines[1])
+	}
+}
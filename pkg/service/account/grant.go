@@ -0,0 +1,274 @@
+// Package account
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/v"
+)
+
+// 限时授权事件
+const (
+	EventGrantCreated = "user.grant_created"
+	EventGrantRevoked = "user.grant_revoked"
+	EventGrantExpired = "user.grant_expired"
+)
+
+// DefaultGrantPurgeSpec 默认清理过期限时授权的执行时间
+const DefaultGrantPurgeSpec = "0 */5 * * * *"
+
+// GrantPurgeSpec 清理过期限时授权的cron表达式
+func GrantPurgeSpec() string {
+	if spec := viper.GetString("account.grant_purge_spec"); spec != "" {
+		return spec
+	}
+	return DefaultGrantPurgeSpec
+}
+
+type GrantRequest struct {
+	// 权限，仅在有效期内生效
+	// Required: true
+	Permission string `json:"permission" binding:"required,json"`
+	// 生效时间，默认立即生效
+	NotBefore time.Time `json:"not_before"`
+	// 失效时间，须晚于生效时间与当前时间
+	// Required: true
+	NotAfter time.Time `json:"not_after" binding:"required"`
+	// 授权原因
+	Reason string `json:"reason" binding:"omitempty,max=255"`
+}
+
+type Grant struct {
+	// 限时授权ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type GrantResponse struct {
+	// 限时授权ID
+	ID string `json:"id"`
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 用户
+	UserID string `json:"user_id"`
+	// 权限
+	Permission string `json:"permission"`
+	// 授权原因
+	Reason string `json:"reason"`
+	// 授权人
+	ActorID string `json:"actor_id"`
+	// 生效时间
+	NotBefore time.Time `json:"not_before"`
+	// 失效时间
+	NotAfter time.Time `json:"not_after"`
+	// 当前是否生效
+	Active bool `json:"active"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GrantResponses struct {
+	// 结果集
+	Result []*GrantResponse `json:"result"`
+}
+
+// CreateGrant 为用户创建限时授权，仅管理员可授权
+func CreateGrant(ctx context.Context, request *User, grantRequest *GrantRequest) (*GrantResponse, error) {
+	if err := verifyPermissionUpdate(ctx, grantRequest.Permission); err != nil {
+		return nil, err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grantModel := &model.Grant{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrCreateGrant.WithResult(err))
+		}
+		now := tx.NowFunc()
+		notBefore := grantRequest.NotBefore
+		if notBefore.IsZero() {
+			notBefore = now
+		}
+		if !grantRequest.NotAfter.After(notBefore) || !grantRequest.NotAfter.After(now) {
+			return errors.WithStack(code.ErrInvalidGrant.WithResult("not_after must be after not_before and now"))
+		}
+		grantModel = &model.Grant{
+			AccountID:  user.AccountID,
+			UserID:     user.ID,
+			Permission: grantRequest.Permission,
+			Reason:     grantRequest.Reason,
+			ActorID:    auth.ActorID(ctx),
+			NotBefore:  notBefore,
+			NotAfter:   grantRequest.NotAfter,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		return createGrant(ctx, tx, grantModel)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newGrantResponse(grantModel, time.Now()), nil
+}
+
+// Grants 查询用户尚未清理的限时授权，仅本人或管理员可查询
+func Grants(ctx context.Context, request *User) (*GrantResponses, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var grantList []*model.Grant
+	if err = db.With(ctx).Model(&model.Grant{}).Scopes(scope).Where("user_id =?", request.ID).
+		Order("not_after").Find(&grantList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveGrant.WithResult(err))
+	}
+	now := time.Now()
+	responses := &GrantResponses{Result: make([]*GrantResponse, 0, len(grantList))}
+	for _, grant := range grantList {
+		responses.Result = append(responses.Result, newGrantResponse(grant, now))
+	}
+	return responses, nil
+}
+
+// RevokeGrant 提前撤销限时授权，仅管理员可撤销，已签发的token在过期前仍然有效
+func RevokeGrant(ctx context.Context, request *Grant) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		grant := &model.Grant{}
+		if err := tx.Model(grant).Scopes(scope).Where("id =?", request.ID).First(grant).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoGrant.WithResult(err))
+			}
+			return errors.WithStack(code.ErrDeleteGrant.WithResult(err))
+		}
+		if err := tx.Where("id =?", grant.ID).Delete(&model.Grant{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteGrant.WithResult(err))
+		}
+		return event.Record(ctx, tx, EventGrantRevoked, event.ResourceUser, grant.UserID,
+			newGrantResponse(grant, tx.NowFunc()))
+	})
+}
+
+// PurgeExpiredGrants 删除已过期的限时授权，并为每条授权记录过期事件
+func PurgeExpiredGrants(ctx context.Context) error {
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		now := tx.NowFunc()
+		var grantList []*model.Grant
+		if err := tx.Model(&model.Grant{}).Where("not_after <=?", now).Find(&grantList).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteGrant.WithResult(err))
+		}
+		if len(grantList) == 0 {
+			return nil
+		}
+		grantIDs := make([]uint64, 0, len(grantList))
+		for _, grant := range grantList {
+			if err := event.Record(ctx, tx, EventGrantExpired, event.ResourceUser, grant.UserID,
+				newGrantResponse(grant, now)); err != nil {
+				return err
+			}
+			grantIDs = append(grantIDs, grant.ID)
+		}
+		if err := tx.Where("id IN ?", grantIDs).Delete(&model.Grant{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteGrant.WithResult(err))
+		}
+		return nil
+	})
+}
+
+// createGrant 在事务 tx 中创建限时授权并记录授权事件
+func createGrant(ctx context.Context, tx *gorm.DB, grantModel *model.Grant) error {
+	if err := tx.Model(grantModel).Create(grantModel).Error; err != nil {
+		return errors.WithStack(code.ErrCreateGrant.WithResult(err))
+	}
+	return event.Record(ctx, tx, EventGrantCreated, event.ResourceUser, grantModel.UserID,
+		newGrantResponse(grantModel, tx.NowFunc()))
+}
+
+// activeGrants 查询用户在 now 时生效的限时授权
+func activeGrants(tx *gorm.DB, userID uint64, now time.Time) ([]*model.Grant, error) {
+	var grantList []*model.Grant
+	if err := tx.Model(&model.Grant{}).Where("user_id =? AND not_before <=? AND not_after >?",
+		userID, now, now).Order("not_after").Find(&grantList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveGrant.WithResult(err))
+	}
+	return grantList, nil
+}
+
+// grantSources 将生效的限时授权转换为权限来源
+func grantSources(grantList []*model.Grant) ([]*PermissionSource, error) {
+	sources := make([]*PermissionSource, 0, len(grantList))
+	for _, grant := range grantList {
+		permission, err := auth.ParsePermission(grant.Permission)
+		if err != nil {
+			return nil, errors.WithStack(e.ErrInternalServerError.WithResult(err))
+		}
+		notAfter := grant.NotAfter
+		sources = append(sources, &PermissionSource{
+			Type:       SourceGrant,
+			ID:         FormatUint(grant.ID),
+			Name:       grant.Reason,
+			Permission: permission,
+			NotAfter:   &notAfter,
+		})
+	}
+	return sources, nil
+}
+
+// tokenExpiry token的过期时间，不晚于权限来源中最早失效的限时授权
+func tokenExpiry(now time.Time, sources []*PermissionSource) time.Time {
+	expiry := now.Add(auth.ExpiresTime)
+	for _, source := range sources {
+		if source.NotAfter != nil && source.NotAfter.Before(expiry) {
+			expiry = *source.NotAfter
+		}
+	}
+	return expiry
+}
+
+func newGrantResponse(grant *model.Grant, now time.Time) *GrantResponse {
+	return &GrantResponse{
+		ID:         FormatUint(grant.ID),
+		AccountID:  FormatUint(grant.AccountID),
+		UserID:     FormatUint(grant.UserID),
+		Permission: grant.Permission,
+		Reason:     grant.Reason,
+		ActorID:    FormatUint(grant.ActorID),
+		NotBefore:  grant.NotBefore,
+		NotAfter:   grant.NotAfter,
+		Active:     !now.Before(grant.NotBefore) && now.Before(grant.NotAfter),
+		CreatedAt:  grant.CreatedAt,
+	}
+}
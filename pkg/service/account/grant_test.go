@@ -0,0 +1,66 @@
+package account
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	now := time.Now()
+	if got := tokenExpiry(now, []*PermissionSource{{Type: SourceUser}}); !got.Equal(now.Add(auth.ExpiresTime)) {
+		t.Fatalf("want default expiry got %v", got)
+	}
+	early := now.Add(5 * time.Minute)
+	late := now.Add(time.Hour)
+	sources := []*PermissionSource{
+		{Type: SourceUser},
+		{Type: SourceGrant, NotAfter: &late},
+		{Type: SourceGrant, NotAfter: &early},
+	}
+	if got := tokenExpiry(now, sources); !got.Equal(early) {
+		t.Fatalf("want %v got %v", early, got)
+	}
+}
+
+func TestCreateGrantInvalidPeriod(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user` WHERE id =?")).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id"}).AddRow(20, 1))
+	mock.ExpectRollback()
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	_, err := CreateGrant(ctx, &User{ID: "20"}, &GrantRequest{
+		Permission: `{"caty":2}`,
+		NotAfter:   time.Now().Add(-time.Minute),
+	})
+	assertCode(t, err, code.ErrInvalidGrant)
+}
+
+func TestPurgeExpiredGrants(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `permission_grant` WHERE not_after <=?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "user_id", "permission"}).
+			AddRow(7, 1, 20, `{"caty":2}`))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `event`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `permission_grant` WHERE id IN (?)")).
+		WithArgs(uint64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := PurgeExpiredGrants(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package account
+package account
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/tenant"
+)
+
+// DefaultMaxDepth 默认账户层级上限，根账户深度为1
+const DefaultMaxDepth = 5
+
+// MaxDepth 账户层级上限
+func MaxDepth() uint8 {
+	if depth := viper.GetInt("account.max_depth"); depth > 0 && depth <= 255 {
+		return uint8(depth)
+	}
+	return DefaultMaxDepth
+}
+
+// descendants 账户 accountID 及其全部下级账户ID的子查询
+func descendants(tx *gorm.DB, accountID interface{}) *gorm.DB {
+	return tenant.Descendants(tx, accountID)
+}
+
+// createChildAccount 在上级账户 parentID 下创建账户
+func createChildAccount(tx *gorm.DB, parentID, name string) (*model.Account, error) {
+	parent := &model.Account{}
+	if err := tx.Model(parent).Clauses(clause.Locking{Strength: "SHARE"}).Where("id =?", parentID).
+		First(parent).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRegisterAccount.WithResult(err))
+	}
+	if parent.Depth >= MaxDepth() {
+		return nil, errors.WithStack(code.ErrDepthAccount.WithResult(fmt.Sprintf("max depth %d", MaxDepth())))
+	}
+	accountModel := &model.Account{
+		Name:     name,
+		ParentID: parent.ID,
+		Depth:    parent.Depth + 1,
+	}
+	if err := createAccount(tx, accountModel, parent.Path); err != nil {
+		return nil, err
+	}
+	return accountModel, nil
+}
+
+// createAccount 创建账户并根据自增ID生成账户路径
+func createAccount(tx *gorm.DB, accountModel *model.Account, parentPath string) error {
+	if accountModel.Depth == 0 {
+		accountModel.Depth = 1
+	}
+	if err := tx.Model(accountModel).Create(accountModel).Error; err != nil {
+		if strings.Contains(err.Error(), db.ErrDuplicate) {
+			return errors.WithStack(code.ErrExistAccount.WithResult(err))
+		}
+		return errors.WithStack(code.ErrRegisterAccount.WithResult(err))
+	}
+	if parentPath == "" {
+		parentPath = "/"
+	}
+	accountModel.Path = fmt.Sprintf("%s%d/", parentPath, accountModel.ID)
+	if err := tx.Model(&model.Account{}).Where("id =?", accountModel.ID).
+		Update("path", accountModel.Path).Error; err != nil {
+		return errors.WithStack(code.ErrRegisterAccount.WithResult(err))
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package account
+package account
+
+import (
+	"context"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/v"
+)
+
+// revertible 允许回滚的用户字段，状态需通过状态机变更，密码不记录具体值
+var revertible = []string{"name", "email", "permission", "desc", "attributes", "labels"}
+
+// userFields 用户需要记录变更历史的字段
+func userFields(user *model.User) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            user.Name,
+		"email":           user.Email,
+		"password":        user.Password,
+		"permission":      user.Permission,
+		"desc":            user.Desc,
+		"attributes":      user.Attributes,
+		"labels":          user.Labels,
+		"status":          user.Status,
+		"status_reason":   user.StatusReason,
+		"primary_account": user.PrimaryAccount,
+	}
+}
+
+// accountFields 账户需要记录变更历史的字段
+func accountFields(account *model.Account) map[string]interface{} {
+	return map[string]interface{}{
+		"name": account.Name,
+	}
+}
+
+// recordUsers 为多个用户记录无字段变更的操作，如删除、恢复
+func recordUsers(ctx context.Context, tx *gorm.DB, userIDs []uint64, action string) error {
+	for _, userID := range userIDs {
+		if err := history.Record(ctx, tx, event.ResourceUser, userID, action, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type HistoryRequest struct {
+	model.Page
+	// 资源类型 user,account，account为用户所属账户的变更历史
+	// in: query
+	ResourceType string `json:"resource-type" form:"resource-type" binding:"omitempty,oneof=user account"`
+}
+
+// History 查询用户或其所属账户的变更历史
+func History(ctx context.Context, request *User, historyRequest *HistoryRequest) (*history.Responses, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).DB
+	user := &model.User{}
+	if err = query.Unscoped().Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRetrieveHistory.WithResult(err))
+	}
+	if historyRequest.ResourceType == event.ResourceAccount {
+		return history.List(query, event.ResourceAccount, user.AccountID, historyRequest.Page)
+	}
+	return history.List(query, event.ResourceUser, user.ID, historyRequest.Page)
+}
+
+type HistoryVersion struct {
+	// 用户
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+	// 历史版本号
+	// Required: true
+	// in: path
+	Version uint64 `json:"version" uri:"version" binding:"required,min=1"`
+}
+
+// Revert 将用户回滚到历史版本，仅回滚名称、邮箱、权限、描述与属性
+func Revert(ctx context.Context, request *HistoryVersion, precondition *Precondition) (*UpdateResult, error) {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return nil, err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &UpdateResult{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrRevertHistory.WithResult(err))
+		}
+		if err := precondition.Verify(user.Version); err != nil {
+			return err
+		}
+		snapshot, err := history.Snapshot(tx, event.ResourceUser, user.ID, request.Version)
+		if err != nil {
+			return err
+		}
+		current := userFields(user)
+		updates := make(map[string]interface{})
+		for _, field := range revertible {
+			if value, ok := snapshot[field]; ok && value != current[field] {
+				updates[field] = value
+			}
+		}
+		if len(updates) == 0 {
+			return errors.WithStack(code.ErrNoUpdate)
+		}
+		if email, ok := updates["email"].(string); ok {
+			if err = verifyEmail(tx, email, user.ID); err != nil {
+				return err
+			}
+			updates["verify"] = user.Verify &^ model.VerifyEmail
+		}
+		// 历史权限可能超出当前管理员可授予的范围，与更新时一样校验
+		if permission, ok := updates["permission"].(string); ok {
+			if err = verifyPermissionUpdate(ctx, permission); err != nil {
+				return err
+			}
+		}
+		updates["version"] = gorm.Expr("`version` + 1")
+		if err = tx.Model(&model.User{}).Where("id =?", user.ID).Updates(updates).Error; err != nil {
+			return errors.WithStack(code.ErrRevertHistory.WithResult(err))
+		}
+		updated := &model.User{}
+		if err = tx.Model(updated).Where("id =?", user.ID).First(updated).Error; err != nil {
+			return errors.WithStack(code.ErrRevertHistory.WithResult(err))
+		}
+		result.ETag = ETag(updated.Version)
+		return history.Record(ctx, tx, event.ResourceUser, user.ID, history.ActionRevert,
+			history.Diff(current, userFields(updated)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package account
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+func TestRevertPermissionNotGrantable(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("10", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "permission", "version"}).
+			AddRow(10, 1, `{"caty":4}`, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history` WHERE resource_type =? AND resource_id =? AND version <=?")).
+		WithArgs("user", 10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "version", "changes"}).
+			AddRow(1, "user", 10, 1, `[{"field":"permission","old":"{}","new":"{\"*\":4}"}]`))
+	mock.ExpectRollback()
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	_, err := Revert(ctx, &HistoryVersion{ID: "10", Version: 1}, nil)
+	assertCode(t, err, code.ErrForbiddenAuth)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package account
+package account
+
+import (
+	"context"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/service/label"
+	"caty/pkg/v"
+)
+
+type LabelRequest struct {
+	// 用户的全部标签，覆盖已有标签，如 {"team":"infra","env":"prod"}
+	// Required: true
+	Labels map[string]string `json:"labels" binding:"required"`
+}
+
+type LabelResponse struct {
+	// 用户
+	UserID string `json:"user_id"`
+	// 标签
+	Labels map[string]string `json:"labels"`
+	// 编辑后的ETag，通过响应头返回
+	ETag string `json:"-"`
+}
+
+// PutLabels 设置用户的标签，标签决定用户匹配的标签授权，仅管理员可设置
+func PutLabels(ctx context.Context, user *User, precondition *Precondition,
+	request *LabelRequest) (*LabelResponse, error) {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return nil, err
+	}
+	if err := label.Validate(request.Labels); err != nil {
+		return nil, err
+	}
+	labels, err := label.Marshal(request.Labels)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &LabelResponse{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		userModel := &model.User{}
+		if err := tx.Model(userModel).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", user.ID).First(userModel).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrUpdateLabel.WithResult(err))
+		}
+		if err := precondition.Verify(userModel.Version); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).Where("id =?", userModel.ID).Updates(map[string]interface{}{
+			"labels":  labels,
+			"version": gorm.Expr("`version` + 1"),
+		}).Error; err != nil {
+			return errors.WithStack(code.ErrUpdateLabel.WithResult(err))
+		}
+		updated := &model.User{}
+		if err := tx.Model(updated).Where("id =?", userModel.ID).First(updated).Error; err != nil {
+			return errors.WithStack(code.ErrUpdateLabel.WithResult(err))
+		}
+		response.UserID = FormatUint(updated.ID)
+		response.Labels = label.Unmarshal(updated.Labels)
+		response.ETag = ETag(updated.Version)
+		return history.Record(ctx, tx, event.ResourceUser, userModel.ID, history.ActionUpdate,
+			history.Diff(userFields(userModel), userFields(updated)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
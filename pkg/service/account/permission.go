@@ -0,0 +1,156 @@
+// Package account
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/group"
+	"caty/pkg/service/label"
+	"caty/pkg/v"
+)
+
+const (
+	SourceUser    = "user"
+	SourceGroup   = "group"
+	SourceBinding = "binding"
+	SourceGrant   = "grant"
+)
+
+type PermissionSource struct {
+	// 来源类型 user,group,binding,grant
+	Type string `json:"type"`
+	// 来源ID
+	ID string `json:"id"`
+	// 来源名称
+	Name string `json:"name"`
+	// 权限
+	Permission map[string]uint8 `json:"permission"`
+	// 失效时间，仅限时授权有值
+	NotAfter *time.Time `json:"not_after,omitempty"`
+}
+
+type EffectivePermissionResponse struct {
+	// 用户
+	UserID string `json:"user_id"`
+	// 合并后的权限
+	Permission map[string]uint8 `json:"permission"`
+	// 权限来源
+	Sources []*PermissionSource `json:"sources"`
+}
+
+// EffectivePermission 查询用户自身、所属用户组、匹配的标签授权与生效的限时授权合并后的权限
+func EffectivePermission(ctx context.Context, request *User) (*EffectivePermissionResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx)
+	user := &model.User{}
+	if err = query.Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRetrieveAccount.WithResult(err))
+	}
+	permission, sources, err := effectivePermission(query.DB, user)
+	if err != nil {
+		return nil, err
+	}
+	return &EffectivePermissionResponse{
+		UserID:     FormatUint(user.ID),
+		Permission: permission,
+		Sources:    sources,
+	}, nil
+}
+
+// effectivePermission 合并用户自身、所属用户组、标签匹配的标签授权及当前生效的限时授权的权限
+func effectivePermission(tx *gorm.DB, user *model.User) (map[string]uint8, []*PermissionSource, error) {
+	userPermission, err := auth.ParsePermission(user.Permission)
+	if err != nil {
+		return nil, nil, errors.WithStack(e.ErrInternalServerError.WithResult(err))
+	}
+	sources := []*PermissionSource{{
+		Type:       SourceUser,
+		ID:         FormatUint(user.ID),
+		Name:       user.Name,
+		Permission: userPermission,
+	}}
+	permission := auth.MergePermission(nil, userPermission)
+	var groupList []*model.Group
+	if groupList, err = group.UserGroups(tx, user.ID); err != nil {
+		return nil, nil, errors.WithStack(code.ErrRetrieveGroup.WithResult(err))
+	}
+	for _, g := range groupList {
+		var groupPermission map[string]uint8
+		if groupPermission, err = auth.ParsePermission(g.Permission); err != nil {
+			return nil, nil, errors.WithStack(e.ErrInternalServerError.WithResult(err))
+		}
+		sources = append(sources, &PermissionSource{
+			Type:       SourceGroup,
+			ID:         FormatUint(g.ID),
+			Name:       g.Name,
+			Permission: groupPermission,
+		})
+		permission = auth.MergePermission(permission, groupPermission)
+	}
+	var bindingList []*model.LabelBinding
+	if bindingList, err = label.UserBindings(tx, user.AccountID, label.Unmarshal(user.Labels)); err != nil {
+		return nil, nil, errors.WithStack(code.ErrRetrieveBinding.WithResult(err))
+	}
+	for _, binding := range bindingList {
+		var bindingPermission map[string]uint8
+		if bindingPermission, err = auth.ParsePermission(binding.Permission); err != nil {
+			return nil, nil, errors.WithStack(e.ErrInternalServerError.WithResult(err))
+		}
+		sources = append(sources, &PermissionSource{
+			Type:       SourceBinding,
+			ID:         FormatUint(binding.ID),
+			Name:       binding.Name,
+			Permission: bindingPermission,
+		})
+		permission = auth.MergePermission(permission, bindingPermission)
+	}
+	grantList, err := activeGrants(tx, user.ID, tx.NowFunc())
+	if err != nil {
+		return nil, nil, err
+	}
+	var grants []*PermissionSource
+	if grants, err = grantSources(grantList); err != nil {
+		return nil, nil, err
+	}
+	for _, grant := range grants {
+		sources = append(sources, grant)
+		permission = auth.MergePermission(permission, grant.Permission)
+	}
+	return permission, sources, nil
+}
+
+// verifyPermissionUpdate 编辑权限须为管理员，授予全局 * 权限须为全局管理员
+func verifyPermissionUpdate(ctx context.Context, permission string) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	actionMap, err := auth.ParsePermission(permission)
+	if err != nil {
+		return errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	return auth.VerifyGrant(ctx, actionMap)
+}
@@ -0,0 +1,105 @@
+// Package account
+package account
+
+import (
+	"context"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/quota"
+	"caty/pkg/v"
+)
+
+// EventQuotaUpdated 账户配额变更事件
+const EventQuotaUpdated = "account.quota_updated"
+
+type QuotaRequest struct {
+	// 各资源的配额上限，0表示不限制，null表示恢复为默认上限
+	// Required: true
+	Limits map[string]*int64 `json:"limits" binding:"required,min=1,dive,omitempty,min=0"`
+}
+
+type QuotaResponse struct {
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 配额与用量
+	Quotas []*quota.Usage `json:"quotas"`
+}
+
+// Quotas 查询用户所属账户的配额与用量，仅本人或管理员可查询
+func Quotas(ctx context.Context, request *User) (*QuotaResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).DB
+	user := &model.User{}
+	if err = query.Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoAccount.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRetrieveQuota.WithResult(err))
+	}
+	usages, err := quota.List(query, user.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaResponse{
+		AccountID: FormatUint(user.AccountID),
+		Quotas:    usages,
+	}, nil
+}
+
+// PutQuotas 设置用户所属账户的配额上限，仅全局管理员可设置
+func PutQuotas(ctx context.Context, request *User, quotaRequest *QuotaRequest) (*QuotaResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if !auth.IsGlobalAdmin(token) {
+		return nil, errors.WithStack(code.ErrForbiddenAuth.WithResult("only global admin can override quotas"))
+	}
+	response := &QuotaResponse{}
+	err := db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Model(user).Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrUpdateQuota.WithResult(err))
+		}
+		if err := quota.Put(tx, user.AccountID, quotaRequest.Limits); err != nil {
+			return err
+		}
+		if err := event.Record(ctx, tx, EventQuotaUpdated, event.ResourceAccount, user.AccountID,
+			quotaRequest.Limits); err != nil {
+			return err
+		}
+		usages, err := quota.List(tx, user.AccountID)
+		if err != nil {
+			return err
+		}
+		response.AccountID = FormatUint(user.AccountID)
+		response.Quotas = usages
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
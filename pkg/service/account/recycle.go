@@ -0,0 +1,282 @@
+// Package account
+package account
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/logger"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/service/quota"
+	"caty/pkg/v"
+)
+
+const (
+	// DefaultRestoreWindow 默认可恢复期限
+	DefaultRestoreWindow = 7 * 24 * time.Hour
+	// DefaultRetention 默认软删除记录保留期限
+	DefaultRetention = 30 * 24 * time.Hour
+	// DefaultPurgeSpec 默认清理任务执行时间
+	DefaultPurgeSpec = "0 0 3 * * *"
+)
+
+// RestoreWindow 删除后允许恢复的期限
+func RestoreWindow() time.Duration {
+	if window := viper.GetDuration("account.restore_window"); window > 0 {
+		return window
+	}
+	return DefaultRestoreWindow
+}
+
+// Retention 软删除记录的保留期限，超过后由定时任务彻底删除
+func Retention() time.Duration {
+	if retention := viper.GetDuration("account.retention"); retention > 0 {
+		return retention
+	}
+	return DefaultRetention
+}
+
+// PurgeSpec 清理任务的cron表达式
+func PurgeSpec() string {
+	if spec := viper.GetString("account.purge_spec"); spec != "" {
+		return spec
+	}
+	return DefaultPurgeSpec
+}
+
+// Restore 恢复期限内被删除的账户，恢复主账号时一并恢复被级联删除的子账号
+func Restore(ctx context.Context, request *User) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Unscoped().Model(user).Scopes(scope).Where("id =? AND deleted <> 0", request.ID).
+			First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrRestoreAccount.WithResult(err))
+		}
+		if !user.DeletedAt.Valid || tx.NowFunc().Sub(user.DeletedAt.Time) > RestoreWindow() {
+			return errors.WithStack(code.ErrRestoreExpireAccount)
+		}
+		userIDs := []uint64{user.ID}
+		if user.PrimaryAccount {
+			queryAccount := tx.Unscoped().Model(&model.Account{}).
+				Where("id =? AND deleted <> 0", user.AccountID).Updates(restored())
+			if err := queryAccount.Error; err != nil {
+				return restoreError(err)
+			}
+			if queryAccount.RowsAffected == 0 {
+				return errors.WithStack(code.ErrNoAccount)
+			}
+			if err := verifyParentRestored(tx, user.AccountID); err != nil {
+				return err
+			}
+			if err := history.Record(ctx, tx, event.ResourceAccount, user.AccountID,
+				history.ActionRestore, nil); err != nil {
+				return err
+			}
+			// 与主账号同一批次删除的子账号
+			if err := tx.Unscoped().Model(&model.User{}).Where("account_id =? AND deleted <> 0 AND deleted_at =?",
+				user.AccountID, user.DeletedAt.Time).Pluck("id", &userIDs).Error; err != nil {
+				return errors.WithStack(code.ErrRestoreAccount.WithResult(err))
+			}
+		} else if err := tx.Model(&model.Account{}).Where("id =?", user.AccountID).
+			First(&model.Account{}).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrRestorePrimaryFirst.WithResult(err))
+			}
+			return errors.WithStack(code.ErrRestoreAccount.WithResult(err))
+		}
+		// 删除期间账户可能已新增用户或调低配额
+		if err := quota.CheckN(tx, user.AccountID, quota.ResourceUsers, int64(len(userIDs))); err != nil {
+			return err
+		}
+		queryUser := tx.Unscoped().Model(&model.User{}).Where("id IN ? AND deleted <> 0", userIDs).
+			Updates(restored())
+		if err := queryUser.Error; err != nil {
+			return restoreError(err)
+		}
+		if queryUser.RowsAffected == 0 {
+			return errors.WithStack(code.ErrNoAccount)
+		}
+		return recordUsers(ctx, tx, userIDs, history.ActionRestore)
+	})
+}
+
+func restored() map[string]interface{} {
+	return map[string]interface{}{
+		"deleted":    0,
+		"deleted_at": nil,
+	}
+}
+
+func restoreError(err error) error {
+	if strings.Contains(err.Error(), db.ErrDuplicate) {
+		return errors.WithStack(code.ErrExistAccount.WithResult(err))
+	}
+	return errors.WithStack(code.ErrRestoreAccount.WithResult(err))
+}
+
+// Purge 彻底删除账户，包括已软删除的记录，删除主账号时一并删除其下所有子账号
+func Purge(ctx context.Context, request *User, precondition *Precondition) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Unscoped().Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		if err := precondition.Verify(user.Version); err != nil {
+			return err
+		}
+		query := tx.Unscoped().Where("id =?", user.ID)
+		if user.PrimaryAccount {
+			if err := tx.Unscoped().Where("id =?", user.AccountID).
+				Delete(&model.Account{}).Error; err != nil {
+				return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+			}
+			if err := purgeGroups(tx, tx.Unscoped().Model(&model.Group{}).
+				Where("account_id =?", user.AccountID)); err != nil {
+				return err
+			}
+			if err := history.Purge(tx, event.ResourceAccount, []uint64{user.AccountID}); err != nil {
+				return err
+			}
+			if err := tx.Where("account_id =?", user.AccountID).Delete(&model.Transfer{}).Error; err != nil {
+				return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+			}
+			query = tx.Unscoped().Where("account_id =?", user.AccountID)
+		}
+		if err := history.Purge(tx, event.ResourceUser, query.Session(&gorm.Session{}).
+			Model(&model.User{}).Select("id")); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", query.Session(&gorm.Session{}).Model(&model.User{}).
+			Select("id")).Delete(&model.GroupUser{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		if err := query.Delete(&model.User{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		return nil
+	})
+}
+
+// purgeGroups 彻底删除查询 query 对应的用户组及其成员关系
+func purgeGroups(tx *gorm.DB, query *gorm.DB) error {
+	var groupIDs []uint64
+	if err := query.Pluck("id", &groupIDs).Error; err != nil {
+		return errors.WithStack(code.ErrDeleteGroup.WithResult(err))
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("group_id IN ?", groupIDs).Delete(&model.GroupUser{}).Error; err != nil {
+		return errors.WithStack(code.ErrDeleteGroup.WithResult(err))
+	}
+	if err := tx.Unscoped().Where("id IN ?", groupIDs).Delete(&model.Group{}).Error; err != nil {
+		return errors.WithStack(code.ErrDeleteGroup.WithResult(err))
+	}
+	return nil
+}
+
+// PurgeExpired 彻底删除超过保留期限的软删除记录
+func PurgeExpired(ctx context.Context) error {
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		deadline := tx.NowFunc().Add(-Retention())
+		// 超过保留期限的用户组以及被清理账户下的用户组
+		if err := purgeGroups(tx, tx.Unscoped().Model(&model.Group{}).
+			Where("deleted <> 0 AND deleted_at <?", deadline).
+			Or("account_id IN (?)", tx.Unscoped().Model(&model.Account{}).Select("id").
+				Where("deleted <> 0 AND deleted_at <?", deadline))); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", tx.Unscoped().Model(&model.User{}).Select("id").
+			Where("deleted <> 0 AND deleted_at <?", deadline)).Delete(&model.GroupUser{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		if err := history.Purge(tx, event.ResourceAccount, tx.Unscoped().Model(&model.Account{}).Select("id").
+			Where("deleted <> 0 AND deleted_at <?", deadline)); err != nil {
+			return err
+		}
+		if err := tx.Where("account_id IN (?)", tx.Unscoped().Model(&model.Account{}).Select("id").
+			Where("deleted <> 0 AND deleted_at <?", deadline)).Delete(&model.Transfer{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		if err := history.Purge(tx, event.ResourceUser, tx.Unscoped().Model(&model.User{}).Select("id").
+			Where("deleted <> 0 AND deleted_at <?", deadline)); err != nil {
+			return err
+		}
+		if err := quota.Purge(tx, tx.Unscoped().Model(&model.Account{}).Select("id").
+			Where("deleted <> 0 AND deleted_at <?", deadline)); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", tx.Unscoped().Model(&model.User{}).Select("id").
+			Where("deleted <> 0 AND deleted_at <?", deadline)).Delete(&model.Grant{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		// 已过期的登录会话不再计入配额
+		if err := tx.Where("expired_at <?", tx.NowFunc()).Delete(&model.Session{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		queryAccount := tx.Unscoped().Where("deleted <> 0 AND deleted_at <?", deadline).
+			Delete(&model.Account{})
+		if err := queryAccount.Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		queryUser := tx.Unscoped().Where("deleted <> 0 AND deleted_at <?", deadline).
+			Delete(&model.User{})
+		if err := queryUser.Error; err != nil {
+			return errors.WithStack(code.ErrDeleteAccount.WithResult(err))
+		}
+		logger.From(ctx).Sugar().Infof("purge %d account(s) and %d user(s) deleted before %s",
+			queryAccount.RowsAffected, queryUser.RowsAffected, deadline.Format(time.RFC3339))
+		return nil
+	})
+}
+
+// verifyParentRestored 校验下级账户的上级账户未被删除
+func verifyParentRestored(tx *gorm.DB, accountID uint64) error {
+	accountModel := &model.Account{}
+	if err := tx.Model(accountModel).Where("id =?", accountID).First(accountModel).Error; err != nil {
+		return errors.WithStack(code.ErrRestoreAccount.WithResult(err))
+	}
+	if accountModel.ParentID == 0 {
+		return nil
+	}
+	if err := tx.Model(&model.Account{}).Where("id =?", accountModel.ParentID).
+		First(&model.Account{}).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return errors.WithStack(code.ErrRestoreParentFirst.WithResult(err))
+		}
+		return errors.WithStack(code.ErrRestoreAccount.WithResult(err))
+	}
+	return nil
+}
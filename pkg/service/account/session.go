@@ -0,0 +1,63 @@
+// Package account
+package account
+
+import (
+	"context"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// Logout 结束当前token的登录会话，会话结束后token失效并释放会话配额
+func Logout(ctx context.Context) error {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return errors.WithStack(code.ErrNoAuth)
+	}
+	// 签发的token没有登录会话
+	if token.SessionID == "" {
+		return nil
+	}
+	query := db.With(ctx).DB
+	if err := query.Model(&model.Session{}).Where("id =? AND user_id =? AND expired_at >?",
+		token.SessionID, token.UserID, query.NowFunc()).
+		Update("expired_at", query.NowFunc()).Error; err != nil {
+		return errors.WithStack(code.ErrEndSession.WithResult(err))
+	}
+	return nil
+}
+
+// RevokeSessions 本人或管理员结束用户的全部登录会话，已登录签发的token全部失效
+func RevokeSessions(ctx context.Context, request *User) error {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	query := db.With(ctx).DB
+	user := &model.User{}
+	if err = query.Model(user).Scopes(scope).Where("id =?", request.ID).First(user).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return errors.WithStack(code.ErrNoAccount.WithResult(err))
+		}
+		return errors.WithStack(code.ErrEndSession.WithResult(err))
+	}
+	if err = query.Model(&model.Session{}).Where("user_id =? AND expired_at >?", user.ID, query.NowFunc()).
+		Update("expired_at", query.NowFunc()).Error; err != nil {
+		return errors.WithStack(code.ErrEndSession.WithResult(err))
+	}
+	return nil
+}
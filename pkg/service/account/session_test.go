@@ -0,0 +1,90 @@
+package account
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/quota"
+	"caty/pkg/v"
+)
+
+func TestCreateSession(t *testing.T) {
+	mock := mockDB(t)
+	// 锁定账户记录后统计有效会话数
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account` WHERE id =?")).
+		WithArgs(uint64(1), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_quota` WHERE account_id =? AND resource =?")).
+		WithArgs(uint64(1), quota.ResourceSessions).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `session` WHERE account_id =? AND expired_at >?")).
+		WithArgs(uint64(1), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `session`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	session, err := createSession(context.Background(), &model.User{ID: 10, AccountID: 1}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID == 0 || session.UserID != 10 {
+		t.Fatalf("unexpected session %+v", session)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	mock := mockDB(t)
+	ctx := auth.SetToken(context.Background(), &auth.Token{AccountID: "1", UserID: "10", SessionID: "100"})
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `session` SET `expired_at`=? WHERE id =? AND user_id =? AND expired_at >?")).
+		WithArgs(sqlmock.AnyArg(), "100", "10", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertCode(t, Logout(context.Background()), code.ErrNoAuth)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	mock := mockDB(t)
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(20))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `session` SET `expired_at`=? WHERE user_id =? AND expired_at >?")).
+		WithArgs(sqlmock.AnyArg(), uint64(20), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	if err := RevokeSessions(ctx, &User{ID: "20"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他账户的用户视为不存在
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("30", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	assertCode(t, RevokeSessions(ctx, &User{ID: "30"}), code.ErrNoAccount)
+
+	// 非管理员只能结束自己的会话
+	ctx = tenantContext(map[string]uint8{v.ServiceName: auth.Read}, false)
+	assertCode(t, RevokeSessions(ctx, &User{ID: "20"}), code.ErrForbiddenAuth)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package account
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/v"
+)
+
+// EventStatusChanged 用户状态变更事件
+const EventStatusChanged = "user.status_changed"
+
+// transitions 用户状态机，key为当前状态，value为允许变更到的状态，已擦除为终态
+var transitions = map[string][]string{
+	model.StatusActive:    {model.StatusSuspended, model.StatusDisabled},
+	model.StatusSuspended: {model.StatusActive, model.StatusDisabled},
+	model.StatusDisabled:  {model.StatusActive},
+}
+
+// CanTransition 判断用户状态能否从 from 变更为 to
+func CanTransition(from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+type StatusRequest struct {
+	// 状态变更原因
+	// Required: true
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type StatusChangedPayload struct {
+	// 变更前状态
+	From string `json:"from"`
+	// 变更后状态
+	To string `json:"to"`
+	// 变更原因
+	Reason string `json:"reason"`
+}
+
+// Suspend 暂停用户，暂停后无法登录且已签发的token失效
+func Suspend(ctx context.Context, user *User, request *StatusRequest) error {
+	return Transition(ctx, user, model.StatusSuspended, request.Reason)
+}
+
+// Reactivate 重新激活用户
+func Reactivate(ctx context.Context, user *User, request *StatusRequest) error {
+	return Transition(ctx, user, model.StatusActive, request.Reason)
+}
+
+// Transition 按状态机变更用户状态并记录审计事件
+func Transition(ctx context.Context, request *User, to, reason string) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &model.User{}
+		if err := tx.Model(user).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		if !CanTransition(user.Status, to) {
+			return errors.WithStack(code.ErrTransitionAccount.WithResult(
+				fmt.Sprintf("%s -> %s", user.Status, to)))
+		}
+		if to == model.StatusActive {
+			if err := verifyEmail(tx, user.Email, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.User{}).Where("id =?", user.ID).Updates(map[string]interface{}{
+			"status":        to,
+			"status_reason": reason,
+			"version":       gorm.Expr("`version` + 1"),
+		}).Error; err != nil {
+			return errors.WithStack(code.ErrUpdateAccount.WithResult(err))
+		}
+		if err := history.Record(ctx, tx, event.ResourceUser, user.ID, history.ActionUpdate, history.Diff(
+			map[string]interface{}{"status": user.Status, "status_reason": user.StatusReason},
+			map[string]interface{}{"status": to, "status_reason": reason})); err != nil {
+			return err
+		}
+		return event.Record(ctx, tx, EventStatusChanged, event.ResourceUser, user.ID, &StatusChangedPayload{
+			From:   user.Status,
+			To:     to,
+			Reason: reason,
+		})
+	})
+}
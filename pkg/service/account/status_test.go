@@ -0,0 +1,29 @@
+package account
+
+import (
+	"testing"
+
+	"caty/pkg/model"
+)
+
+func TestCanTransition(t *testing.T) {
+	list := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{model.StatusActive, model.StatusSuspended, true},
+		{model.StatusActive, model.StatusActive, false},
+		{model.StatusSuspended, model.StatusActive, true},
+		{model.StatusSuspended, model.StatusErased, false},
+		{model.StatusDisabled, model.StatusActive, true},
+		{model.StatusDisabled, model.StatusSuspended, false},
+		{model.StatusErased, model.StatusActive, false},
+		{"unknown", model.StatusActive, false},
+	}
+	for _, input := range list {
+		if got := CanTransition(input.from, input.to); got != input.want {
+			t.Errorf("%s -> %s want %v got %v", input.from, input.to, input.want, got)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Package account
+package account
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"caty/pkg/service/auth"
+	"caty/pkg/service/tenant"
+)
+
+// TenantScope 按调用方所属账户限定查询范围，见 tenant.Scope
+func TenantScope(ctx context.Context) (func(*gorm.DB) *gorm.DB, error) {
+	return tenant.Scope(ctx)
+}
+
+// verifyTenant 校验调用方能否访问账户 accountID
+func verifyTenant(ctx context.Context, tx *gorm.DB, accountID string) error {
+	return tenant.Verify(ctx, tx, accountID)
+}
+
+func isAdmin(token *auth.Token) bool {
+	return tenant.IsAdmin(token)
+}
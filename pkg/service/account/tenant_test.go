@@ -0,0 +1,183 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// subtree 管理员可访问所属账户及其下级账户
+const subtree = "account_id IN (SELECT d.id FROM `account` AS d JOIN `account` AS p ON d.path LIKE CONCAT(p.path, '%') " +
+	"WHERE p.id =? AND p.deleted = 0 AND d.deleted = 0)"
+
+func tenantContext(permission map[string]uint8, allTenants bool) context.Context {
+	ctx := auth.SetToken(context.Background(), &auth.Token{
+		AccountID:  "1",
+		UserID:     "10",
+		Permission: permission,
+	})
+	return auth.SetAllTenants(ctx, allTenants)
+}
+
+func mockDB(t *testing.T) sqlmock.Sqlmock {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mock
+}
+
+func assertCode(t *testing.T, err error, want e.ErrorCode) {
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) || errorCode.Code() != want.Code() {
+		t.Fatalf("want %v got %v", want, err)
+	}
+}
+
+func TestRetrieveOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	_, err := Retrieve(ctx, &User{ID: "20"})
+	assertCode(t, err, code.ErrNoAccount)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListScoped(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE account_id = ? AND "+subtree)).
+		WithArgs("2", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	responses, err := List(ctx, &RetrievesRequest{AccountID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses.Result) != 0 {
+		t.Fatalf("want empty result got %d", len(responses.Result))
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	_, err := Update(ctx, &User{ID: "20"}, nil, &UpdateRequest{Account: "other"})
+	assertCode(t, err, code.ErrNoAccount)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	err := Delete(ctx, &User{ID: "20"}, nil)
+	assertCode(t, err, code.ErrNoAccount)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteNotAdmin(t *testing.T) {
+	mockDB(t)
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Read}, false)
+	assertCode(t, Delete(ctx, &User{ID: "10"}, nil), code.ErrForbiddenAuth)
+}
+
+func TestCreateOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM (SELECT d.id")).
+		WithArgs("1", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	_, err := Create(ctx, &CreateRequest{AccountID: "2", Account: "sub", Password: "password"})
+	assertCode(t, err, code.ErrForbiddenAuth)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllTenants(t *testing.T) {
+	mock := mockDB(t)
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, true)
+	_, err := Retrieve(ctx, &User{ID: "20"})
+	assertCode(t, err, code.ErrForbiddenAuth)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND `user`.`deleted` = ?")).
+		WithArgs("20", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id"}).AddRow(20, 2))
+	ctx = tenantContext(map[string]uint8{auth.AllService: auth.Admin}, true)
+	response, err := Retrieve(ctx, &User{ID: "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.AccountID != "2" {
+		t.Fatalf("want account 2 got %s", response.AccountID)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetrieveNotAdmin(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND account_id =?")).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Read}, false)
+	_, err := Retrieve(ctx, &User{ID: "20"})
+	assertCode(t, err, code.ErrNoAccount)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListDescendants(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE account_id = ? AND "+subtree)).
+		WithArgs("1", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id"}))
+	ctx := tenantContext(map[string]uint8{v.ServiceName: auth.Admin}, false)
+	if _, err := List(ctx, &RetrievesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE "+subtree+" AND `user`.`deleted` = ?")).
+		WithArgs("1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id"}).AddRow(20, 2))
+	responses, err := List(ctx, &RetrievesRequest{IncludeDescendants: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses.Result) != 1 || responses.Result[0].AccountID != "2" {
+		t.Fatalf("want user of descendant account got %+v", responses.Result)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
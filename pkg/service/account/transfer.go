@@ -0,0 +1,361 @@
+// Package account
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/event"
+	"caty/pkg/service/history"
+	"caty/pkg/v"
+)
+
+// 主账号转让事件
+const (
+	EventTransferInitiated = "account.transfer_initiated"
+	EventTransferAccepted  = "account.transfer_accepted"
+	EventTransferCancelled = "account.transfer_cancelled"
+)
+
+// DefaultTransferTTL 默认主账号转让的有效期
+const DefaultTransferTTL = 72 * time.Hour
+
+// TransferTTL 主账号转让的有效期，超过后目标用户无法接受
+func TransferTTL() time.Duration {
+	if ttl := viper.GetDuration("account.transfer_ttl"); ttl > 0 {
+		return ttl
+	}
+	return DefaultTransferTTL
+}
+
+type TransferRequest struct {
+	// 目标用户，须为同一账户下已激活的子账号
+	// Required: true
+	ToUserID string `json:"to_user_id" binding:"required,numeric"`
+}
+
+type Transfer struct {
+	// 转让ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type TransferResponse struct {
+	// 转让ID
+	ID string `json:"id"`
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 原主账号用户
+	FromUserID string `json:"from_user_id"`
+	// 目标用户
+	ToUserID string `json:"to_user_id"`
+	// 状态 pending,accepted,cancelled
+	Status string `json:"status"`
+	// 发起人
+	ActorID string `json:"actor_id"`
+	// 过期时间
+	ExpiredAt time.Time `json:"expired_at"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type transferPayload struct {
+	TransferID string `json:"transfer_id"`
+	FromUserID string `json:"from_user_id"`
+	ToUserID   string `json:"to_user_id"`
+}
+
+// InitiateTransfer 发起主账号转让，仅主账号本人或管理员可发起
+func InitiateTransfer(ctx context.Context, request *User, transferRequest *TransferRequest) (*TransferResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID != request.ID {
+		if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+			return nil, err
+		}
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transferModel := &model.Transfer{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		owner := &model.User{}
+		if err := tx.Model(owner).Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(scope).
+			Where("id =?", request.ID).First(owner).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		if !owner.PrimaryAccount {
+			return errors.WithStack(code.ErrForbiddenAuth.WithResult("only primary account can be transferred"))
+		}
+		target := &model.User{}
+		if err := tx.Model(target).Scopes(scope).Where("id =?", transferRequest.ToUserID).
+			First(target).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrTargetTransfer.WithResult(err))
+			}
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		if target.AccountID != owner.AccountID || target.PrimaryAccount || target.Status != model.StatusActive {
+			return errors.WithStack(code.ErrTargetTransfer)
+		}
+		now := tx.NowFunc()
+		var count int64
+		if err := tx.Model(&model.Transfer{}).Where("account_id =? AND status =? AND expired_at >?",
+			owner.AccountID, model.TransferPending, now).Count(&count).Error; err != nil {
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		if count > 0 {
+			return errors.WithStack(code.ErrExistTransfer)
+		}
+		transferModel = &model.Transfer{
+			AccountID:  owner.AccountID,
+			FromUserID: owner.ID,
+			ToUserID:   target.ID,
+			Status:     model.TransferPending,
+			ActorID:    auth.ActorID(ctx),
+			ExpiredAt:  now.Add(TransferTTL()),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := tx.Model(transferModel).Create(transferModel).Error; err != nil {
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		return event.Record(ctx, tx, EventTransferInitiated, event.ResourceAccount, owner.AccountID,
+			newTransferPayload(transferModel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newTransferResponse(transferModel), nil
+}
+
+// RetrieveTransfer 查询主账号转让，仅转让双方或管理员可查询
+func RetrieveTransfer(ctx context.Context, request *Transfer) (*TransferResponse, error) {
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transferModel := &model.Transfer{}
+	if err = db.With(ctx).Model(transferModel).Scopes(scope).Where("id =?", request.ID).
+		First(transferModel).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoTransfer.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	if err := verifyTransferParty(ctx, transferModel); err != nil {
+		return nil, err
+	}
+	return newTransferResponse(transferModel), nil
+}
+
+// AcceptTransfer 目标用户接受主账号转让，在同一事务中交换主账号身份与权限
+func AcceptTransfer(ctx context.Context, request *Transfer) (*TransferResponse, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transferModel := &model.Transfer{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingTransfer(tx.Scopes(scope), request, transferModel); err != nil {
+			return err
+		}
+		if token.UserID != FormatUint(transferModel.ToUserID) {
+			return errors.WithStack(code.ErrForbiddenAuth.WithResult("only target user can accept the transfer"))
+		}
+		now := tx.NowFunc()
+		if transferModel.ExpiredAt.Before(now) {
+			return errors.WithStack(code.ErrExpireTransfer)
+		}
+		var users []*model.User
+		if err := tx.Model(&model.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint64{transferModel.FromUserID, transferModel.ToUserID}).
+			Find(&users).Error; err != nil {
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		var owner, target *model.User
+		for _, user := range users {
+			switch user.ID {
+			case transferModel.FromUserID:
+				owner = user
+			case transferModel.ToUserID:
+				target = user
+			}
+		}
+		if owner == nil || !owner.PrimaryAccount || target == nil || target.AccountID != owner.AccountID ||
+			target.Status != model.StatusActive {
+			return errors.WithStack(code.ErrTargetTransfer)
+		}
+		ownerPermission, targetPermission, err := swapAdmin(owner.Permission, target.Permission)
+		if err != nil {
+			return err
+		}
+		// 先取消原主账号，避免同一账户下同时存在两个主账号
+		if err = swapPrimary(ctx, tx, owner, false, ownerPermission); err != nil {
+			return err
+		}
+		if err = swapPrimary(ctx, tx, target, true, targetPermission); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Transfer{}).Where("id =?", transferModel.ID).
+			Update("status", model.TransferAccepted).Error; err != nil {
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		transferModel.Status = model.TransferAccepted
+		transferModel.UpdatedAt = now
+		return event.Record(ctx, tx, EventTransferAccepted, event.ResourceAccount, transferModel.AccountID,
+			newTransferPayload(transferModel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newTransferResponse(transferModel), nil
+}
+
+// CancelTransfer 取消主账号转让，转让双方或管理员可取消
+func CancelTransfer(ctx context.Context, request *Transfer) (*TransferResponse, error) {
+	scope, err := TenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transferModel := &model.Transfer{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingTransfer(tx.Scopes(scope), request, transferModel); err != nil {
+			return err
+		}
+		if err := verifyTransferParty(ctx, transferModel); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Transfer{}).Where("id =?", transferModel.ID).
+			Update("status", model.TransferCancelled).Error; err != nil {
+			return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+		}
+		transferModel.Status = model.TransferCancelled
+		transferModel.UpdatedAt = tx.NowFunc()
+		return event.Record(ctx, tx, EventTransferCancelled, event.ResourceAccount, transferModel.AccountID,
+			newTransferPayload(transferModel))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newTransferResponse(transferModel), nil
+}
+
+func lockPendingTransfer(tx *gorm.DB, request *Transfer, transferModel *model.Transfer) error {
+	if err := tx.Model(transferModel).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id =?", request.ID).First(transferModel).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return errors.WithStack(code.ErrNoTransfer.WithResult(err))
+		}
+		return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	if transferModel.Status != model.TransferPending {
+		return errors.WithStack(code.ErrStatusTransfer.WithResult(transferModel.Status))
+	}
+	return nil
+}
+
+// verifyTransferParty 校验当前用户为转让双方或管理员
+func verifyTransferParty(ctx context.Context, transferModel *model.Transfer) error {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return errors.WithStack(code.ErrNoAuth)
+	}
+	if token.UserID == FormatUint(transferModel.FromUserID) || token.UserID == FormatUint(transferModel.ToUserID) {
+		return nil
+	}
+	_, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin)
+	return err
+}
+
+// swapAdmin 交换双方在本服务的权限，其他服务及全局权限(*)属于用户本人，不随主账号转让
+func swapAdmin(owner, target string) (string, string, error) {
+	ownerMap, err := auth.ParsePermission(owner)
+	if err != nil {
+		return "", "", errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	targetMap, err := auth.ParsePermission(target)
+	if err != nil {
+		return "", "", errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	if action, ok := targetMap[v.ServiceName]; ok {
+		ownerMap[v.ServiceName] = action
+	} else {
+		delete(ownerMap, v.ServiceName)
+	}
+	targetMap[v.ServiceName] = auth.Admin
+	ownerPermission, err := json.Marshal(ownerMap)
+	if err != nil {
+		return "", "", errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	targetPermission, err := json.Marshal(targetMap)
+	if err != nil {
+		return "", "", errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	return string(ownerPermission), string(targetPermission), nil
+}
+
+// swapPrimary 变更用户的主账号身份与权限并记录变更历史
+func swapPrimary(ctx context.Context, tx *gorm.DB, user *model.User, primary bool, permission string) error {
+	if err := tx.Model(&model.User{}).Where("id =?", user.ID).Updates(map[string]interface{}{
+		"primary_account": primary,
+		"permission":      permission,
+		"version":         gorm.Expr("`version` + 1"),
+	}).Error; err != nil {
+		if strings.Contains(err.Error(), db.ErrDuplicate) {
+			return errors.WithStack(code.ErrExistAccount.WithResult(err))
+		}
+		return errors.WithStack(code.ErrTransferAccount.WithResult(err))
+	}
+	old := userFields(user)
+	user.PrimaryAccount = primary
+	user.Permission = permission
+	return history.Record(ctx, tx, event.ResourceUser, user.ID, history.ActionUpdate,
+		history.Diff(old, userFields(user)))
+}
+
+func newTransferPayload(transferModel *model.Transfer) *transferPayload {
+	return &transferPayload{
+		TransferID: FormatUint(transferModel.ID),
+		FromUserID: FormatUint(transferModel.FromUserID),
+		ToUserID:   FormatUint(transferModel.ToUserID),
+	}
+}
+
+func newTransferResponse(transferModel *model.Transfer) *TransferResponse {
+	return &TransferResponse{
+		ID:         FormatUint(transferModel.ID),
+		AccountID:  FormatUint(transferModel.AccountID),
+		FromUserID: FormatUint(transferModel.FromUserID),
+		ToUserID:   FormatUint(transferModel.ToUserID),
+		Status:     transferModel.Status,
+		ActorID:    FormatUint(transferModel.ActorID),
+		ExpiredAt:  transferModel.ExpiredAt,
+		CreatedAt:  transferModel.CreatedAt,
+		UpdatedAt:  transferModel.UpdatedAt,
+	}
+}
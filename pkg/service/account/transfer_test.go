@@ -0,0 +1,26 @@
+package account
+
+import "testing"
+
+func TestSwapAdmin(t *testing.T) {
+	list := []struct {
+		owner      string
+		target     string
+		ownerWant  string
+		targetWant string
+	}{
+		{`{"caty":4}`, `{"caty":1}`, `{"caty":1}`, `{"caty":4}`},
+		{`{"*":4,"caty":4,"billing":2}`, `{"caty":1}`, `{"*":4,"billing":2,"caty":1}`, `{"caty":4}`},
+		{`{"caty":4}`, `{"billing":1}`, `{}`, `{"billing":1,"caty":4}`},
+	}
+	for _, input := range list {
+		owner, target, err := swapAdmin(input.owner, input.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if owner != input.ownerWant || target != input.targetWant {
+			t.Errorf("%s <-> %s want %s %s got %s %s", input.owner, input.target,
+				input.ownerWant, input.targetWant, owner, target)
+		}
+	}
+}
@@ -6,6 +6,13 @@ package auth
 import (
 	"context"
 	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/pkg/errors"
+
+	"caty/pkg/code"
+	"caty/pkg/metrics"
+	"caty/pkg/model"
 )
 
 type APIToken struct {
@@ -20,17 +27,51 @@ func Create(_ context.Context, token *TokenClaims) (*APIToken, error) {
 		token.Now = time.Now().Unix()
 	}
 	permission, err := token.Create()
+	metrics.TokensIssued.WithLabelValues(metrics.Result(err)).Inc()
 	if err != nil {
 		return nil, err
 	}
 	return &APIToken{Token: permission}, nil
 }
 
-// Parse 解析token
-func Parse(_ context.Context, token *APIToken) (*TokenClaims, error) {
+// Sign 签发任意token，仅全局管理员可用
+func Sign(ctx context.Context, token *TokenClaims) (*APIToken, error) {
+	if !IsGlobalAdmin(GetToken(ctx)) {
+		return nil, errors.WithStack(code.ErrForbiddenAuth.WithResult("only global admin can sign token"))
+	}
+	return Create(ctx, token)
+}
+
+// Parse 解析token，用户非激活状态或登录会话已结束时token失效
+func Parse(ctx context.Context, token *APIToken) (tokenClaims *TokenClaims, err error) {
+	defer func() {
+		metrics.TokensParsed.WithLabelValues(metrics.Result(err)).Inc()
+	}()
 	tokenImpl := &TokenClaims{}
 	if err := tokenImpl.Parse(token.Token); err != nil {
 		return nil, err
 	}
+	user := &model.User{}
+	if err := db.With(ctx).Model(user).Select("status").Where("id =?", tokenImpl.Token.UserID).
+		Take(user).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrInactiveAuth.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrParseAuth.WithResult(err))
+	}
+	if user.Status != model.StatusActive {
+		return nil, errors.WithStack(code.ErrInactiveAuth.WithResult(user.Status))
+	}
+	if tokenImpl.Token.SessionID == "" {
+		return tokenImpl, nil
+	}
+	var count int64
+	if err := db.With(ctx).Model(&model.Session{}).Where("id =? AND user_id =? AND expired_at >?",
+		tokenImpl.Token.SessionID, tokenImpl.Token.UserID, time.Now()).Count(&count).Error; err != nil {
+		return nil, errors.WithStack(code.ErrParseAuth.WithResult(err))
+	}
+	if count == 0 {
+		return nil, errors.WithStack(code.ErrEndedSession)
+	}
 	return tokenImpl, nil
 }
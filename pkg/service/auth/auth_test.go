@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/spf13/viper"
+
+	"caty/pkg/code"
+)
+
+func TestParseEndedSession(t *testing.T) {
+	viper.Set("auth.secret", "test")
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken, err := Create(context.Background(), &TokenClaims{Token: &Token{
+		AccountID:  "1",
+		UserID:     "10",
+		Permission: map[string]uint8{"caty": Admin},
+		SessionID:  "100",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := func(count int) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `status` FROM `user` WHERE id =?")).
+			WithArgs("10", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("active"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `session` WHERE id =? AND user_id =? AND expired_at >?")).
+			WithArgs("100", "10", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+	expect(1)
+	if _, err = Parse(context.Background(), apiToken); err != nil {
+		t.Fatal(err)
+	}
+	// 登出或撤销后会话结束，token失效
+	expect(0)
+	_, err = Parse(context.Background(), apiToken)
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) || errorCode.Code() != code.ErrEndedSession.Code() {
+		t.Fatalf("want %v got %v", code.ErrEndedSession, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package auth
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/crochee/lirity/variable"
+	"github.com/pkg/errors"
+
+	"caty/pkg/code"
+)
+
+type tokenKey struct{}
+
+// SetToken Add Token to context.Context.
+func SetToken(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// GetToken Get the Token from context.Context.
+func GetToken(ctx context.Context) *Token {
+	token, ok := ctx.Value(tokenKey{}).(*Token)
+	if !ok {
+		return nil
+	}
+	return token
+}
+
+// ActorID 获取 context.Context 中 Token 的用户ID，未携带Token时为0
+func ActorID(ctx context.Context) uint64 {
+	token := GetToken(ctx)
+	if token == nil {
+		return 0
+	}
+	actorID, _ := strconv.ParseUint(token.UserID, variable.DecimalSystem, 64)
+	return actorID
+}
+
+// VerifyToken 校验 context.Context 中的 Token 是否具有 serviceName 的 action 权限
+func VerifyToken(ctx context.Context, serviceName string, action uint8) (*Token, error) {
+	token := GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if err := VerifyAuth(token.Permission, serviceName, action); err != nil {
+		return nil, errors.WithStack(code.ErrForbiddenAuth.WithResult(err.Error()))
+	}
+	return token, nil
+}
+
+type allTenantsKey struct{}
+
+// SetAllTenants Add all_tenants flag to context.Context.
+func SetAllTenants(ctx context.Context, allTenants bool) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, allTenants)
+}
+
+// AllTenants Get the all_tenants flag from context.Context.
+func AllTenants(ctx context.Context) bool {
+	allTenants, ok := ctx.Value(allTenantsKey{}).(bool)
+	return ok && allTenants
+}
+
+// IsGlobalAdmin 是否拥有全局 * 的管理员权限
+func IsGlobalAdmin(token *Token) bool {
+	return token != nil && token.Permission[AllService] >= Admin
+}
+
+// VerifyGrant 校验当前用户能否授予 permission，授予全局 * 权限须为全局管理员
+func VerifyGrant(ctx context.Context, permission map[string]uint8) error {
+	if _, ok := permission[AllService]; ok && !IsGlobalAdmin(GetToken(ctx)) {
+		return errors.WithStack(code.ErrForbiddenAuth.WithResult("only global admin can grant * permission"))
+	}
+	return nil
+}
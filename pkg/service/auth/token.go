@@ -1,11 +1,11 @@
 package auth
 
 import (
-	"crypto/sha256"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 
 	"caty/pkg/code"
 )
@@ -32,18 +32,26 @@ type Token struct {
 	// 权限列表
 	// Required: true
 	Permission map[string]uint8 `json:"permission" binding:"required"`
+	// 登录会话id，登录签发的token在会话结束后失效
+	SessionID string `json:"session_id,omitempty" binding:"omitempty,numeric"`
 }
 
 // TokenClaims jwt.Claims的 Token 实现
 type TokenClaims struct {
 	// 生成token的时间戳
 	Now int64 `json:"now"`
+	// 过期时间戳，早于默认有效期时生效
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 	// token信息
 	Token *Token `json:"token" binding:"required,dive"`
 }
 
 func (t *TokenClaims) Valid() error {
-	if t.Now != 0 && time.Now().Add(-ExpiresTime).Unix() > t.Now {
+	now := time.Now()
+	if t.Now != 0 && now.Add(-ExpiresTime).Unix() > t.Now {
+		return code.ErrExpireAuth
+	}
+	if t.ExpiresAt != 0 && now.Unix() >= t.ExpiresAt {
 		return code.ErrExpireAuth
 	}
 	return nil
@@ -79,11 +87,21 @@ func (t *TokenClaims) Parse(data string) error {
 	return nil
 }
 
-func (t *TokenClaims) Secret(_ *jwt.Token) (interface{}, error) {
-	h := sha256.New()
-	_, err := h.Write([]byte(t.Token.UserID))
-	if err != nil {
-		return nil, err
+// Secret 所有token使用服务端密钥签名，只接受HMAC签名算法
+func (t *TokenClaims) Secret(token *jwt.Token) (interface{}, error) {
+	if token != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+	}
+	return SecretKey()
+}
+
+// SecretKey token签名密钥，来自配置 auth.secret
+func SecretKey() ([]byte, error) {
+	secret := viper.GetString("auth.secret")
+	if secret == "" {
+		return nil, errors.New("auth.secret isn't configured")
 	}
-	return h.Sum(nil), nil
+	return []byte(secret), nil
 }
@@ -7,9 +7,12 @@ package auth
 import (
 	"testing"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 func TestCreateToken(t *testing.T) {
+	viper.Set("auth.secret", "test")
 	var tokenImpl = &TokenClaims{
 		Now: time.Now().Unix(),
 		Token: &Token{
@@ -25,4 +28,25 @@ func TestCreateToken(t *testing.T) {
 		t.Fatal(err)
 	}
 	t.Log(value)
+	if err = (&TokenClaims{}).Parse(value); err != nil {
+		t.Fatal(err)
+	}
+	// 其他密钥签名的token无法通过校验
+	viper.Set("auth.secret", "other")
+	defer viper.Set("auth.secret", "test")
+	if err = (&TokenClaims{}).Parse(value); err == nil {
+		t.Fatal("want invalid signature")
+	}
+}
+
+func TestTokenExpiresAt(t *testing.T) {
+	now := time.Now()
+	tokenImpl := &TokenClaims{Now: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	if err := tokenImpl.Valid(); err != nil {
+		t.Fatal(err)
+	}
+	tokenImpl.ExpiresAt = now.Add(-time.Second).Unix()
+	if err := tokenImpl.Valid(); err == nil {
+		t.Fatal("want expired token")
+	}
 }
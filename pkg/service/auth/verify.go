@@ -5,6 +5,7 @@
 package auth
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -51,3 +52,25 @@ func VerifyAuth(actionMap map[string]uint8, serviceName string, action uint8) er
 	}
 	return fmt.Errorf("must obtain %s access to the %s", ActionString[action], serviceName)
 }
+
+// ParsePermission 解析权限文本
+func ParsePermission(permission string) (map[string]uint8, error) {
+	actionMap := make(map[string]uint8)
+	if err := json.Unmarshal([]byte(permission), &actionMap); err != nil {
+		return nil, err
+	}
+	return actionMap, nil
+}
+
+// MergePermission 将 src 合并进 dst，同一服务取较高的权限
+func MergePermission(dst, src map[string]uint8) map[string]uint8 {
+	if dst == nil {
+		dst = make(map[string]uint8, len(src))
+	}
+	for serviceName, action := range src {
+		if tempAction, ok := dst[serviceName]; !ok || action > tempAction {
+			dst[serviceName] = action
+		}
+	}
+	return dst
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePermission(t *testing.T) {
+	list := []struct {
+		name string
+		dst  map[string]uint8
+		src  map[string]uint8
+		want map[string]uint8
+	}{
+		{
+			"nil dst",
+			nil,
+			map[string]uint8{"caty": Read},
+			map[string]uint8{"caty": Read},
+		},
+		{
+			"higher wins",
+			map[string]uint8{"caty": Read, "obs": Admin},
+			map[string]uint8{"caty": Write, "obs": Read},
+			map[string]uint8{"caty": Write, "obs": Admin},
+		},
+		{
+			"union",
+			map[string]uint8{"caty": Read},
+			map[string]uint8{AllService: Not, "obs": Delete},
+			map[string]uint8{"caty": Read, AllService: Not, "obs": Delete},
+		},
+	}
+	for _, input := range list {
+		t.Run(input.name, func(t *testing.T) {
+			got := MergePermission(input.dst, input.src)
+			if !reflect.DeepEqual(input.want, got) {
+				t.Errorf("want %v got %v", input.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package event
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/crochee/lirity"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/history"
+	"caty/pkg/v"
+)
+
+// 资源类型
+const (
+	ResourceUser    = "user"
+	ResourceAccount = "account"
+)
+
+// Record 在事务 tx 中记录审计事件，操作人与追踪ID取自 ctx
+func Record(ctx context.Context, tx *gorm.DB, eventType, resourceType string, resourceID uint64,
+	payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.WithStack(code.ErrRecordEvent.WithResult(err))
+	}
+	eventModel := &model.Event{
+		Type:         eventType,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ActorID:      auth.ActorID(ctx),
+		TraceID:      v.GetTraceID(ctx),
+		Payload:      lirity.String(data),
+	}
+	if err = tx.Model(eventModel).Create(eventModel).Error; err != nil {
+		return errors.WithStack(code.ErrRecordEvent.WithResult(err))
+	}
+	return nil
+}
+
+// Scrub 在事务 tx 中将资源审计事件内容中 fields 的值替换为已擦除，保留事件本身，返回修改的事件数
+func Scrub(tx *gorm.DB, resourceType string, resourceID uint64, fields []string) (int, error) {
+	var eventList []*model.Event
+	if err := tx.Model(&model.Event{}).Where("resource_type =? AND resource_id =?",
+		resourceType, resourceID).Find(&eventList).Error; err != nil {
+		return 0, errors.WithStack(code.ErrRecordEvent.WithResult(err))
+	}
+	var count int
+	for _, eventModel := range eventList {
+		payload := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(eventModel.Payload), &payload); err != nil {
+			return 0, errors.WithStack(code.ErrRecordEvent.WithResult(err))
+		}
+		var modified bool
+		for _, field := range fields {
+			if value, ok := payload[field]; ok && value != "" {
+				payload[field] = history.Erased
+				modified = true
+			}
+		}
+		if !modified {
+			continue
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, errors.WithStack(code.ErrRecordEvent.WithResult(err))
+		}
+		if err = tx.Model(&model.Event{}).Where("id =?", eventModel.ID).
+			Update("payload", lirity.String(data)).Error; err != nil {
+			return 0, errors.WithStack(code.ErrRecordEvent.WithResult(err))
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,44 @@
+package event
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+)
+
+func TestScrub(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `event` WHERE resource_type =? AND resource_id =?")).
+		WithArgs("user", uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+			AddRow(10, `{"from":"active","reason":"on leave","to":"suspended"}`).
+			AddRow(11, `{"erasure_id":"5","user_id":"1"}`).
+			AddRow(12, `{"decision_reason":"","justification":"debug prod","status":"pending"}`))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `event` SET `payload`=? WHERE id =?")).
+		WithArgs(`{"from":"active","reason":"erased","to":"suspended"}`, uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `event` SET `payload`=? WHERE id =?")).
+		WithArgs(`{"decision_reason":"","justification":"erased","status":"pending"}`, uint64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	count, err := Scrub(db.With(context.Background()).DB, ResourceUser, 1,
+		[]string{"reason", "justification", "decision_reason"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("want 2 got %d", count)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
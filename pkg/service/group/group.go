@@ -0,0 +1,274 @@
+// Package group
+package group
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/variable"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/quota"
+	"caty/pkg/service/tenant"
+	"caty/pkg/v"
+)
+
+type CreateRequest struct {
+	// 账户ID
+	// Required: true
+	AccountID string `json:"account_id" binding:"required,numeric"`
+	// 用户组名
+	// Required: true
+	Name string `json:"name" binding:"required"`
+	// 权限
+	// Required: true
+	Permission string `json:"permission" binding:"required,json"`
+	// 描述信息
+	Desc string `json:"desc" binding:"omitempty,json"`
+}
+
+type Response struct {
+	// 用户组ID
+	ID string `json:"id"`
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 用户组名
+	Name string `json:"name"`
+	// 权限
+	Permission string `json:"permission"`
+	// 描述
+	Desc string `json:"desc"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Create 创建用户组
+func Create(ctx context.Context, request *CreateRequest) (*Response, error) {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return nil, err
+	}
+	if err := verifyGrant(ctx, request.Permission); err != nil {
+		return nil, err
+	}
+	groupModel := &model.Group{
+		Name:       request.Name,
+		Permission: request.Permission,
+		Desc:       request.Desc,
+	}
+	if groupModel.Desc == "" {
+		groupModel.Desc = "{}"
+	}
+	err := db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tenant.Verify(ctx, tx, request.AccountID); err != nil {
+			return err
+		}
+		accountModel := &model.Account{}
+		if err := tx.Model(accountModel).Where("id =?", request.AccountID).
+			First(accountModel).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrCreateGroup.WithResult(err))
+		}
+		groupModel.AccountID = accountModel.ID
+		if err := quota.Check(tx, accountModel.ID, quota.ResourceGroups); err != nil {
+			return err
+		}
+		if err := tx.Model(groupModel).Create(groupModel).Error; err != nil {
+			if strings.Contains(err.Error(), db.ErrDuplicate) {
+				return errors.WithStack(code.ErrExistGroup.WithResult(err))
+			}
+			return errors.WithStack(code.ErrCreateGroup.WithResult(err))
+		}
+		if err := tx.Model(groupModel).First(groupModel).Error; err != nil {
+			return errors.WithStack(code.ErrCreateGroup.WithResult(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(groupModel), nil
+}
+
+type Group struct {
+	// 用户组ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type UpdateRequest struct {
+	// 用户组名
+	Name string `json:"name" binding:"omitempty"`
+	// 权限
+	Permission string `json:"permission" binding:"omitempty,json"`
+	// 描述信息
+	Desc string `json:"desc" binding:"omitempty,json"`
+}
+
+// Update 编辑用户组
+func Update(ctx context.Context, group *Group, request *UpdateRequest) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	updates := make(map[string]interface{})
+	if request.Name != "" {
+		updates["name"] = request.Name
+	}
+	if request.Permission != "" {
+		if err := verifyGrant(ctx, request.Permission); err != nil {
+			return err
+		}
+		updates["permission"] = request.Permission
+	}
+	if request.Desc != "" {
+		updates["desc"] = request.Desc
+	}
+	if len(updates) == 0 {
+		return errors.WithStack(code.ErrNoUpdate)
+	}
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	query := db.With(ctx).Model(&model.Group{}).Scopes(scope).Where("id =?", group.ID).Updates(updates)
+	if err := query.Error; err != nil {
+		if strings.Contains(err.Error(), db.ErrDuplicate) {
+			return errors.WithStack(code.ErrExistGroup.WithResult(err))
+		}
+		return errors.WithStack(code.ErrUpdateGroup.WithResult(err))
+	}
+	if query.RowsAffected == 0 {
+		return errors.WithStack(code.ErrNoGroup)
+	}
+	return nil
+}
+
+type RetrievesRequest struct {
+	model.Page
+	// 账户ID
+	// in: query
+	AccountID string `json:"account-id" form:"account-id" binding:"omitempty,numeric"`
+	// 用户组名
+	// in: query
+	Name string `json:"name" form:"name" binding:"omitempty"`
+}
+
+type Responses struct {
+	model.Page
+	// 结果集
+	Result []*Response `json:"result"`
+}
+
+// List 查询调用方可访问账户内的用户组列表
+func List(ctx context.Context, request *RetrievesRequest) (*Responses, error) {
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).Model(&model.Group{}).Scopes(scope)
+	if request.AccountID != "" {
+		query = query.Where("account_id = ?", request.AccountID)
+	}
+	if request.Name != "" {
+		query = query.Where("name = ?", request.Name)
+	}
+	query = model.HandlePage(query, request.Page)
+	var groupList []*model.Group
+	if err := query.Find(&groupList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveGroup.WithResult(err))
+	}
+	responses := &Responses{
+		Page: model.Page{
+			Index: request.Index,
+			Size:  request.Size,
+			Total: len(groupList),
+		},
+		Result: make([]*Response, 0, len(groupList)),
+	}
+	for _, group := range groupList {
+		responses.Result = append(responses.Result, newResponse(group))
+	}
+	return responses, nil
+}
+
+// Retrieve 查询指定用户组
+func Retrieve(ctx context.Context, request *Group) (*Response, error) {
+	group, err := scopedGroup(ctx, db.With(ctx).DB, request.ID, code.ErrRetrieveGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(group), nil
+}
+
+// Delete 删除用户组及其成员关系
+func Delete(ctx context.Context, request *Group) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		queryDel := tx.Scopes(scope).Where("id =?", request.ID).Delete(&model.Group{})
+		if err := queryDel.Error; err != nil {
+			return errors.WithStack(code.ErrDeleteGroup.WithResult(err))
+		}
+		if queryDel.RowsAffected == 0 {
+			return errors.WithStack(code.ErrNoGroup)
+		}
+		if err := tx.Where("group_id =?", request.ID).Delete(&model.GroupUser{}).Error; err != nil {
+			return errors.WithStack(code.ErrDeleteGroup.WithResult(err))
+		}
+		return nil
+	})
+}
+
+// scopedGroup 查询调用方可访问账户内的用户组，其他账户的用户组视为不存在
+func scopedGroup(ctx context.Context, tx *gorm.DB, id string, errorCode e.ErrorCode) (*model.Group, error) {
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	group := &model.Group{}
+	if err = tx.Model(group).Scopes(scope).Where("id =?", id).First(group).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoGroup.WithResult(err))
+		}
+		return nil, errors.WithStack(errorCode.WithResult(err))
+	}
+	return group, nil
+}
+
+func newResponse(group *model.Group) *Response {
+	return &Response{
+		ID:         strconv.FormatUint(group.ID, variable.DecimalSystem),
+		AccountID:  strconv.FormatUint(group.AccountID, variable.DecimalSystem),
+		Name:       group.Name,
+		Permission: group.Permission,
+		Desc:       group.Desc,
+		CreatedAt:  group.CreatedAt,
+		UpdatedAt:  group.UpdatedAt,
+	}
+}
+
+// verifyGrant 校验当前用户能否为用户组授予 permission
+func verifyGrant(ctx context.Context, permission string) error {
+	actionMap, err := auth.ParsePermission(permission)
+	if err != nil {
+		return errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	return auth.VerifyGrant(ctx, actionMap)
+}
@@ -0,0 +1,116 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// subtree 管理员可访问所属账户及其下级账户
+const subtree = "account_id IN (SELECT d.id FROM `account` AS d JOIN `account` AS p ON d.path LIKE CONCAT(p.path, '%') " +
+	"WHERE p.id =? AND p.deleted = 0 AND d.deleted = 0)"
+
+func adminContext() context.Context {
+	return auth.SetToken(context.Background(), &auth.Token{
+		AccountID:  "1",
+		UserID:     "10",
+		Permission: map[string]uint8{v.ServiceName: auth.Admin},
+	})
+}
+
+func mockDB(t *testing.T) sqlmock.Sqlmock {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return mock
+}
+
+func assertCode(t *testing.T, err error, want e.ErrorCode) {
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) || errorCode.Code() != want.Code() {
+		t.Fatalf("want %v got %v", want, err)
+	}
+}
+
+func TestCreateOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM (SELECT d.id")).
+		WithArgs("1", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+	_, err := Create(adminContext(), &CreateRequest{AccountID: "2", Name: "ops", Permission: `{"caty":2}`})
+	assertCode(t, err, code.ErrForbiddenAuth)
+}
+
+func TestRetrieveOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err := Retrieve(adminContext(), &Group{ID: "20"})
+	assertCode(t, err, code.ErrNoGroup)
+
+	_, err = Retrieve(context.Background(), &Group{ID: "20"})
+	assertCode(t, err, code.ErrNoAuth)
+}
+
+func TestListScoped(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE account_id = ? AND "+subtree)).
+		WithArgs("2", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	responses, err := List(adminContext(), &RetrievesRequest{AccountID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses.Result) != 0 {
+		t.Fatalf("want empty result got %d", len(responses.Result))
+	}
+}
+
+func TestUpdateOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id =? AND " + subtree)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	err := Update(adminContext(), &Group{ID: "20"}, &UpdateRequest{Name: "ops"})
+	assertCode(t, err, code.ErrNoGroup)
+}
+
+func TestDeleteOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id =? AND " + subtree)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err := Delete(adminContext(), &Group{ID: "20"})
+	assertCode(t, err, code.ErrNoGroup)
+}
+
+func TestAddUserOtherTenant(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	err := AddUser(adminContext(), &Member{ID: "20", UserID: "30"})
+	assertCode(t, err, code.ErrNoGroup)
+}
@@ -0,0 +1,136 @@
+// Package group
+package group
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/variable"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+type Member struct {
+	// 用户组ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+	// 用户ID
+	// Required: true
+	// in: path
+	UserID string `json:"user_id" uri:"user_id" binding:"required,numeric"`
+}
+
+// AddUser 添加用户组成员，成员必须与用户组属于同一账户
+func AddUser(ctx context.Context, request *Member) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	return db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		group, err := scopedGroup(ctx, tx, request.ID, code.ErrMemberGroup)
+		if err != nil {
+			return err
+		}
+		user := &model.User{}
+		if err := tx.Model(user).Where("id =?", request.UserID).First(user).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrMemberGroup.WithResult(err))
+		}
+		if user.AccountID != group.AccountID {
+			return errors.WithStack(code.ErrDiffAccountGroup)
+		}
+		if err := tx.Create(&model.GroupUser{
+			GroupID: group.ID,
+			UserID:  user.ID,
+		}).Error; err != nil {
+			if strings.Contains(err.Error(), db.ErrDuplicate) {
+				return nil
+			}
+			return errors.WithStack(code.ErrMemberGroup.WithResult(err))
+		}
+		return nil
+	})
+}
+
+// RemoveUser 移除用户组成员
+func RemoveUser(ctx context.Context, request *Member) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	if _, err := scopedGroup(ctx, db.With(ctx).DB, request.ID, code.ErrMemberGroup); err != nil {
+		return err
+	}
+	queryDel := db.With(ctx).Where("group_id =? AND user_id =?", request.ID, request.UserID).
+		Delete(&model.GroupUser{})
+	if err := queryDel.Error; err != nil {
+		return errors.WithStack(code.ErrMemberGroup.WithResult(err))
+	}
+	if queryDel.RowsAffected == 0 {
+		return errors.WithStack(code.ErrNoMemberGroup)
+	}
+	return nil
+}
+
+type MemberResponses struct {
+	// 结果集
+	Result []*MemberResponse `json:"result"`
+}
+
+type MemberResponse struct {
+	// 用户ID
+	UserID string `json:"user_id"`
+	// 用户名
+	Account string `json:"account"`
+	// 加入时间
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ListUsers 查询用户组成员
+func ListUsers(ctx context.Context, request *Group) (*MemberResponses, error) {
+	query := db.With(ctx)
+	if _, err := scopedGroup(ctx, query.DB, request.ID, code.ErrRetrieveGroup); err != nil {
+		return nil, err
+	}
+	var memberList []*struct {
+		model.User
+		JoinedAt time.Time
+	}
+	if err := query.Model(&model.User{}).
+		Select("`user`.*, `group_user`.`created_at` AS joined_at").
+		Joins("JOIN `group_user` ON `group_user`.`user_id` = `user`.`id`").
+		Where("`group_user`.`group_id` =?", request.ID).
+		Find(&memberList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveGroup.WithResult(err))
+	}
+	responses := &MemberResponses{Result: make([]*MemberResponse, 0, len(memberList))}
+	for _, member := range memberList {
+		responses.Result = append(responses.Result, &MemberResponse{
+			UserID:   strconv.FormatUint(member.ID, variable.DecimalSystem),
+			Account:  member.Name,
+			JoinedAt: member.JoinedAt,
+		})
+	}
+	return responses, nil
+}
+
+// UserGroups 查询用户所属的用户组
+func UserGroups(tx *gorm.DB, userID uint64) ([]*model.Group, error) {
+	var groupList []*model.Group
+	if err := tx.Model(&model.Group{}).
+		Joins("JOIN `group_user` ON `group_user`.`group_id` = `user_group`.`id`").
+		Where("`group_user`.`user_id` =?", userID).
+		Find(&groupList).Error; err != nil {
+		return nil, err
+	}
+	return groupList, nil
+}
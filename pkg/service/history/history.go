@@ -0,0 +1,250 @@
+// Package history
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/variable"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// 操作类型
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionRevert  = "revert"
+	ActionErase   = "erase"
+)
+
+// Changed 敏感字段变更时只记录为已变更
+const Changed = "changed"
+
+// Erased 数据擦除后字段值的占位
+const Erased = "erased"
+
+// sensitive 敏感字段，不记录具体值，也不参与回滚
+var sensitive = map[string]struct{}{
+	"password": {},
+}
+
+// IsSensitive 判断字段是否为敏感字段
+func IsSensitive(field string) bool {
+	_, ok := sensitive[field]
+	return ok
+}
+
+type Change struct {
+	// 字段名
+	Field string `json:"field"`
+	// 变更前的值
+	Old interface{} `json:"old"`
+	// 变更后的值
+	New interface{} `json:"new"`
+}
+
+// Diff 比较变更前后的字段值，敏感字段只记录为已变更
+func Diff(old, new map[string]interface{}) []*Change {
+	fields := make([]string, 0, len(new))
+	for field := range old {
+		fields = append(fields, field)
+	}
+	for field := range new {
+		if _, ok := old[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	changes := make([]*Change, 0, len(fields))
+	for _, field := range fields {
+		oldValue, newValue := old[field], new[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if IsSensitive(field) {
+			changes = append(changes, &Change{Field: field, New: Changed})
+			continue
+		}
+		changes = append(changes, &Change{Field: field, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+// Record 在事务 tx 中记录资源的一次变更，版本号按资源递增
+func Record(ctx context.Context, tx *gorm.DB, resourceType string, resourceID uint64, action string,
+	changes []*Change) error {
+	if changes == nil {
+		changes = []*Change{}
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return errors.WithStack(code.ErrRecordHistory.WithResult(err))
+	}
+	var version uint64
+	if err = tx.Model(&model.History{}).Select("COALESCE(MAX(`version`), 0)").
+		Where("resource_type =? AND resource_id =?", resourceType, resourceID).
+		Scan(&version).Error; err != nil {
+		return errors.WithStack(code.ErrRecordHistory.WithResult(err))
+	}
+	historyModel := &model.History{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Version:      version + 1,
+		Action:       action,
+		Changes:      lirity.String(data),
+		ActorID:      auth.ActorID(ctx),
+		TraceID:      v.GetTraceID(ctx),
+	}
+	if err = tx.Model(historyModel).Create(historyModel).Error; err != nil {
+		return errors.WithStack(code.ErrRecordHistory.WithResult(err))
+	}
+	return nil
+}
+
+type Response struct {
+	// 历史版本号
+	Version uint64 `json:"version"`
+	// 操作类型 create,update,delete,restore,revert,erase
+	Action string `json:"action"`
+	// 字段变更
+	Changes []*Change `json:"changes"`
+	// 操作人ID
+	ActorID string `json:"actor_id"`
+	// 请求追踪ID
+	TraceID string `json:"trace_id"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Responses struct {
+	model.Page
+	// 结果集
+	Result []*Response `json:"result"`
+}
+
+// List 按版本倒序查询资源的变更历史
+func List(tx *gorm.DB, resourceType string, resourceID uint64, page model.Page) (*Responses, error) {
+	query := tx.Model(&model.History{}).Where("resource_type =? AND resource_id =?", resourceType, resourceID).
+		Order("version DESC")
+	query = model.HandlePage(query, page)
+	var historyList []*model.History
+	if err := query.Find(&historyList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveHistory.WithResult(err))
+	}
+	responses := &Responses{
+		Page: model.Page{
+			Index: page.Index,
+			Size:  page.Size,
+			Total: len(historyList),
+		},
+		Result: make([]*Response, 0, len(historyList)),
+	}
+	for _, history := range historyList {
+		response := &Response{
+			Version:   history.Version,
+			Action:    history.Action,
+			ActorID:   strconv.FormatUint(history.ActorID, variable.DecimalSystem),
+			TraceID:   history.TraceID,
+			CreatedAt: history.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(history.Changes), &response.Changes); err != nil {
+			return nil, errors.WithStack(code.ErrRetrieveHistory.WithResult(err))
+		}
+		responses.Result = append(responses.Result, response)
+	}
+	return responses, nil
+}
+
+// Snapshot 按变更历史计算资源在版本 version 时的字段值，不包含敏感字段
+func Snapshot(tx *gorm.DB, resourceType string, resourceID, version uint64) (map[string]interface{}, error) {
+	var historyList []*model.History
+	if err := tx.Model(&model.History{}).Where("resource_type =? AND resource_id =? AND version <=?",
+		resourceType, resourceID, version).Order("version").Find(&historyList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveHistory.WithResult(err))
+	}
+	if len(historyList) == 0 || historyList[len(historyList)-1].Version != version {
+		return nil, errors.WithStack(code.ErrNoHistory)
+	}
+	snapshot := make(map[string]interface{})
+	for _, history := range historyList {
+		var changes []*Change
+		if err := json.Unmarshal([]byte(history.Changes), &changes); err != nil {
+			return nil, errors.WithStack(code.ErrRetrieveHistory.WithResult(err))
+		}
+		for _, change := range changes {
+			if IsSensitive(change.Field) {
+				continue
+			}
+			snapshot[change.Field] = change.New
+		}
+	}
+	return snapshot, nil
+}
+
+// Purge 在事务 tx 中删除资源的全部变更历史，resourceIDs 可为id列表或子查询
+func Purge(tx *gorm.DB, resourceType string, resourceIDs interface{}) error {
+	if err := tx.Where("resource_type =? AND resource_id IN (?)", resourceType, resourceIDs).
+		Delete(&model.History{}).Error; err != nil {
+		return errors.WithStack(code.ErrRecordHistory.WithResult(err))
+	}
+	return nil
+}
+
+// Scrub 在事务 tx 中将资源变更历史中 fields 的值替换为已擦除，保留变更记录本身，返回修改的记录数
+func Scrub(tx *gorm.DB, resourceType string, resourceID uint64, fields []string) (int, error) {
+	scrubbed := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		scrubbed[field] = struct{}{}
+	}
+	var historyList []*model.History
+	if err := tx.Model(&model.History{}).Where("resource_type =? AND resource_id =?",
+		resourceType, resourceID).Find(&historyList).Error; err != nil {
+		return 0, errors.WithStack(code.ErrRecordHistory.WithResult(err))
+	}
+	var count int
+	for _, history := range historyList {
+		var changes []*Change
+		if err := json.Unmarshal([]byte(history.Changes), &changes); err != nil {
+			return 0, errors.WithStack(code.ErrRecordHistory.WithResult(err))
+		}
+		var modified bool
+		for _, change := range changes {
+			if _, ok := scrubbed[change.Field]; !ok {
+				continue
+			}
+			if change.Old != nil {
+				change.Old = Erased
+			}
+			if change.New != nil {
+				change.New = Erased
+			}
+			modified = true
+		}
+		if !modified {
+			continue
+		}
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return 0, errors.WithStack(code.ErrRecordHistory.WithResult(err))
+		}
+		if err = tx.Model(&model.History{}).Where("id =?", history.ID).
+			Update("changes", lirity.String(data)).Error; err != nil {
+			return 0, errors.WithStack(code.ErrRecordHistory.WithResult(err))
+		}
+		count++
+	}
+	return count, nil
+}
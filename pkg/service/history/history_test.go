@@ -0,0 +1,65 @@
+package history
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+)
+
+func TestDiff(t *testing.T) {
+	old := map[string]interface{}{
+		"name":     "a",
+		"email":    "a@caty.com",
+		"password": "secret1",
+		"desc":     "{}",
+	}
+	changed := map[string]interface{}{
+		"name":     "a",
+		"email":    "b@caty.com",
+		"password": "secret2",
+		"status":   "active",
+	}
+	want := []*Change{
+		{Field: "desc", Old: "{}", New: nil},
+		{Field: "email", Old: "a@caty.com", New: "b@caty.com"},
+		{Field: "password", New: Changed},
+		{Field: "status", Old: nil, New: "active"},
+	}
+	if got := Diff(old, changed); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if got := Diff(old, old); len(got) != 0 {
+		t.Errorf("want no change got %v", got)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history` WHERE resource_type =? AND resource_id =?")).
+		WithArgs("user", uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "changes"}).
+			AddRow(10, `[{"field":"email","old":"a@caty.com","new":"b@caty.com"}]`).
+			AddRow(11, `[{"field":"status","old":"active","new":"suspended"}]`))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `history` SET `changes`=? WHERE id =?")).
+		WithArgs(`[{"field":"email","old":"erased","new":"erased"}]`, uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	count, err := Scrub(db.With(context.Background()).DB, "user", 1, []string{"name", "email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("want 1 got %d", count)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
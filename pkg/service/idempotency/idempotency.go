@@ -0,0 +1,210 @@
+// Package idempotency
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/logger"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+)
+
+const (
+	// DefaultWindow 默认幂等记录保留期限
+	DefaultWindow = 24 * time.Hour
+	// DefaultLockTimeout 默认处理中记录的最长占用时间，超过后允许重新处理
+	DefaultLockTimeout = time.Minute
+	// DefaultPurgeSpec 默认清理任务执行时间
+	DefaultPurgeSpec = "0 30 3 * * *"
+	// MaxKeyLength 幂等键最大长度
+	MaxKeyLength = 255
+)
+
+// Window 幂等记录保留期限，期限内相同幂等键的请求返回首次的响应
+func Window() time.Duration {
+	if window := viper.GetDuration("idempotency.window"); window > 0 {
+		return window
+	}
+	return DefaultWindow
+}
+
+// LockTimeout 处理中记录的最长占用时间，避免服务异常退出后幂等键无法使用
+func LockTimeout() time.Duration {
+	if timeout := viper.GetDuration("idempotency.lock_timeout"); timeout > 0 {
+		return timeout
+	}
+	return DefaultLockTimeout
+}
+
+// PurgeSpec 清理任务的cron表达式
+func PurgeSpec() string {
+	if spec := viper.GetString("idempotency.purge_spec"); spec != "" {
+		return spec
+	}
+	return DefaultPurgeSpec
+}
+
+// Fingerprint 计算请求指纹
+func Fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{' '})
+	hash.Write([]byte(path))
+	hash.Write([]byte{'\n'})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Caller 幂等键按调用方隔离，携带token时为用户，否则为客户端IP
+func Caller(ctx context.Context, clientIP string) string {
+	if token := auth.GetToken(ctx); token != nil {
+		return userCaller(token.AccountID, token.UserID)
+	}
+	return "ip:" + clientIP
+}
+
+func userCaller(accountID, userID string) string {
+	return "user:" + accountID + "/" + userID
+}
+
+// Begin 登记调用方 caller 的幂等键，返回的replay为true时表示请求已处理完成，应直接返回记录中的响应
+func Begin(ctx context.Context, caller, key, method, path, fingerprint string) (record *model.Idempotency,
+	replay bool, err error) {
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		now := tx.NowFunc()
+		record = &model.Idempotency{}
+		if err := tx.Model(record).Where("caller =? AND idempotency_key =? AND method =? AND path =?", caller, key, method, path).
+			Take(record).Error; err != nil {
+			if !errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrIdempotency.WithResult(err))
+			}
+			record = &model.Idempotency{
+				Caller:      caller,
+				Key:         key,
+				Method:      method,
+				Path:        path,
+				Fingerprint: fingerprint,
+				Headers:     "{}",
+				ExpiredAt:   now.Add(Window()),
+				CreatedAt:   now,
+			}
+			if err = tx.Model(record).Create(record).Error; err != nil {
+				if strings.Contains(err.Error(), db.ErrDuplicate) {
+					// 并发的相同请求已登记
+					return errors.WithStack(code.ErrIdempotencyProcessing.WithResult(err))
+				}
+				return errors.WithStack(code.ErrIdempotency.WithResult(err))
+			}
+			return nil
+		}
+		if record.ExpiredAt.Before(now) ||
+			(record.Status == 0 && now.Sub(record.CreatedAt) > LockTimeout()) {
+			// 记录已过期或处理中断，重新登记
+			query := tx.Model(&model.Idempotency{}).Where("id =? AND status =? AND created_at =?",
+				record.ID, record.Status, record.CreatedAt).Updates(map[string]interface{}{
+				"fingerprint":  fingerprint,
+				"status":       0,
+				"content_type": "",
+				"headers":      "{}",
+				"body":         nil,
+				"expired_at":   now.Add(Window()),
+				"created_at":   now,
+			})
+			if err := query.Error; err != nil {
+				return errors.WithStack(code.ErrIdempotency.WithResult(err))
+			}
+			if query.RowsAffected == 0 {
+				return errors.WithStack(code.ErrIdempotencyProcessing)
+			}
+			record.Fingerprint = fingerprint
+			record.Status = 0
+			record.CreatedAt = now
+			return nil
+		}
+		if record.Fingerprint != fingerprint {
+			return errors.WithStack(code.ErrIdempotencyMismatch)
+		}
+		if record.Status == 0 {
+			return errors.WithStack(code.ErrIdempotencyProcessing)
+		}
+		replay = true
+		return nil
+	})
+	return
+}
+
+// Complete 保存请求的响应，用于后续重放，header为处理器设置的响应头
+func Complete(ctx context.Context, record *model.Idempotency, status int, header http.Header, body []byte) error {
+	headers, err := json.Marshal(header)
+	if err != nil {
+		return errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	if err = db.With(ctx).Model(&model.Idempotency{}).Where("id =?", record.ID).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"content_type": header.Get("Content-Type"),
+			"headers":      string(headers),
+			"body":         body,
+		}).Error; err != nil {
+		return errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	return nil
+}
+
+// Header 解析记录中保存的响应头
+func Header(record *model.Idempotency) (http.Header, error) {
+	header := http.Header{}
+	if record.Headers == "" {
+		return header, nil
+	}
+	if err := json.Unmarshal([]byte(record.Headers), &header); err != nil {
+		return nil, errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	return header, nil
+}
+
+// Scrub 在事务 tx 中清除用户本人发起的以及响应中包含该用户的幂等记录保存的响应内容，
+// 记录本身保留，期限内相同的请求仍不会重复处理，返回修改的记录数
+func Scrub(tx *gorm.DB, accountID, userID string) (int64, error) {
+	query := tx.Model(&model.Idempotency{}).
+		Where("(caller =? OR body LIKE ?) AND body IS NOT NULL",
+			userCaller(accountID, userID), `%"user_id":"`+userID+`"%`).
+		Updates(map[string]interface{}{
+			"headers": "{}",
+			"body":    nil,
+		})
+	if err := query.Error; err != nil {
+		return 0, errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	return query.RowsAffected, nil
+}
+
+// Release 释放幂等键，请求失败时允许使用相同的幂等键重试
+func Release(ctx context.Context, record *model.Idempotency) error {
+	if err := db.With(ctx).Where("id =?", record.ID).Delete(&model.Idempotency{}).Error; err != nil {
+		return errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	return nil
+}
+
+// PurgeExpired 删除已过期的幂等记录
+func PurgeExpired(ctx context.Context) error {
+	query := db.With(ctx).Where("expired_at <?", time.Now()).Delete(&model.Idempotency{})
+	if err := query.Error; err != nil {
+		return errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	logger.From(ctx).Sugar().Infof("purge %d expired idempotency record(s)", query.RowsAffected)
+	return nil
+}
@@ -0,0 +1,162 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+)
+
+const selectRecord = "SELECT * FROM `idempotency` WHERE caller =? AND idempotency_key =? AND method =? AND path =?"
+
+var columns = []string{"id", "caller", "idempotency_key", "method", "path", "fingerprint", "status",
+	"content_type", "headers", "body", "expired_at", "created_at"}
+
+func mockDB(t *testing.T) sqlmock.Sqlmock {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return mock
+}
+
+func assertCode(t *testing.T, err error, want e.ErrorCode) {
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) || errorCode.Code() != want.Code() {
+		t.Fatalf("want %v got %v", want, err)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	if caller := Caller(context.Background(), "10.0.0.1"); caller != "ip:10.0.0.1" {
+		t.Fatalf("unexpected caller %s", caller)
+	}
+	ctx := auth.SetToken(context.Background(), &auth.Token{AccountID: "1", UserID: "10"})
+	if caller := Caller(ctx, "10.0.0.1"); caller != "user:1/10" {
+		t.Fatalf("unexpected caller %s", caller)
+	}
+}
+
+func TestBeginNew(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectRecord)).
+		WithArgs("user:1/10", "key", "POST", "/v1/accounts").
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `idempotency`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	record, replay, err := Begin(context.Background(), "user:1/10", "key", "POST", "/v1/accounts", "fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay || record.Caller != "user:1/10" || record.Status != 0 {
+		t.Fatalf("unexpected record %+v replay %v", record, replay)
+	}
+}
+
+func TestBeginReplay(t *testing.T) {
+	mock := mockDB(t)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectRecord)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user:1/10", "key", "POST", "/v1/accounts", "fp", 200,
+			"application/json", `{"Etag":["\"1\""]}`, []byte(`{"id":"1"}`), now.Add(time.Hour), now))
+	mock.ExpectCommit()
+	record, replay, err := Begin(context.Background(), "user:1/10", "key", "POST", "/v1/accounts", "fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replay || string(record.Body) != `{"id":"1"}` {
+		t.Fatalf("unexpected record %+v replay %v", record, replay)
+	}
+	header, err := Header(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("ETag") != `"1"` {
+		t.Fatalf("unexpected header %v", header)
+	}
+}
+
+func TestBeginMismatch(t *testing.T) {
+	mock := mockDB(t)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectRecord)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user:1/10", "key", "POST", "/v1/accounts", "fp", 200,
+			"application/json", "{}", nil, now.Add(time.Hour), now))
+	mock.ExpectRollback()
+	_, _, err := Begin(context.Background(), "user:1/10", "key", "POST", "/v1/accounts", "other")
+	assertCode(t, err, code.ErrIdempotencyMismatch)
+}
+
+func TestBeginProcessing(t *testing.T) {
+	mock := mockDB(t)
+	now := time.Now()
+	// 相同幂等键的请求正在处理
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectRecord)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user:1/10", "key", "POST", "/v1/accounts", "fp", 0,
+			"", "{}", nil, now.Add(time.Hour), now))
+	mock.ExpectRollback()
+	_, _, err := Begin(context.Background(), "user:1/10", "key", "POST", "/v1/accounts", "fp")
+	assertCode(t, err, code.ErrIdempotencyProcessing)
+
+	// 并发的相同请求先完成登记
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectRecord)).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `idempotency`")).
+		WillReturnError(errors.New("Error 1062: Duplicate entry"))
+	mock.ExpectRollback()
+	_, _, err = Begin(context.Background(), "user:1/10", "key", "POST", "/v1/accounts", "fp")
+	assertCode(t, err, code.ErrIdempotencyProcessing)
+}
+
+func TestBeginExpired(t *testing.T) {
+	mock := mockDB(t)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectRecord)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "user:1/10", "key", "POST", "/v1/accounts", "fp", 200,
+			"application/json", "{}", []byte(`{}`), now.Add(-time.Minute), now.Add(-Window())))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `idempotency` SET")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	record, replay, err := Begin(context.Background(), "user:1/10", "key", "POST", "/v1/accounts", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay || record.Status != 0 || record.Fingerprint != "other" {
+		t.Fatalf("unexpected record %+v replay %v", record, replay)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `idempotency` SET `body`=?,`headers`=? WHERE (caller =? OR body LIKE ?) AND body IS NOT NULL")).
+		WithArgs(nil, "{}", "user:1/10", `%"user_id":"10"%`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	count, err := Scrub(db.With(context.Background()).DB, "1", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("want 2 got %d", count)
+	}
+}
@@ -0,0 +1,309 @@
+// Package label
+package label
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/variable"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/tenant"
+	"caty/pkg/v"
+)
+
+type CreateRequest struct {
+	// 账户ID
+	// Required: true
+	AccountID string `json:"account_id" binding:"required,numeric"`
+	// 授权名
+	// Required: true
+	Name string `json:"name" binding:"required"`
+	// 标签选择器，如 env in (prod,stage),!contractor
+	// Required: true
+	Selector string `json:"selector" binding:"required,max=1024"`
+	// 权限，授予账户内标签匹配选择器的用户
+	// Required: true
+	Permission string `json:"permission" binding:"required,json"`
+	// 描述信息
+	Desc string `json:"desc" binding:"omitempty,json"`
+}
+
+type Response struct {
+	// 标签授权ID
+	ID string `json:"id"`
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 授权名
+	Name string `json:"name"`
+	// 标签选择器
+	Selector string `json:"selector"`
+	// 权限
+	Permission string `json:"permission"`
+	// 描述
+	Desc string `json:"desc"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Create 创建标签授权
+func Create(ctx context.Context, request *CreateRequest) (*Response, error) {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return nil, err
+	}
+	selector, err := parseSelector(request.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyGrant(ctx, request.Permission); err != nil {
+		return nil, err
+	}
+	bindingModel := &model.LabelBinding{
+		Name:       request.Name,
+		Selector:   selector,
+		Permission: request.Permission,
+		Desc:       request.Desc,
+	}
+	if bindingModel.Desc == "" {
+		bindingModel.Desc = "{}"
+	}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tenant.Verify(ctx, tx, request.AccountID); err != nil {
+			return err
+		}
+		accountModel := &model.Account{}
+		if err := tx.Model(accountModel).Where("id =?", request.AccountID).
+			First(accountModel).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrCreateBinding.WithResult(err))
+		}
+		bindingModel.AccountID = accountModel.ID
+		if err := tx.Model(bindingModel).Create(bindingModel).Error; err != nil {
+			if strings.Contains(err.Error(), db.ErrDuplicate) {
+				return errors.WithStack(code.ErrExistBinding.WithResult(err))
+			}
+			return errors.WithStack(code.ErrCreateBinding.WithResult(err))
+		}
+		if err := tx.Model(bindingModel).First(bindingModel).Error; err != nil {
+			return errors.WithStack(code.ErrCreateBinding.WithResult(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(bindingModel), nil
+}
+
+type Binding struct {
+	// 标签授权ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type UpdateRequest struct {
+	// 授权名
+	Name string `json:"name" binding:"omitempty"`
+	// 标签选择器
+	Selector string `json:"selector" binding:"omitempty,max=1024"`
+	// 权限
+	Permission string `json:"permission" binding:"omitempty,json"`
+	// 描述信息
+	Desc string `json:"desc" binding:"omitempty,json"`
+}
+
+// Update 编辑标签授权
+func Update(ctx context.Context, binding *Binding, request *UpdateRequest) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	updates := make(map[string]interface{})
+	if request.Name != "" {
+		updates["name"] = request.Name
+	}
+	if request.Selector != "" {
+		selector, err := parseSelector(request.Selector)
+		if err != nil {
+			return err
+		}
+		updates["selector"] = selector
+	}
+	if request.Permission != "" {
+		if err := verifyGrant(ctx, request.Permission); err != nil {
+			return err
+		}
+		updates["permission"] = request.Permission
+	}
+	if request.Desc != "" {
+		updates["desc"] = request.Desc
+	}
+	if len(updates) == 0 {
+		return errors.WithStack(code.ErrNoUpdate)
+	}
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	query := db.With(ctx).Model(&model.LabelBinding{}).Scopes(scope).Where("id =?", binding.ID).Updates(updates)
+	if err := query.Error; err != nil {
+		if strings.Contains(err.Error(), db.ErrDuplicate) {
+			return errors.WithStack(code.ErrExistBinding.WithResult(err))
+		}
+		return errors.WithStack(code.ErrUpdateBinding.WithResult(err))
+	}
+	if query.RowsAffected == 0 {
+		return errors.WithStack(code.ErrNoBinding)
+	}
+	return nil
+}
+
+type RetrievesRequest struct {
+	model.Page
+	// 账户ID
+	// in: query
+	AccountID string `json:"account-id" form:"account-id" binding:"omitempty,numeric"`
+	// 授权名
+	// in: query
+	Name string `json:"name" form:"name" binding:"omitempty"`
+}
+
+type Responses struct {
+	model.Page
+	// 结果集
+	Result []*Response `json:"result"`
+}
+
+// List 查询调用方可访问账户内的标签授权列表
+func List(ctx context.Context, request *RetrievesRequest) (*Responses, error) {
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.With(ctx).Model(&model.LabelBinding{}).Scopes(scope)
+	if request.AccountID != "" {
+		query = query.Where("account_id = ?", request.AccountID)
+	}
+	if request.Name != "" {
+		query = query.Where("name = ?", request.Name)
+	}
+	query = model.HandlePage(query, request.Page)
+	var bindingList []*model.LabelBinding
+	if err := query.Find(&bindingList).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveBinding.WithResult(err))
+	}
+	responses := &Responses{
+		Page: model.Page{
+			Index: request.Index,
+			Size:  request.Size,
+			Total: len(bindingList),
+		},
+		Result: make([]*Response, 0, len(bindingList)),
+	}
+	for _, binding := range bindingList {
+		responses.Result = append(responses.Result, newResponse(binding))
+	}
+	return responses, nil
+}
+
+// Retrieve 查询指定标签授权
+func Retrieve(ctx context.Context, request *Binding) (*Response, error) {
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	binding := &model.LabelBinding{}
+	if err = db.With(ctx).Model(binding).Scopes(scope).Where("id =?", request.ID).First(binding).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoBinding.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRetrieveBinding.WithResult(err))
+	}
+	return newResponse(binding), nil
+}
+
+// Delete 删除标签授权
+func Delete(ctx context.Context, request *Binding) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	scope, err := tenant.Scope(ctx)
+	if err != nil {
+		return err
+	}
+	queryDel := db.With(ctx).Scopes(scope).Where("id =?", request.ID).Delete(&model.LabelBinding{})
+	if err := queryDel.Error; err != nil {
+		return errors.WithStack(code.ErrDeleteBinding.WithResult(err))
+	}
+	if queryDel.RowsAffected == 0 {
+		return errors.WithStack(code.ErrNoBinding)
+	}
+	return nil
+}
+
+// UserBindings 查询账户 accountID 内选择器匹配 labels 的标签授权
+func UserBindings(tx *gorm.DB, accountID uint64, labels map[string]string) ([]*model.LabelBinding, error) {
+	var bindingList []*model.LabelBinding
+	if err := tx.Model(&model.LabelBinding{}).Where("account_id =?", accountID).
+		Find(&bindingList).Error; err != nil {
+		return nil, err
+	}
+	matched := make([]*model.LabelBinding, 0, len(bindingList))
+	for _, binding := range bindingList {
+		selector, err := Parse(binding.Selector)
+		if err != nil {
+			// 存量数据无法解析时不授予权限
+			continue
+		}
+		if selector.Matches(labels) {
+			matched = append(matched, binding)
+		}
+	}
+	return matched, nil
+}
+
+func newResponse(binding *model.LabelBinding) *Response {
+	return &Response{
+		ID:         strconv.FormatUint(binding.ID, variable.DecimalSystem),
+		AccountID:  strconv.FormatUint(binding.AccountID, variable.DecimalSystem),
+		Name:       binding.Name,
+		Selector:   binding.Selector,
+		Permission: binding.Permission,
+		Desc:       binding.Desc,
+		CreatedAt:  binding.CreatedAt,
+		UpdatedAt:  binding.UpdatedAt,
+	}
+}
+
+// parseSelector 校验选择器并返回规范化的文本，空选择器会匹配账户内所有用户，不允许用于授权
+func parseSelector(text string) (string, error) {
+	selector, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if selector.Empty() {
+		return "", errors.WithStack(code.ErrInvalidSelector.WithResult("selector must not be empty"))
+	}
+	return selector.String(), nil
+}
+
+// verifyGrant 校验当前用户能否为标签授权授予 permission
+func verifyGrant(ctx context.Context, permission string) error {
+	actionMap, err := auth.ParsePermission(permission)
+	if err != nil {
+		return errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	return auth.VerifyGrant(ctx, actionMap)
+}
@@ -0,0 +1,83 @@
+package label
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// subtree 管理员可访问所属账户及其下级账户
+const subtree = "account_id IN (SELECT d.id FROM `account` AS d JOIN `account` AS p ON d.path LIKE CONCAT(p.path, '%') " +
+	"WHERE p.id =? AND p.deleted = 0 AND d.deleted = 0)"
+
+func adminContext() context.Context {
+	return auth.SetToken(context.Background(), &auth.Token{
+		AccountID:  "1",
+		UserID:     "10",
+		Permission: map[string]uint8{v.ServiceName: auth.Admin},
+	})
+}
+
+func assertCode(t *testing.T, err error, want e.ErrorCode) {
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) || errorCode.Code() != want.Code() {
+		t.Fatalf("want %v got %v", want, err)
+	}
+}
+
+func TestBindingOtherTenant(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := adminContext()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM (SELECT d.id")).
+		WithArgs("1", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+	_, err = Create(ctx, &CreateRequest{AccountID: "2", Name: "prod", Selector: "env=prod", Permission: `{"caty":2}`})
+	assertCode(t, err, code.ErrForbiddenAuth)
+
+	_, err = Retrieve(context.Background(), &Binding{ID: "20"})
+	assertCode(t, err, code.ErrNoAuth)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id =? AND "+subtree)).
+		WithArgs("20", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = Retrieve(ctx, &Binding{ID: "20"})
+	assertCode(t, err, code.ErrNoBinding)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE account_id = ? AND "+subtree)).
+		WithArgs("2", "1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err = List(ctx, &RetrievesRequest{AccountID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id =? AND " + subtree)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assertCode(t, Update(ctx, &Binding{ID: "20"}, &UpdateRequest{Name: "stage"}), code.ErrNoBinding)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id =? AND " + subtree)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assertCode(t, Delete(ctx, &Binding{ID: "20"}), code.ErrNoBinding)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package label
+package label
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/e"
+	"github.com/pkg/errors"
+
+	"caty/pkg/code"
+)
+
+const (
+	// MaxNameLength 标签名与标签值的最大长度
+	MaxNameLength = 63
+	// MaxPrefixLength 标签名前缀的最大长度
+	MaxPrefixLength = 253
+)
+
+var (
+	// name 标签名与标签值，以字母或数字开头和结尾，中间可包含-_.
+	name = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	// prefix 标签名前缀，为小写的DNS子域名
+	prefix = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidKey 校验标签名，格式为[前缀/]名称，如 team、caty.io/env
+func ValidKey(key string) error {
+	keyName := key
+	if index := strings.IndexByte(key, '/'); index >= 0 {
+		keyPrefix := key[:index]
+		keyName = key[index+1:]
+		if len(keyPrefix) == 0 || len(keyPrefix) > MaxPrefixLength || !prefix.MatchString(keyPrefix) {
+			return fmt.Errorf("invalid label key prefix %q", keyPrefix)
+		}
+	}
+	if len(keyName) == 0 || len(keyName) > MaxNameLength || !name.MatchString(keyName) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+// ValidValue 校验标签值，可为空
+func ValidValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > MaxNameLength || !name.MatchString(value) {
+		return fmt.Errorf("invalid label value %q", value)
+	}
+	return nil
+}
+
+// Validate 校验所有标签
+func Validate(labels map[string]string) error {
+	for key, value := range labels {
+		if err := ValidKey(key); err != nil {
+			return errors.WithStack(code.ErrInvalidLabel.WithResult(err.Error()))
+		}
+		if err := ValidValue(value); err != nil {
+			return errors.WithStack(code.ErrInvalidLabel.WithResult(err.Error()))
+		}
+	}
+	return nil
+}
+
+// Marshal 将标签序列化为JSON文本
+func Marshal(labels map[string]string) (string, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return "", errors.WithStack(e.ErrInternalServerError.WithResult(err))
+	}
+	return lirity.String(data), nil
+}
+
+// Unmarshal 解析JSON文本中的标签，无法解析时返回空标签
+func Unmarshal(data string) map[string]string {
+	labels := make(map[string]string)
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &labels)
+	}
+	return labels
+}
@@ -0,0 +1,309 @@
+// Package label
+package label
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+)
+
+// 选择器操作符
+const (
+	Equals       = "="
+	DoubleEquals = "=="
+	NotEquals    = "!="
+	In           = "in"
+	NotIn        = "notin"
+	Exists       = "exists"
+	DoesNotExist = "!"
+)
+
+// Requirement 单个标签条件，如 env in (prod,stage)、!contractor
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// Matches 判断标签是否满足条件，!= 与 notin 在标签不存在时同样满足
+func (r *Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case Equals, DoubleEquals, In:
+		return ok && r.has(value)
+	case NotEquals, NotIn:
+		return !ok || !r.has(value)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+func (r *Requirement) has(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	case In, NotIn:
+		return r.Key + " " + r.Operator + " (" + strings.Join(r.Values, ",") + ")"
+	}
+	return r.Key + r.Operator + r.Values[0]
+}
+
+// Selector 标签选择器，多个条件之间为与关系，空选择器匹配所有标签
+type Selector []*Requirement
+
+// Matches 判断标签是否满足选择器的所有条件
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		if !requirement.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty 是否为空选择器
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+func (s Selector) String() string {
+	requirements := make([]string, 0, len(s))
+	for _, requirement := range s {
+		requirements = append(requirements, requirement.String())
+	}
+	return strings.Join(requirements, ",")
+}
+
+// Scope 按选择器过滤 column 列中以JSON对象存储的标签
+func (s Selector) Scope(column string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, r := range s {
+			// 标签名已校验不含引号，可安全拼接为JSON路径
+			path := `$."` + r.Key + `"`
+			value := "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", ?))"
+			switch r.Operator {
+			case Equals, DoubleEquals:
+				tx = tx.Where(value+" = ?", path, r.Values[0])
+			case NotEquals:
+				tx = tx.Where("(NOT JSON_CONTAINS_PATH("+column+", 'one', ?) OR "+value+" <> ?)",
+					path, path, r.Values[0])
+			case In:
+				tx = tx.Where(value+" IN ?", path, r.Values)
+			case NotIn:
+				tx = tx.Where("(NOT JSON_CONTAINS_PATH("+column+", 'one', ?) OR "+value+" NOT IN ?)",
+					path, path, r.Values)
+			case Exists:
+				tx = tx.Where("JSON_CONTAINS_PATH("+column+", 'one', ?)", path)
+			case DoesNotExist:
+				tx = tx.Where("NOT JSON_CONTAINS_PATH("+column+", 'one', ?)", path)
+			}
+		}
+		return tx
+	}
+}
+
+// Parse 解析Kubernetes风格的标签选择器，如 env in (prod,stage),!contractor
+// 支持 key=value、key==value、key!=value、key in (v1,v2)、key notin (v1,v2)、key、!key
+func Parse(selector string) (Selector, error) {
+	p := &parser{tokens: lex(selector)}
+	result, err := p.parse()
+	if err != nil {
+		return nil, errors.WithStack(code.ErrInvalidSelector.WithResult(err.Error()))
+	}
+	return result, nil
+}
+
+const (
+	tokenIdentifier = iota
+	tokenOperator
+	tokenComma
+	tokenOpenParen
+	tokenCloseParen
+	tokenError
+	tokenEnd
+)
+
+type token struct {
+	kind  int
+	value string
+}
+
+func isIdentifierChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		ch == '-' || ch == '_' || ch == '.' || ch == '/'
+}
+
+// lex 将选择器切分为词法单元，以 tokenEnd 结尾
+func lex(selector string) []token {
+	var tokens []token
+	for i := 0; i < len(selector); {
+		ch := selector[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ","})
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, value: "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, value: ")"})
+			i++
+		case ch == '=' || ch == '!':
+			operator := string(ch)
+			if i+1 < len(selector) && selector[i+1] == '=' {
+				operator += "="
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: operator})
+			i += len(operator)
+		case isIdentifierChar(ch):
+			start := i
+			for i < len(selector) && isIdentifierChar(selector[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, value: selector[start:i]})
+		default:
+			return append(tokens, token{kind: tokenError, value: string(ch)})
+		}
+	}
+	return append(tokens, token{kind: tokenEnd})
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if t.kind != tokenEnd {
+		p.position++
+	}
+	return t
+}
+
+func (p *parser) parse() (Selector, error) {
+	var selector Selector
+	if p.peek().kind == tokenEnd {
+		return selector, nil
+	}
+	for {
+		requirement, err := p.requirement()
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, requirement)
+		switch t := p.next(); t.kind {
+		case tokenEnd:
+			sort.SliceStable(selector, func(i, j int) bool { return selector[i].Key < selector[j].Key })
+			return selector, nil
+		case tokenComma:
+		default:
+			return nil, fmt.Errorf("unexpected %q, expected ','", t.value)
+		}
+	}
+}
+
+func (p *parser) requirement() (*Requirement, error) {
+	t := p.next()
+	if t.kind == tokenOperator && t.value == DoesNotExist {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		return &Requirement{Key: key, Operator: DoesNotExist}, nil
+	}
+	if t.kind != tokenIdentifier {
+		return nil, fmt.Errorf("unexpected %q, expected label key", t.value)
+	}
+	if err := ValidKey(t.value); err != nil {
+		return nil, err
+	}
+	requirement := &Requirement{Key: t.value}
+	switch op := p.peek(); {
+	case op.kind == tokenEnd || op.kind == tokenComma:
+		requirement.Operator = Exists
+		return requirement, nil
+	case op.kind == tokenOperator && op.value != DoesNotExist:
+		p.next()
+		requirement.Operator = op.value
+		value := ""
+		if p.peek().kind == tokenIdentifier {
+			value = p.next().value
+		}
+		if err := ValidValue(value); err != nil {
+			return nil, err
+		}
+		requirement.Values = []string{value}
+		return requirement, nil
+	case op.kind == tokenIdentifier && (op.value == In || op.value == NotIn):
+		p.next()
+		requirement.Operator = op.value
+		values, err := p.values()
+		if err != nil {
+			return nil, err
+		}
+		requirement.Values = values
+		return requirement, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q after label key %s", op.value, t.value)
+	}
+}
+
+func (p *parser) key() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdentifier {
+		return "", fmt.Errorf("unexpected %q, expected label key", t.value)
+	}
+	return t.value, ValidKey(t.value)
+}
+
+// values 解析 (v1,v2) 形式的取值集合，不能为空
+func (p *parser) values() ([]string, error) {
+	if t := p.next(); t.kind != tokenOpenParen {
+		return nil, fmt.Errorf("unexpected %q, expected '('", t.value)
+	}
+	var values []string
+	for {
+		t := p.next()
+		if t.kind != tokenIdentifier {
+			return nil, fmt.Errorf("unexpected %q, expected label value", t.value)
+		}
+		if err := ValidValue(t.value); err != nil {
+			return nil, err
+		}
+		values = append(values, t.value)
+		switch t = p.next(); t.kind {
+		case tokenCloseParen:
+			sort.Strings(values)
+			return values, nil
+		case tokenComma:
+		default:
+			return nil, fmt.Errorf("unexpected %q, expected ',' or ')'", t.value)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package label
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{selector: "", want: ""},
+		{selector: "env=prod", want: "env=prod"},
+		{selector: "env==prod", want: "env==prod"},
+		{selector: "env!=prod", want: "env!=prod"},
+		{selector: "env in (stage, prod),!contractor", want: "!contractor,env in (prod,stage)"},
+		{selector: "team notin (infra),caty.io/owner", want: "caty.io/owner,team notin (infra)"},
+		{selector: "env=", want: "env="},
+	}
+	for _, test := range tests {
+		selector, err := Parse(test.selector)
+		if err != nil {
+			t.Fatalf("%q: %v", test.selector, err)
+		}
+		if got := selector.String(); got != test.want {
+			t.Errorf("%q: want %q got %q", test.selector, test.want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, selector := range []string{
+		"env in ()",
+		"env in (prod",
+		"env prod",
+		"env=prod,",
+		"!",
+		"env=\"prod\"",
+		"-env=prod",
+		"env notin prod",
+		"Caty.io/env=prod",
+	} {
+		if _, err := Parse(selector); err == nil {
+			t.Errorf("%q: want error", selector)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{"team": "infra", "env": "prod"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "", want: true},
+		{selector: "env in (prod,stage),!contractor", want: true},
+		{selector: "env in (prod,stage),contractor", want: false},
+		{selector: "team=infra,env!=stage", want: true},
+		{selector: "team!=infra", want: false},
+		{selector: "owner!=alice", want: true},
+		{selector: "owner notin (alice)", want: true},
+		{selector: "owner in (alice)", want: false},
+		{selector: "env notin (prod)", want: false},
+	}
+	for _, test := range tests {
+		selector, err := Parse(test.selector)
+		if err != nil {
+			t.Fatalf("%q: %v", test.selector, err)
+		}
+		if got := selector.Matches(labels); got != test.want {
+			t.Errorf("%q: want %v got %v", test.selector, test.want, got)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector, err := Parse("env in (prod,stage),!contractor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `t` WHERE NOT JSON_CONTAINS_PATH(`labels`, 'one', ?) AND "+
+		"JSON_UNQUOTE(JSON_EXTRACT(`labels`, ?)) IN (?,?)")).
+		WithArgs(`$."contractor"`, `$."env"`, "prod", "stage").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	var rows []map[string]interface{}
+	if err = db.With(context.Background()).Table("t").Scopes(selector.Scope("`labels`")).
+		Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,197 @@
+// Package profile
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/crochee/lirity"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/variable"
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/tenant"
+	"caty/pkg/v"
+)
+
+type Account struct {
+	// 账户ID
+	// Required: true
+	// in: path
+	ID string `json:"id" uri:"id" binding:"required,numeric"`
+}
+
+type SchemaRequest struct {
+	// 用户属性的JSON Schema，顶层须为object，属性支持default与deprecated
+	// Required: true
+	Schema map[string]interface{} `json:"schema" binding:"required"`
+}
+
+type SchemaResponse struct {
+	// 账户ID
+	AccountID string `json:"account_id"`
+	// 用户属性的JSON Schema
+	Schema map[string]interface{} `json:"schema"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Put 设置账户的用户属性模式，已存在时覆盖
+func Put(ctx context.Context, account *Account, request *SchemaRequest) (*SchemaResponse, error) {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return nil, err
+	}
+	if schemaType, ok := request.Schema["type"]; ok && schemaType != "object" {
+		return nil, errors.WithStack(code.ErrInvalidProfileSchema.WithResult("type must be object"))
+	}
+	data, err := json.Marshal(request.Schema)
+	if err != nil {
+		return nil, errors.WithStack(code.ErrInvalidProfileSchema.WithResult(err))
+	}
+	if _, err = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data)); err != nil {
+		return nil, errors.WithStack(code.ErrInvalidProfileSchema.WithResult(err))
+	}
+	schemaModel := &model.ProfileSchema{}
+	err = db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tenant.Verify(ctx, tx, account.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Account{}).Where("id =?", account.ID).
+			First(&model.Account{}).Error; err != nil {
+			if errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrNoAccount.WithResult(err))
+			}
+			return errors.WithStack(code.ErrPutProfileSchema.WithResult(err))
+		}
+		if err := tx.Model(schemaModel).Where("account_id =?", account.ID).
+			First(schemaModel).Error; err != nil {
+			if !errors.Is(err, db.NotFound) {
+				return errors.WithStack(code.ErrPutProfileSchema.WithResult(err))
+			}
+			schemaModel.AccountID, _ = strconv.ParseUint(account.ID, variable.DecimalSystem, 64)
+			schemaModel.Schema = lirity.String(data)
+			if err = tx.Model(schemaModel).Create(schemaModel).Error; err != nil {
+				return errors.WithStack(code.ErrPutProfileSchema.WithResult(err))
+			}
+		} else if err = tx.Model(schemaModel).Where("id =?", schemaModel.ID).
+			Update("schema", lirity.String(data)).Error; err != nil {
+			return errors.WithStack(code.ErrPutProfileSchema.WithResult(err))
+		}
+		if err := tx.Model(schemaModel).Where("id =?", schemaModel.ID).First(schemaModel).Error; err != nil {
+			return errors.WithStack(code.ErrPutProfileSchema.WithResult(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSchemaResponse(schemaModel), nil
+}
+
+// Retrieve 查询账户的用户属性模式
+func Retrieve(ctx context.Context, account *Account) (*SchemaResponse, error) {
+	if err := tenant.Verify(ctx, db.With(ctx).DB, account.ID); err != nil {
+		return nil, err
+	}
+	schemaModel := &model.ProfileSchema{}
+	if err := db.With(ctx).Model(schemaModel).Where("account_id =?", account.ID).
+		First(schemaModel).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			return nil, errors.WithStack(code.ErrNoProfileSchema.WithResult(err))
+		}
+		return nil, errors.WithStack(code.ErrRetrieveProfileSchema.WithResult(err))
+	}
+	return newSchemaResponse(schemaModel), nil
+}
+
+// Delete 删除账户的用户属性模式，删除后用户属性不再校验
+func Delete(ctx context.Context, account *Account) error {
+	if _, err := auth.VerifyToken(ctx, v.ServiceName, auth.Admin); err != nil {
+		return err
+	}
+	if err := tenant.Verify(ctx, db.With(ctx).DB, account.ID); err != nil {
+		return err
+	}
+	query := db.With(ctx).Where("account_id =?", account.ID).Delete(&model.ProfileSchema{})
+	if err := query.Error; err != nil {
+		return errors.WithStack(code.ErrDeleteProfileSchema.WithResult(err))
+	}
+	if query.RowsAffected == 0 {
+		return errors.WithStack(code.ErrNoProfileSchema)
+	}
+	return nil
+}
+
+func newSchemaResponse(schemaModel *model.ProfileSchema) *SchemaResponse {
+	response := &SchemaResponse{
+		AccountID: strconv.FormatUint(schemaModel.AccountID, variable.DecimalSystem),
+		Schema:    make(map[string]interface{}),
+		CreatedAt: schemaModel.CreatedAt,
+		UpdatedAt: schemaModel.UpdatedAt,
+	}
+	_ = json.Unmarshal([]byte(schemaModel.Schema), &response.Schema)
+	return response
+}
+
+// Apply 按账户的用户属性模式补全默认值并校验 attributes，返回使用已废弃属性的告警
+func Apply(tx *gorm.DB, accountID uint64, attributes map[string]interface{}) ([]string, error) {
+	schemaModel := &model.ProfileSchema{}
+	if err := tx.Model(schemaModel).Where("account_id =?", accountID).First(schemaModel).Error; err != nil {
+		if errors.Is(err, db.NotFound) {
+			// 未设置模式时不做校验
+			return nil, nil
+		}
+		return nil, errors.WithStack(code.ErrRetrieveProfileSchema.WithResult(err))
+	}
+	return Validate(schemaModel.Schema, attributes)
+}
+
+// Validate 按模式 schema 补全默认值并校验 attributes
+func Validate(schema string, attributes map[string]interface{}) ([]string, error) {
+	var schemaContent struct {
+		Properties map[string]struct {
+			Default    interface{} `json:"default"`
+			Deprecated bool        `json:"deprecated"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(schema), &schemaContent); err != nil {
+		return nil, errors.WithStack(code.ErrInvalidProfileSchema.WithResult(err))
+	}
+	var warnings []string
+	for name, property := range schemaContent.Properties {
+		if _, ok := attributes[name]; ok {
+			if property.Deprecated {
+				warnings = append(warnings, fmt.Sprintf("attribute %s is deprecated", name))
+			}
+			continue
+		}
+		if property.Default != nil {
+			attributes[name] = property.Default
+		}
+	}
+	sort.Strings(warnings)
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(schema),
+		gojsonschema.NewGoLoader(attributes))
+	if err != nil {
+		return nil, errors.WithStack(code.ErrInvalidProfileSchema.WithResult(err))
+	}
+	if !result.Valid() {
+		details := make([]string, 0, len(result.Errors()))
+		for _, resultError := range result.Errors() {
+			details = append(details, resultError.String())
+		}
+		return nil, errors.WithStack(code.ErrInvalidAttributes.WithResult(details))
+	}
+	return warnings, nil
+}
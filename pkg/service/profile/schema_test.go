@@ -0,0 +1,129 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"department": {"type": "string"},
+		"level": {"type": "integer", "default": 1},
+		"fax": {"type": "string", "deprecated": true}
+	},
+	"required": ["department"],
+	"additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	list := []struct {
+		name       string
+		attributes map[string]interface{}
+		want       map[string]interface{}
+		warnings   []string
+		err        e.ErrorCode
+	}{
+		{
+			name:       "default",
+			attributes: map[string]interface{}{"department": "dev"},
+			want:       map[string]interface{}{"department": "dev", "level": 1.0},
+		},
+		{
+			name:       "deprecated",
+			attributes: map[string]interface{}{"department": "dev", "level": 3, "fax": "123"},
+			want:       map[string]interface{}{"department": "dev", "level": 3, "fax": "123"},
+			warnings:   []string{"attribute fax is deprecated"},
+		},
+		{
+			name:       "required",
+			attributes: map[string]interface{}{"level": 3},
+			err:        code.ErrInvalidAttributes,
+		},
+		{
+			name:       "type",
+			attributes: map[string]interface{}{"department": 1},
+			err:        code.ErrInvalidAttributes,
+		},
+		{
+			name:       "additional",
+			attributes: map[string]interface{}{"department": "dev", "other": true},
+			err:        code.ErrInvalidAttributes,
+		},
+	}
+	for _, input := range list {
+		t.Run(input.name, func(t *testing.T) {
+			warnings, err := Validate(testSchema, input.attributes)
+			if input.err != nil {
+				var errorCode e.ErrorCode
+				if !errors.As(err, &errorCode) || errorCode.Code() != input.err.Code() {
+					t.Errorf("want %v got %v", input.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(input.attributes, input.want) {
+				t.Errorf("want %v got %v", input.want, input.attributes)
+			}
+			if !reflect.DeepEqual(warnings, input.warnings) {
+				t.Errorf("want %v got %v", input.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestSchemaOtherTenant(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := auth.SetToken(context.Background(), &auth.Token{
+		AccountID:  "1",
+		UserID:     "10",
+		Permission: map[string]uint8{v.ServiceName: auth.Admin},
+	})
+	assertForbidden := func(err error) {
+		var errorCode e.ErrorCode
+		if !errors.As(err, &errorCode) || errorCode.Code() != code.ErrForbiddenAuth.Code() {
+			t.Fatalf("want forbidden got %v", err)
+		}
+	}
+	expectCount := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM (SELECT d.id")).
+			WithArgs("1", "2").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+
+	mock.ExpectBegin()
+	expectCount()
+	mock.ExpectRollback()
+	_, err = Put(ctx, &Account{ID: "2"}, &SchemaRequest{Schema: map[string]interface{}{"type": "object"}})
+	assertForbidden(err)
+
+	expectCount()
+	_, err = Retrieve(ctx, &Account{ID: "2"})
+	assertForbidden(err)
+
+	expectCount()
+	assertForbidden(Delete(ctx, &Account{ID: "2"}))
+
+	if _, err = Retrieve(context.Background(), &Account{ID: "2"}); err == nil {
+		t.Fatal("want error without token")
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package quota
+package quota
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+)
+
+// 配额资源
+const (
+	ResourceUsers    = "users"
+	ResourceSessions = "sessions"
+	ResourceGroups   = "groups"
+)
+
+// defaults 未配置时各资源的默认上限，0表示不限制
+var defaults = map[string]int64{
+	ResourceUsers:    100,
+	ResourceSessions: 1000,
+	ResourceGroups:   50,
+}
+
+// Counter 统计账户已使用的资源数量
+type Counter func(tx *gorm.DB, accountID uint64) (int64, error)
+
+// counters 各资源的用量统计
+var counters = map[string]Counter{
+	ResourceUsers: func(tx *gorm.DB, accountID uint64) (int64, error) {
+		var count int64
+		err := tx.Model(&model.User{}).Where("account_id =?", accountID).Count(&count).Error
+		return count, err
+	},
+	ResourceSessions: func(tx *gorm.DB, accountID uint64) (int64, error) {
+		var count int64
+		err := tx.Model(&model.Session{}).Where("account_id =? AND expired_at >?",
+			accountID, tx.NowFunc()).Count(&count).Error
+		return count, err
+	},
+	ResourceGroups: func(tx *gorm.DB, accountID uint64) (int64, error) {
+		var count int64
+		err := tx.Model(&model.Group{}).Where("account_id =?", accountID).Count(&count).Error
+		return count, err
+	},
+}
+
+// Resources 支持的配额资源
+func Resources() []string {
+	resources := make([]string, 0, len(defaults))
+	for resource := range defaults {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// IsResource 判断是否为支持的配额资源
+func IsResource(resource string) bool {
+	_, ok := defaults[resource]
+	return ok
+}
+
+// Default 资源的默认上限，可通过 quota.<resource> 配置
+func Default(resource string) int64 {
+	key := "quota." + resource
+	if viper.IsSet(key) {
+		return viper.GetInt64(key)
+	}
+	return defaults[resource]
+}
+
+// Usage 资源的配额上限与用量
+type Usage struct {
+	// 配额资源 users,sessions,groups
+	Resource string `json:"resource"`
+	// 配额上限，0表示不限制
+	Limit int64 `json:"limit"`
+	// 已使用数量
+	Used int64 `json:"used"`
+	// 是否为账户单独设置的上限
+	Override bool `json:"override"`
+}
+
+// Check 在事务 tx 中校验账户 accountID 新增一个 resource 后不超过配额
+// 锁定账户记录使同一账户的并发创建串行执行，须在创建资源的同一事务中调用
+func Check(tx *gorm.DB, accountID uint64, resource string) error {
+	return CheckN(tx, accountID, resource, 1)
+}
+
+// CheckN 与 Check 一致，校验新增 n 个 resource 后不超过配额，用于批量恢复等场景
+func CheckN(tx *gorm.DB, accountID uint64, resource string, n int64) error {
+	if err := tx.Model(&model.Account{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id =?", accountID).First(&model.Account{}).Error; err != nil {
+		return errors.WithStack(code.ErrRetrieveQuota.WithResult(err))
+	}
+	usage, err := usageOf(tx, accountID, resource)
+	if err != nil {
+		return err
+	}
+	if usage.Limit > 0 && usage.Used+n > usage.Limit {
+		return errors.WithStack(code.ErrExceedQuota.WithResult(map[string]interface{}{
+			"resource": resource,
+			"limit":    usage.Limit,
+			"used":     usage.Used,
+		}))
+	}
+	return nil
+}
+
+// List 查询账户 accountID 各资源的配额与用量
+func List(tx *gorm.DB, accountID uint64) ([]*Usage, error) {
+	resources := Resources()
+	usages := make([]*Usage, 0, len(resources))
+	for _, resource := range resources {
+		usage, err := usageOf(tx, accountID, resource)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// Put 在事务 tx 中设置账户的配额上限，limit为nil时恢复为默认上限
+func Put(tx *gorm.DB, accountID uint64, limits map[string]*int64) error {
+	for resource, limit := range limits {
+		if !IsResource(resource) {
+			return errors.WithStack(code.ErrInvalidQuota.WithResult(
+				resource + " not in " + strings.Join(Resources(), ",")))
+		}
+		if limit == nil {
+			if err := tx.Where("account_id =? AND resource =?", accountID, resource).
+				Delete(&model.Quota{}).Error; err != nil {
+				return errors.WithStack(code.ErrUpdateQuota.WithResult(err))
+			}
+			continue
+		}
+		quotaModel := &model.Quota{
+			AccountID: accountID,
+			Resource:  resource,
+			Limit:     *limit,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"limit"}),
+		}).Create(quotaModel).Error; err != nil {
+			return errors.WithStack(code.ErrUpdateQuota.WithResult(err))
+		}
+	}
+	return nil
+}
+
+// Purge 在事务 tx 中删除账户的配额设置，accountIDs 可为id列表或子查询
+func Purge(tx *gorm.DB, accountIDs interface{}) error {
+	if err := tx.Where("account_id IN (?)", accountIDs).Delete(&model.Quota{}).Error; err != nil {
+		return errors.WithStack(code.ErrUpdateQuota.WithResult(err))
+	}
+	return nil
+}
+
+func usageOf(tx *gorm.DB, accountID uint64, resource string) (*Usage, error) {
+	usage := &Usage{
+		Resource: resource,
+		Limit:    Default(resource),
+	}
+	var overrides []*model.Quota
+	if err := tx.Model(&model.Quota{}).Where("account_id =? AND resource =?", accountID, resource).
+		Find(&overrides).Error; err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveQuota.WithResult(err))
+	}
+	if len(overrides) > 0 {
+		usage.Limit = overrides[0].Limit
+		usage.Override = true
+	}
+	counter, ok := counters[resource]
+	if !ok {
+		return usage, nil
+	}
+	used, err := counter(tx, accountID)
+	if err != nil {
+		return nil, errors.WithStack(code.ErrRetrieveQuota.WithResult(err))
+	}
+	usage.Used = used
+	return usage, nil
+}
@@ -0,0 +1,91 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/e"
+	"github.com/spf13/viper"
+
+	"caty/pkg/code"
+)
+
+func assertCode(t *testing.T, err error, want e.ErrorCode) {
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) || errorCode.Code() != want.Code() {
+		t.Fatalf("want %v got %v", want, err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if got := Default(ResourceGroups); got != 50 {
+		t.Fatalf("want 50 got %d", got)
+	}
+	viper.Set("quota.groups", 0)
+	defer viper.Set("quota.groups", nil)
+	if got := Default(ResourceGroups); got != 0 {
+		t.Fatalf("want 0 got %d", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account` WHERE id =?")).
+		WithArgs(uint64(1), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_quota` WHERE account_id =? AND resource =?")).
+		WithArgs(uint64(1), ResourceGroups).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "limit"}).AddRow(1, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `user_group` WHERE account_id =?")).
+		WithArgs(uint64(1), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	err = Check(db.With(context.Background()).DB, 1, ResourceGroups)
+	assertCode(t, err, code.ErrExceedQuota)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckN(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account` WHERE id =?")).
+			WithArgs(uint64(1), 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_quota` WHERE account_id =? AND resource =?")).
+			WithArgs(uint64(1), ResourceUsers).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "limit"}).AddRow(1, 3))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `user` WHERE account_id =?")).
+			WithArgs(uint64(1), 0).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+	expect()
+	if err = CheckN(db.With(context.Background()).DB, 1, ResourceUsers, 2); err != nil {
+		t.Fatal(err)
+	}
+	expect()
+	err = CheckN(db.With(context.Background()).DB, 1, ResourceUsers, 3)
+	assertCode(t, err, code.ErrExceedQuota)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPutInvalid(t *testing.T) {
+	if _, err := db.Mock(); err != nil {
+		t.Fatal(err)
+	}
+	limit := int64(1)
+	err := Put(db.With(context.Background()).DB, 1, map[string]*int64{"disks": &limit})
+	assertCode(t, err, code.ErrInvalidQuota)
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/crochee/lirity/db"
+	"github.com/crochee/lirity/logger"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"caty/pkg/code"
+	"caty/pkg/model"
+)
+
+// DBStore 集群共享的数据库令牌桶存储
+type DBStore struct {
+}
+
+func (DBStore) Take(ctx context.Context, rule *Rule, key string, now time.Time) (*Result, error) {
+	hash := sha256.Sum256([]byte(key))
+	bucketKey := hex.EncodeToString(hash[:])
+	var result *Result
+	err := db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		record := &model.RateLimit{}
+		b := &bucket{}
+		err := tx.Model(record).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket_key =?", bucketKey).Take(record).Error
+		if err != nil && !errors.Is(err, db.NotFound) {
+			return errors.WithStack(code.ErrRateLimit.WithResult(err))
+		}
+		found := err == nil
+		if found {
+			b.tokens, b.refilledAt = record.Tokens, record.RefilledAt
+		}
+		result = b.take(rule, now)
+		if !found {
+			// 并发创建同一令牌桶时以先创建的为准
+			if err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.RateLimit{
+				Key:        bucketKey,
+				Tokens:     b.tokens,
+				RefilledAt: b.refilledAt,
+				ExpiredAt:  now.Add(result.Reset),
+			}).Error; err != nil {
+				return errors.WithStack(code.ErrRateLimit.WithResult(err))
+			}
+			return nil
+		}
+		if err = tx.Model(&model.RateLimit{}).Where("id =?", record.ID).Updates(map[string]interface{}{
+			"tokens":      b.tokens,
+			"refilled_at": b.refilledAt,
+			"expired_at":  now.Add(result.Reset),
+		}).Error; err != nil {
+			return errors.WithStack(code.ErrRateLimit.WithResult(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PurgeExpired 删除已回满的令牌桶，回满的令牌桶与新建的等价
+func PurgeExpired(ctx context.Context) error {
+	query := db.With(ctx).Where("expired_at <?", time.Now()).Delete(&model.RateLimit{})
+	if err := query.Error; err != nil {
+		return errors.WithStack(code.ErrRateLimit.WithResult(err))
+	}
+	logger.From(ctx).Sugar().Infof("purge %d expired rate limit bucket(s)", query.RowsAffected)
+	return nil
+}
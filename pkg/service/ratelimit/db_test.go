@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+)
+
+func TestDBStoreTake(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := &Rule{Route: "/v1/accounts", Key: KeyIP, Requests: 1, Period: time.Second, Burst: 1}
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rate_limit` WHERE bucket_key =?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bucket_key", "tokens", "refilled_at"}).
+			AddRow(1, "k", 0.25, now.Add(-500*time.Millisecond)))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rate_limit` SET")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 0.75, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	result, err := DBStore{}.Take(context.Background(), rule, "ip:127.0.0.1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.RetryAfter != 250*time.Millisecond {
+		t.Fatalf("want denied got %+v", result)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 内存令牌桶清理间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 单节点的内存令牌桶存储
+type MemoryStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*memoryBucket
+	sweptAt time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	// expiredAt 令牌桶回满的时间，之后可以删除
+	expiredAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *MemoryStore) Take(_ context.Context, rule *Rule, key string, now time.Time) (*Result, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sweep(now)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{}
+		m.buckets[key] = b
+	}
+	result := b.take(rule, now)
+	b.expiredAt = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep 定期删除已回满的令牌桶，回满的令牌桶与新建的等价
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.sweptAt) < sweepInterval {
+		return
+	}
+	m.sweptAt = now
+	for key, b := range m.buckets {
+		if !b.expiredAt.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Package ratelimit 基于令牌桶的请求限流
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/logger"
+	"github.com/spf13/viper"
+)
+
+// 限流维度
+const (
+	KeyIP   = "ip"
+	KeyUser = "user"
+)
+
+// 限流存储
+const (
+	StoreMemory = "memory"
+	StoreDB     = "db"
+)
+
+// DefaultPurgeSpec 默认清理数据库中已回满令牌桶的执行时间
+const DefaultPurgeSpec = "0 */10 * * * *"
+
+// Enabled 是否启用限流
+func Enabled() bool {
+	return viper.GetBool("rate_limit.enable")
+}
+
+// StoreName 限流存储，单节点使用 memory，集群使用共享的 db
+func StoreName() string {
+	if store := viper.GetString("rate_limit.store"); store != "" {
+		return store
+	}
+	return StoreMemory
+}
+
+// PurgeSpec 清理数据库中已回满令牌桶的cron表达式
+func PurgeSpec() string {
+	if spec := viper.GetString("rate_limit.purge_spec"); spec != "" {
+		return spec
+	}
+	return DefaultPurgeSpec
+}
+
+// Rule 限流规则，每个 Key 维度的取值独立拥有一个令牌桶
+type Rule struct {
+	// Route 路由模板，支持通配符，如 /v1/accounts、/v1/accounts/*
+	Route string `mapstructure:"route"`
+	// Method 请求方法，为空时匹配所有方法
+	Method string `mapstructure:"method"`
+	// Key 限流维度 ip,user，未携带token时 user 按 ip 限流
+	Key string `mapstructure:"key"`
+	// Requests 每个周期补充的令牌数
+	Requests int `mapstructure:"requests"`
+	// Period 补充周期
+	Period time.Duration `mapstructure:"period"`
+	// Burst 令牌桶容量，默认等于 Requests
+	Burst int `mapstructure:"burst"`
+}
+
+// Validate 校验规则并补全默认值
+func (r *Rule) Validate() error {
+	if r.Route == "" {
+		return fmt.Errorf("rate limit rule route is required")
+	}
+	if _, err := path.Match(r.Route, ""); err != nil {
+		return fmt.Errorf("rate limit rule route %s: %w", r.Route, err)
+	}
+	switch r.Key {
+	case KeyIP, KeyUser:
+	default:
+		return fmt.Errorf("rate limit rule %s has invalid key %q, must be one of ip,user", r.Route, r.Key)
+	}
+	if r.Requests <= 0 || r.Period <= 0 {
+		return fmt.Errorf("rate limit rule %s requests and period must be positive", r.Route)
+	}
+	if r.Burst <= 0 {
+		r.Burst = r.Requests
+	}
+	r.Method = strings.ToUpper(r.Method)
+	return nil
+}
+
+// Match 判断请求是否适用该规则，route 为请求匹配到的路由模板
+func (r *Rule) Match(method, route string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	matched, _ := path.Match(r.Route, route)
+	return matched
+}
+
+// BucketKey 令牌桶标识
+func (r *Rule) BucketKey(value string) string {
+	return fmt.Sprintf("%s %s %s=%s", r.Method, r.Route, r.Key, value)
+}
+
+// rate 每秒补充的令牌数
+func (r *Rule) rate() float64 {
+	return float64(r.Requests) / r.Period.Seconds()
+}
+
+// Rules 读取配置中的限流规则
+func Rules() ([]*Rule, error) {
+	var rules []*Rule
+	if err := viper.UnmarshalKey("rate_limit.rules", &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// Load 读取配置中的规则并创建存储，规则按维度拆分，
+// 按IP限流的规则须在校验token之前执行，按用户限流的规则须在校验token之后执行
+func Load() (store Store, ipRules, userRules []*Rule, err error) {
+	rules, err := Rules()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if store, err = NewStore(StoreName()); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, rule := range rules {
+		if rule.Key == KeyUser {
+			userRules = append(userRules, rule)
+			continue
+		}
+		ipRules = append(ipRules, rule)
+	}
+	return store, ipRules, userRules, nil
+}
+
+// Apply 从 rules 中匹配请求的每条规则对应的令牌桶各取一个令牌，多条规则匹配时返回最严格的结果，
+// 没有匹配的规则时返回nil，keyOf 返回请求在限流维度上的取值
+func Apply(ctx context.Context, store Store, rules []*Rule, method, route string,
+	keyOf func(key string) string) *Result {
+	now := time.Now()
+	var report *Result
+	for _, rule := range rules {
+		if !rule.Match(method, route) {
+			continue
+		}
+		result, err := store.Take(ctx, rule, rule.BucketKey(keyOf(rule.Key)), now)
+		if err != nil {
+			// 存储异常时放行，避免限流故障导致服务不可用
+			logger.From(ctx).Sugar().Errorf("%+v", err)
+			continue
+		}
+		if report == nil || Restrictive(result, report) {
+			report = result
+		}
+	}
+	return report
+}
+
+// Restrictive 判断 result 是否比 report 更严格
+func Restrictive(result, report *Result) bool {
+	if result.Allowed != report.Allowed {
+		return !result.Allowed
+	}
+	return result.Remaining < report.Remaining
+}
+
+// CeilSeconds 向上取整的秒数，用于 RateLimit-Reset 与 Retry-After
+func CeilSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%d", int64(math.Ceil(duration.Seconds())))
+}
+
+// Result 取令牌的结果
+type Result struct {
+	// Allowed 是否放行
+	Allowed bool
+	// Limit 令牌桶容量
+	Limit int
+	// Remaining 剩余令牌数
+	Remaining int
+	// Reset 令牌桶回满所需时间
+	Reset time.Duration
+	// RetryAfter 被拒绝时距下一个可用令牌的时间
+	RetryAfter time.Duration
+}
+
+// Store 令牌桶存储
+type Store interface {
+	// Take 在 now 时从 key 对应的令牌桶中取一个令牌
+	Take(ctx context.Context, rule *Rule, key string, now time.Time) (*Result, error)
+}
+
+// NewStore 按名称创建令牌桶存储
+func NewStore(name string) (Store, error) {
+	switch name {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreDB:
+		return DBStore{}, nil
+	}
+	return nil, fmt.Errorf("unsupported rate limit store %s, must be one of memory,db", name)
+}
+
+// bucket 令牌桶状态
+type bucket struct {
+	tokens     float64
+	refilledAt time.Time
+}
+
+// take 补充自上次以来的令牌后取一个令牌，新建的令牌桶是满的
+func (b *bucket) take(rule *Rule, now time.Time) *Result {
+	burst := float64(rule.Burst)
+	if b.refilledAt.IsZero() {
+		b.tokens = burst
+		b.refilledAt = now
+	} else if now.After(b.refilledAt) {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.refilledAt).Seconds()*rule.rate())
+		b.refilledAt = now
+	}
+	result := &Result{Limit: rule.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rule.rate())
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((burst - b.tokens) / rule.rate())
+	return result
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRuleValidate(t *testing.T) {
+	rule := &Rule{Route: "/v1/accounts/*", Method: "post", Key: KeyUser, Requests: 10, Period: time.Second}
+	if err := rule.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Burst != 10 || rule.Method != "POST" {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+	if !rule.Match("POST", "/v1/accounts/:id") || rule.Match("GET", "/v1/accounts/:id") ||
+		rule.Match("POST", "/v1/accounts") {
+		t.Fatal("unexpected match")
+	}
+	for _, invalid := range []*Rule{
+		{Key: KeyIP, Requests: 1, Period: time.Second},
+		{Route: "/v1/accounts", Key: "token", Requests: 1, Period: time.Second},
+		{Route: "/v1/accounts", Key: KeyIP, Period: time.Second},
+		{Route: "/v1/accounts[", Key: KeyIP, Requests: 1, Period: time.Second},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Fatalf("want error for %+v", invalid)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	rule := &Rule{Route: "/v1/accounts", Key: KeyIP, Requests: 2, Period: time.Second, Burst: 3}
+	now := time.Now()
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(context.Background(), rule, "a", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != i || result.Limit != 3 {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	}
+	result, _ := store.Take(context.Background(), rule, "a", now)
+	if result.Allowed || result.RetryAfter != 500*time.Millisecond || result.Reset != 1500*time.Millisecond {
+		t.Fatalf("want denied got %+v", result)
+	}
+	// 其他令牌桶互不影响
+	if result, _ = store.Take(context.Background(), rule, "b", now); !result.Allowed {
+		t.Fatalf("want allowed got %+v", result)
+	}
+	// 半秒后补充一个令牌
+	if result, _ = store.Take(context.Background(), rule, "a", now.Add(500*time.Millisecond)); !result.Allowed {
+		t.Fatalf("want allowed got %+v", result)
+	}
+	// 回满后的令牌桶被清理
+	store.Take(context.Background(), rule, "c", now.Add(time.Hour))
+	if len(store.buckets) != 1 {
+		t.Fatalf("want swept got %d buckets", len(store.buckets))
+	}
+}
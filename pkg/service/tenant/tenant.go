@@ -0,0 +1,73 @@
+// Package tenant 按调用方所属账户限定可访问的数据范围
+package tenant
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"caty/pkg/code"
+	"caty/pkg/service/auth"
+	"caty/pkg/v"
+)
+
+// Scope 按调用方所属账户限定查询范围，仅全局管理员携带 all_tenants=true 时不限定
+// 管理员的权限向下继承，可访问所属账户及其全部下级账户
+func Scope(ctx context.Context) (func(*gorm.DB) *gorm.DB, error) {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return nil, errors.WithStack(code.ErrNoAuth)
+	}
+	if auth.AllTenants(ctx) {
+		if !auth.IsGlobalAdmin(token) {
+			return nil, errors.WithStack(code.ErrForbiddenAuth.WithResult("all_tenants requires global admin"))
+		}
+		return func(query *gorm.DB) *gorm.DB {
+			return query
+		}, nil
+	}
+	accountID := token.AccountID
+	if IsAdmin(token) {
+		return func(query *gorm.DB) *gorm.DB {
+			return query.Where("account_id IN (?)", Descendants(query, accountID))
+		}, nil
+	}
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Where("account_id =?", accountID)
+	}, nil
+}
+
+// Verify 校验调用方能否访问账户 accountID
+func Verify(ctx context.Context, tx *gorm.DB, accountID string) error {
+	token := auth.GetToken(ctx)
+	if token == nil {
+		return errors.WithStack(code.ErrNoAuth)
+	}
+	if token.AccountID == accountID || (auth.AllTenants(ctx) && auth.IsGlobalAdmin(token)) {
+		return nil
+	}
+	if IsAdmin(token) {
+		var count int64
+		if err := tx.Table("(?) AS t", Descendants(tx, token.AccountID)).Where("t.id =?", accountID).
+			Count(&count).Error; err != nil {
+			return errors.WithStack(code.ErrRetrieveAccount.WithResult(err))
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+	return errors.WithStack(code.ErrForbiddenAuth.WithResult("cross-tenant access is not allowed"))
+}
+
+// Descendants 账户 accountID 及其全部下级账户ID的子查询
+func Descendants(tx *gorm.DB, accountID interface{}) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Table("`account` AS d").Select("d.id").
+		Joins("JOIN `account` AS p ON d.path LIKE CONCAT(p.path, '%')").
+		Where("p.id =? AND p.deleted = 0 AND d.deleted = 0", accountID)
+}
+
+// IsAdmin 是否拥有本服务的管理员权限
+func IsAdmin(token *auth.Token) bool {
+	return auth.VerifyAuth(token.Permission, v.ServiceName, auth.Admin) == nil
+}
@@ -0,0 +1,62 @@
+package tracex
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"strings"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type traceIDKey struct{}
+
+// WithTraceID 指定在 ctx 中新建的根span使用的追踪ID，用于沿用 X-Trace-Id
+func WithTraceID(ctx context.Context, traceID trace.TraceID) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// ParseTraceID 解析 X-Trace-Id，兼容带连字符的UUID
+func ParseTraceID(value string) (trace.TraceID, bool) {
+	traceID, err := trace.TraceIDFromHex(strings.ReplaceAll(value, "-", ""))
+	if err != nil {
+		return trace.TraceID{}, false
+	}
+	return traceID, true
+}
+
+// idGenerator 优先使用 WithTraceID 指定的追踪ID，否则随机生成
+type idGenerator struct {
+	sync.Mutex
+	random *rand.Rand
+}
+
+// NewIDGenerator 创建支持 WithTraceID 的ID生成器
+func NewIDGenerator() sdktrace.IDGenerator {
+	var seed int64
+	_ = binary.Read(crand.Reader, binary.LittleEndian, &seed)
+	return &idGenerator{random: rand.New(rand.NewSource(seed))}
+}
+
+func (g *idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	g.Lock()
+	defer g.Unlock()
+	traceID, ok := ctx.Value(traceIDKey{}).(trace.TraceID)
+	if !ok || !traceID.IsValid() {
+		_, _ = g.random.Read(traceID[:])
+	}
+	spanID := trace.SpanID{}
+	_, _ = g.random.Read(spanID[:])
+	return traceID, spanID
+}
+
+func (g *idGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	g.Lock()
+	defer g.Unlock()
+	spanID := trace.SpanID{}
+	_, _ = g.random.Read(spanID[:])
+	return spanID
+}
@@ -0,0 +1,113 @@
+// Package tracex 基于OpenTelemetry的分布式追踪
+package tracex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"caty/pkg/v"
+)
+
+// 导出方式
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Tracer 服务的追踪器，未初始化时不记录span，但仍然传递上游的追踪上下文
+func Tracer() trace.Tracer {
+	return otel.Tracer(v.ServiceName)
+}
+
+// Init 按配置初始化追踪，始终启用W3C traceparent/tracestate与baggage传递，返回的函数用于刷新并关闭导出
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	exporter, err := newExporter(ctx, viper.GetString("trace.exporter"))
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+	ratio := 1.0
+	if viper.IsSet("trace.sample_ratio") {
+		ratio = viper.GetFloat64("trace.sample_ratio")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithIDGenerator(NewIDGenerator()),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(v.ServiceName),
+			semconv.ServiceVersionKey.String(v.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(viper.GetString("trace.endpoint"))}
+		if viper.GetBool("trace.insecure") {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		writer, err := openFile(viper.GetString("trace.path"))
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			_ = writer.Close()
+			return nil, err
+		}
+		return &fileExporter{SpanExporter: exporter, closer: writer}, nil
+	}
+	return nil, fmt.Errorf("unsupported trace exporter %s", name)
+}
+
+func openFile(path string) (*os.File, error) {
+	if path == "" {
+		return nil, fmt.Errorf("trace.path is required by %s exporter", ExporterFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
+
+// fileExporter 关闭导出时一并关闭文件
+type fileExporter struct {
+	sdktrace.SpanExporter
+	closer io.Closer
+}
+
+func (f *fileExporter) Shutdown(ctx context.Context) error {
+	if err := f.SpanExporter.Shutdown(ctx); err != nil {
+		return err
+	}
+	return f.closer.Close()
+}
@@ -0,0 +1,229 @@
+package grpcx
+
+import (
+	"context"
+
+	"github.com/crochee/lirity/e"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"caty/pkg/model"
+	"caty/pkg/pb"
+	"caty/pkg/service/account"
+)
+
+// accountServer 账户服务，参数校验与 api/v1/account 一致
+type accountServer struct {
+	pb.UnimplementedAccountServiceServer
+}
+
+func (accountServer) Create(ctx context.Context, request *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	createRequest := &account.CreateRequest{
+		Account:    request.GetAccount(),
+		AccountID:  request.GetAccountId(),
+		ParentID:   request.GetParentId(),
+		Email:      request.GetEmail(),
+		Password:   request.GetPassword(),
+		Desc:       request.GetDesc(),
+		Attributes: fromStruct(request.GetAttributes()),
+	}
+	if err := validate(createRequest); err != nil {
+		return nil, err
+	}
+	if err := account.ValidPassword(createRequest.Password); err != nil {
+		return nil, errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	response, err := account.Create(ctx, createRequest)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := toStruct(response.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateAccountResponse{
+		PrimaryAccount: response.PrimaryAccount,
+		Verify:         uint32(response.Verify),
+		AccountId:      response.AccountID,
+		ParentId:       response.ParentID,
+		Account:        response.Account,
+		UserId:         response.UserID,
+		Email:          response.Email,
+		Permission:     response.Permission,
+		Desc:           response.Desc,
+		Attributes:     attributes,
+		CreatedAt:      timestamppb.New(response.CreatedAt),
+		UpdatedAt:      timestamppb.New(response.UpdatedAt),
+		Warnings:       response.Warnings,
+	}, nil
+}
+
+func (accountServer) List(ctx context.Context, request *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	retrievesRequest := &account.RetrievesRequest{
+		Page: model.Page{
+			Index: request.GetIndex(),
+			Size:  int(request.GetSize()),
+		},
+		AccountID:          request.GetAccountId(),
+		ID:                 request.GetId(),
+		Account:            request.GetAccount(),
+		Email:              request.GetEmail(),
+		Attributes:         request.GetAttributes(),
+		Selector:           request.GetSelector(),
+		IncludeDescendants: request.GetIncludeDescendants(),
+	}
+	if err := validate(retrievesRequest); err != nil {
+		return nil, err
+	}
+	responses, err := account.List(ctx, retrievesRequest)
+	if err != nil {
+		return nil, err
+	}
+	result := &pb.ListAccountsResponse{
+		Index:  responses.Index,
+		Size:   int64(responses.Size),
+		Total:  int64(responses.Total),
+		Result: make([]*pb.Account, 0, len(responses.Result)),
+	}
+	for _, response := range responses.Result {
+		item, err := toAccount(response)
+		if err != nil {
+			return nil, err
+		}
+		result.Result = append(result.Result, item)
+	}
+	return result, nil
+}
+
+func (accountServer) Retrieve(ctx context.Context, request *pb.RetrieveAccountRequest) (*pb.Account, error) {
+	user := &account.User{ID: request.GetId()}
+	if err := validate(user); err != nil {
+		return nil, err
+	}
+	response, err := account.Retrieve(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return toAccount(response)
+}
+
+func (accountServer) Update(ctx context.Context, request *pb.UpdateAccountRequest) (*pb.UpdateAccountResponse, error) {
+	user := &account.User{ID: request.GetId()}
+	if err := validate(user); err != nil {
+		return nil, err
+	}
+	updateRequest := &account.UpdateRequest{
+		OldPassword: request.GetOldPassword(),
+		Account:     request.GetAccount(),
+		Email:       request.GetEmail(),
+		Password:    request.GetPassword(),
+		Permission:  request.GetPermission(),
+		Desc:        request.GetDesc(),
+		Attributes:  fromStruct(request.GetAttributes()),
+	}
+	if err := validate(updateRequest); err != nil {
+		return nil, err
+	}
+	if err := account.ValidPassword(updateRequest.Password); err != nil {
+		return nil, errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	if err := account.ValidPermission(updateRequest.Permission); err != nil {
+		return nil, errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	result, err := account.Update(ctx, user, &account.Precondition{IfMatch: request.GetIfMatch()}, updateRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateAccountResponse{Etag: result.ETag, Warnings: result.Warnings}, nil
+}
+
+func (accountServer) Delete(ctx context.Context, request *pb.DeleteAccountRequest) (*emptypb.Empty, error) {
+	user := &account.User{ID: request.GetId()}
+	if err := validate(user); err != nil {
+		return nil, err
+	}
+	precondition := &account.Precondition{IfMatch: request.GetIfMatch()}
+	var err error
+	if request.GetPurge() {
+		err = account.Purge(ctx, user, precondition)
+	} else {
+		err = account.Delete(ctx, user, precondition)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (accountServer) Login(ctx context.Context, request *pb.LoginRequest) (*pb.APIToken, error) {
+	loginRequest := &account.LoginRequest{
+		UserID:   request.GetUserId(),
+		Account:  request.GetAccount(),
+		Name:     request.GetName(),
+		Email:    request.GetEmail(),
+		Password: request.GetPassword(),
+	}
+	if err := validate(loginRequest); err != nil {
+		return nil, err
+	}
+	if err := account.ValidPassword(loginRequest.Password); err != nil {
+		return nil, errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	apiToken, err := account.Login(ctx, loginRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.APIToken{Token: apiToken.Token}, nil
+}
+
+// validate 按binding标签校验请求，与gin绑定参数时的校验一致
+func validate(request interface{}) error {
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	return nil
+}
+
+func toAccount(response *account.RetrieveResponse) (*pb.Account, error) {
+	attributes, err := toStruct(response.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Account{
+		Verify:       uint32(response.Verify),
+		AccountId:    response.AccountID,
+		Account:      response.Account,
+		UserId:       response.UserID,
+		Email:        response.Email,
+		Permission:   response.Permission,
+		Desc:         response.Desc,
+		Attributes:   attributes,
+		Labels:       response.Labels,
+		Version:      response.Version,
+		Status:       response.Status,
+		StatusReason: response.StatusReason,
+		CreatedAt:    timestamppb.New(response.CreatedAt),
+		UpdatedAt:    timestamppb.New(response.UpdatedAt),
+	}, nil
+}
+
+func fromStruct(attributes *structpb.Struct) map[string]interface{} {
+	if attributes == nil {
+		return nil
+	}
+	return attributes.AsMap()
+}
+
+func toStruct(attributes map[string]interface{}) (*structpb.Struct, error) {
+	if attributes == nil {
+		return nil, nil
+	}
+	result, err := structpb.NewStruct(attributes)
+	if err != nil {
+		return nil, errors.WithStack(e.ErrInternalServerError.WithResult(err))
+	}
+	return result, nil
+}
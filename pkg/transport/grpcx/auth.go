@@ -0,0 +1,64 @@
+package grpcx
+
+import (
+	"context"
+
+	"caty/pkg/pb"
+	"caty/pkg/service/auth"
+)
+
+// authServer 鉴权服务，参数校验与 api/v1/auth 一致
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+}
+
+func (authServer) Sign(ctx context.Context, request *pb.TokenClaims) (*pb.APIToken, error) {
+	claims := &auth.TokenClaims{
+		Now:       request.GetNow(),
+		ExpiresAt: request.GetExpiresAt(),
+	}
+	if token := request.GetToken(); token != nil {
+		claims.Token = &auth.Token{
+			AccountID:  token.GetAccountId(),
+			UserID:     token.GetUserId(),
+			Permission: make(map[string]uint8, len(token.GetPermission())),
+		}
+		for service, action := range token.GetPermission() {
+			claims.Token.Permission[service] = uint8(action)
+		}
+	}
+	if err := validate(claims); err != nil {
+		return nil, err
+	}
+	apiToken, err := auth.Sign(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.APIToken{Token: apiToken.Token}, nil
+}
+
+func (authServer) Parse(ctx context.Context, request *pb.APIToken) (*pb.TokenClaims, error) {
+	apiToken := &auth.APIToken{Token: request.GetToken()}
+	if err := validate(apiToken); err != nil {
+		return nil, err
+	}
+	claims, err := auth.Parse(ctx, apiToken)
+	if err != nil {
+		return nil, err
+	}
+	result := &pb.TokenClaims{
+		Now:       claims.Now,
+		ExpiresAt: claims.ExpiresAt,
+	}
+	if claims.Token != nil {
+		result.Token = &pb.Token{
+			AccountId:  claims.Token.AccountID,
+			UserId:     claims.Token.UserID,
+			Permission: make(map[string]uint32, len(claims.Token.Permission)),
+		}
+		for service, action := range claims.Token.Permission {
+			result.Token.Permission[service] = uint32(action)
+		}
+	}
+	return result, nil
+}
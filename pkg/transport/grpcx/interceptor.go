@@ -0,0 +1,414 @@
+package grpcx
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crochee/lirity/e"
+	"github.com/crochee/lirity/id"
+	"github.com/crochee/lirity/logger"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"caty/internal"
+	"caty/pkg/code"
+	"caty/pkg/i18n"
+	"caty/pkg/model"
+	"caty/pkg/service/auth"
+	"caty/pkg/service/idempotency"
+	"caty/pkg/service/ratelimit"
+	"caty/pkg/tracex"
+	"caty/pkg/v"
+)
+
+// 请求元数据，gRPC要求元数据的键为小写
+var (
+	MetadataTraceID    = strings.ToLower(v.XTraceID)
+	MetadataAuthToken  = strings.ToLower(v.XAuthToken)
+	MetadataAllTenants = "x-all-tenants"
+	// MetadataIdempotencyKey 携带幂等键的调用在保留期限内只处理一次
+	MetadataIdempotencyKey = strings.ToLower(v.XIdempotencyKey)
+	// MetadataAcceptLanguage 错误信息的语言
+	MetadataAcceptLanguage = strings.ToLower(i18n.AcceptLanguage)
+)
+
+// metadataCarrier 以gRPC元数据实现 propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	if values := metadata.MD(m).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func incoming(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return metadataCarrier(md).Get(key)
+}
+
+// TraceID 与 middleware.TraceID 一致，延续上游的追踪或以 x-trace-id 作为新追踪的ID，并为请求创建服务端span
+func TraceID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	tracedID := metadataCarrier(md).Get(MetadataTraceID)
+	if tracedID == "" {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			tracedID = spanContext.TraceID().String()
+		} else {
+			tracedID = id.UV4()
+		}
+	}
+	if traceID, ok := tracex.ParseTraceID(tracedID); ok {
+		ctx = tracex.WithTraceID(ctx, traceID)
+	}
+	service, method := path.Split(info.FullMethod)
+	ctx, span := tracex.Tracer().Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("grpc"),
+			semconv.RPCServiceKey.String(strings.Trim(service, "/")),
+			semconv.RPCMethodKey.String(method),
+		),
+	)
+	defer span.End()
+	if err := grpc.SetHeader(ctx, metadata.Pairs(MetadataTraceID, tracedID)); err != nil {
+		span.RecordError(err)
+	}
+
+	resp, err := handler(v.SetTraceID(ctx, tracedID), req)
+
+	statusCode := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int64(int64(statusCode)))
+	if statusCode != grpccodes.OK {
+		span.SetStatus(codes.Error, statusCode.String())
+	}
+	return resp, err
+}
+
+// RequestLogger 设置请求日志
+func RequestLogger(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		var fieldList []zap.Field
+		if traceID := v.GetTraceID(ctx); traceID != "" {
+			fieldList = append(fieldList, zap.String("trace_id", traceID))
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			fieldList = append(fieldList, zap.String("client_ip", p.Addr.String()))
+		}
+		return handler(logger.With(ctx, log.With(fieldList...)), req)
+	}
+}
+
+// Log 记录请求方法、状态码与耗时
+func Log(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logger.From(ctx).Sugar().Infof("[%s] %s | %s | %v", v.ServiceName, info.FullMethod, status.Code(err),
+		time.Since(start))
+	return resp, err
+}
+
+// Error 将业务错误转换为gRPC状态，业务错误码通过 errdetails.ErrorInfo 返回
+func Error(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return resp, err
+	}
+	return resp, Status(ctx, err).Err()
+}
+
+// Status 与 code.Error 一致，将错误转换为gRPC状态，错误信息按元数据 accept-language 翻译
+func Status(ctx context.Context, err error) *status.Status {
+	var errorCode e.ErrorCode
+	if !errors.As(err, &errorCode) {
+		logger.From(ctx).Sugar().Errorf("%+v", err)
+		errorCode = e.ErrInternalServerError.WithResult(err.Error())
+	} else if errorCode.StatusCode() >= http.StatusInternalServerError {
+		logger.From(ctx).Sugar().Errorf("%+v", err)
+	}
+	errorCode = code.Translate(i18n.Match(incoming(ctx, MetadataAcceptLanguage)), errorCode)
+	s := status.New(grpcCode(errorCode.StatusCode()), errorCode.Message())
+	info := &errdetails.ErrorInfo{
+		Reason: strconv.Itoa(errorCode.Code()),
+		Domain: v.ServiceName,
+	}
+	details := []proto.Message{info}
+	switch result := errorCode.Result().(type) {
+	case nil:
+	case map[string]string:
+		// 参数校验错误按字段返回
+		fields := make([]string, 0, len(result))
+		for field := range result {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: result[field],
+			})
+		}
+		details = append(details, badRequest)
+	default:
+		info.Metadata = map[string]string{"result": fmt.Sprint(result)}
+	}
+	if detailed, detailErr := s.WithDetails(details...); detailErr == nil {
+		return detailed
+	}
+	return s
+}
+
+// grpcCode 将http状态码转换为gRPC状态码
+func grpcCode(statusCode int) grpccodes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return grpccodes.InvalidArgument
+	case http.StatusUnauthorized:
+		return grpccodes.Unauthenticated
+	case http.StatusForbidden:
+		return grpccodes.PermissionDenied
+	case http.StatusNotFound:
+		return grpccodes.NotFound
+	case http.StatusConflict:
+		return grpccodes.AlreadyExists
+	case http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		return grpccodes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return grpccodes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return grpccodes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return grpccodes.Unavailable
+	case http.StatusGatewayTimeout:
+		return grpccodes.DeadlineExceeded
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return grpccodes.Internal
+	}
+	return grpccodes.FailedPrecondition
+}
+
+// Recovery 捕获panic并返回内部错误
+func Recovery(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.From(ctx).Sugar().Errorf("[Recovery] %s\n%v\n%s", info.FullMethod, r, internal.Stack(3))
+			err = e.ErrInternalServerError.WithResult(fmt.Sprint(r))
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// Token 与 middleware.Token 一致，解析 x-auth-token 并将token加入上下文，x-all-tenants 为true时请求跨租户访问
+func Token(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	apiToken := incoming(ctx, MetadataAuthToken)
+	if apiToken == "" {
+		return handler(ctx, req)
+	}
+	claims, err := auth.Parse(ctx, &auth.APIToken{Token: apiToken})
+	if err != nil {
+		return nil, err
+	}
+	ctx = auth.SetToken(ctx, claims.Token)
+	if allTenants, _ := strconv.ParseBool(incoming(ctx, MetadataAllTenants)); allTenants {
+		ctx = auth.SetAllTenants(ctx, true)
+	}
+	return handler(ctx, req)
+}
+
+// methodGRPC 幂等记录与限流规则中gRPC调用的请求方法，gRPC调用均以POST请求承载
+const methodGRPC = http.MethodPost
+
+// clientIP 客户端IP，取自连接的对端地址
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// RateLimit 与 middleware.RateLimit 一致，规则的路由模板匹配gRPC的完整方法名，如 /caty.v1.AuthService/*
+// 按客户端IP限流的拦截器须在 Token 之前使用，按用户限流的拦截器须在 Token 之后使用
+func RateLimit() (byIP, byUser grpc.UnaryServerInterceptor) {
+	if !ratelimit.Enabled() {
+		next := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}
+		return next, next
+	}
+	store, ipRules, userRules, err := ratelimit.Load()
+	if err != nil {
+		panic(err)
+	}
+	return NewRateLimit(store, ipRules), NewRateLimit(store, userRules)
+}
+
+// NewRateLimit 使用指定的存储与规则限流，限流信息通过响应头元数据返回
+func NewRateLimit(store ratelimit.Store, rules []*ratelimit.Rule) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		report := ratelimit.Apply(ctx, store, rules, methodGRPC, info.FullMethod, func(key string) string {
+			if key == ratelimit.KeyUser {
+				if token := auth.GetToken(ctx); token != nil {
+					return "user:" + token.UserID
+				}
+			}
+			return "ip:" + clientIP(ctx)
+		})
+		if report == nil {
+			return handler(ctx, req)
+		}
+		md := metadata.Pairs(
+			strings.ToLower(v.XRateLimitLimit), strconv.Itoa(report.Limit),
+			strings.ToLower(v.XRateLimitRemaining), strconv.Itoa(report.Remaining),
+			strings.ToLower(v.XRateLimitReset), ratelimit.CeilSeconds(report.Reset),
+		)
+		if !report.Allowed {
+			md.Set(strings.ToLower(v.XRetryAfter), ratelimit.CeilSeconds(report.RetryAfter))
+		}
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			logger.From(ctx).Sugar().Errorf("%+v", err)
+		}
+		if !report.Allowed {
+			return nil, errors.WithStack(code.ErrRateLimited)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Idempotency 与 middleware.Idempotency 一致，携带 idempotency-key 的调用在保留期限内只处理一次，
+// 重复调用返回首次的响应，调用失败时不保存，允许使用相同的幂等键重试
+func Idempotency(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+	key := incoming(ctx, MetadataIdempotencyKey)
+	if key == "" {
+		return handler(ctx, req)
+	}
+	if len(key) > idempotency.MaxKeyLength {
+		return nil, errors.WithStack(e.ErrInvalidParam.WithResult(
+			fmt.Sprintf("%s's length is more than %d", MetadataIdempotencyKey, idempotency.MaxKeyLength)))
+	}
+	message, ok := req.(protov2.Message)
+	if !ok {
+		return handler(ctx, req)
+	}
+	body, err := protov2.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return nil, errors.WithStack(e.ErrInvalidParam.WithResult(err))
+	}
+	record, replay, err := idempotency.Begin(ctx, idempotency.Caller(ctx, clientIP(ctx)), key, methodGRPC,
+		info.FullMethod, idempotency.Fingerprint(methodGRPC, info.FullMethod, body))
+	if err != nil {
+		return nil, err
+	}
+	if replay {
+		if err = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(v.XIdempotencyReplayed), "true")); err != nil {
+			logger.From(ctx).Sugar().Errorf("%+v", err)
+		}
+		return replayMessage(record)
+	}
+	release := func() {
+		if err := idempotency.Release(ctx, record); err != nil {
+			logger.From(ctx).Sugar().Errorf("%+v", err)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			release()
+			panic(r)
+		}
+	}()
+	if resp, err = handler(ctx, req); err != nil {
+		release()
+		return resp, err
+	}
+	result, ok := resp.(protov2.Message)
+	if !ok {
+		release()
+		return resp, nil
+	}
+	var data []byte
+	if data, err = protov2.Marshal(result); err != nil {
+		release()
+		return resp, nil
+	}
+	header := http.Header{}
+	header.Set("Content-Type", mime.FormatMediaType(protoContentType, map[string]string{
+		"messagetype": string(result.ProtoReflect().Descriptor().FullName()),
+	}))
+	if err = idempotency.Complete(ctx, record, http.StatusOK, header, data); err != nil {
+		logger.From(ctx).Sugar().Errorf("%+v", err)
+	}
+	return resp, nil
+}
+
+// protoContentType 幂等记录中gRPC响应的类型，messagetype参数为响应消息的完整名称
+const protoContentType = "application/x-protobuf"
+
+// replayMessage 由幂等记录还原首次调用的响应
+func replayMessage(record *model.Idempotency) (interface{}, error) {
+	mediaType, params, err := mime.ParseMediaType(record.ContentType)
+	if err != nil || mediaType != protoContentType {
+		return nil, errors.WithStack(code.ErrIdempotency.WithResult(record.ContentType))
+	}
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(params["messagetype"]))
+	if err != nil {
+		return nil, errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	message := messageType.New().Interface()
+	if err = protov2.Unmarshal(record.Body, message); err != nil {
+		return nil, errors.WithStack(code.ErrIdempotency.WithResult(err))
+	}
+	return message, nil
+}
@@ -0,0 +1,150 @@
+// Package grpcx 提供与 httpx 并行的gRPC服务
+package grpcx
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+	"time"
+
+	"github.com/crochee/lirity/logger"
+	"github.com/crochee/lirity/registry"
+	"github.com/crochee/lirity/registry/etcd"
+	"github.com/crochee/lirity/routine"
+	"github.com/crochee/uid"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"caty/pkg/netx"
+	"caty/pkg/pb"
+	"caty/pkg/v"
+)
+
+// ServiceName 注册到etcd的服务名，与http服务区分，避免按服务名发现http服务时得到gRPC地址
+const ServiceName = v.ServiceName + "-grpc"
+
+// Enabled 是否启用gRPC服务
+func Enabled() bool {
+	return viper.GetBool("grpc.enable")
+}
+
+func NewServer() (*GRPCServer, error) {
+	r, err := etcd.NewRegistry(func(option *etcd.Option) {
+		option.AddrList = viper.GetStringSlice("etcd.url")
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := netx.Load("grpc", ":8121")
+	if err != nil {
+		return nil, err
+	}
+	uri := &url.URL{
+		Scheme:   "grpc",
+		RawQuery: "isSecure=false",
+	}
+	if uri.Host, err = cfg.Endpoint(); err != nil {
+		return nil, err
+	}
+	options := []grpc.ServerOption{
+		Interceptor(logger.New(
+			logger.WithLevel(viper.GetString("level")),
+			logger.WithWriter(logger.SetWriter(viper.GetString("path"))))),
+	}
+	var tlsConfig *tls.Config
+	if tlsConfig, err = cfg.TLSConfig(); err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		uri.RawQuery = "isSecure=true"
+	}
+	srv := &GRPCServer{
+		Server: grpc.NewServer(options...),
+		Listen: cfg.Listen,
+		Instance: &registry.ServiceInstance{
+			ID:        uid.New().String(),
+			Name:      ServiceName,
+			Version:   v.Version,
+			Endpoints: []string{uri.String()},
+		},
+		Registrar: r,
+	}
+	pb.RegisterAccountServiceServer(srv.Server, accountServer{})
+	pb.RegisterAuthServiceServer(srv.Server, authServer{})
+	return srv, nil
+}
+
+// Interceptor 追踪、日志、错误转换、panic恢复、限流、鉴权与幂等拦截器，顺序与gin中间件一致
+func Interceptor(log *zap.Logger) grpc.ServerOption {
+	limitIP, limitUser := RateLimit()
+	return grpc.ChainUnaryInterceptor(
+		TraceID,
+		RequestLogger(log),
+		Log,
+		Error,
+		Recovery,
+		limitIP,
+		Token,
+		limitUser,
+		Idempotency,
+	)
+}
+
+type GRPCServer struct {
+	Server *grpc.Server
+	// Listen 监听地址，支持tcp与unix socket
+	Listen    []string
+	Instance  *registry.ServiceInstance
+	Registrar registry.Registrar
+}
+
+func (s *GRPCServer) Start(ctx context.Context) error {
+	// 先监听再注册，避免注册后端口不可用
+	listeners, err := netx.Listeners(s.Listen)
+	if err != nil {
+		return err
+	}
+	if s.Registrar != nil {
+		if err = s.Registrar.Register(ctx, s.Instance); err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+			return err
+		}
+	}
+	g := routine.NewGroup(ctx)
+	for _, listener := range listeners {
+		listener := listener
+		g.Go(func(context.Context) error {
+			return s.Server.Serve(listener)
+		})
+	}
+	return g.Wait()
+}
+
+var DefaultStopTime = 10 * time.Second
+
+// Stop 注销服务后优雅关闭，超时后强制关闭
+func (s *GRPCServer) Stop(ctx context.Context) error {
+	if s.Registrar != nil {
+		if err := s.Registrar.Deregister(ctx, s.Instance); err != nil {
+			return err
+		}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		s.Server.GracefulStop()
+		close(stopped)
+	}()
+	timer := time.NewTimer(DefaultStopTime)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		s.Server.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package grpcx
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/crochee/lirity/db"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	protov2 "google.golang.org/protobuf/proto"
+
+	"caty/pkg/code"
+	"caty/pkg/pb"
+	"caty/pkg/service/idempotency"
+)
+
+func dial(t *testing.T) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(Interceptor(zap.NewNop()))
+	pb.RegisterAuthServiceServer(server, authServer{})
+	pb.RegisterAccountServiceServer(server, accountServer{})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func TestAuthService(t *testing.T) {
+	if err := code.Loading(); err != nil {
+		t.Fatal(err)
+	}
+	client := pb.NewAuthServiceClient(dial(t))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataTraceID, "trace-test")
+
+	// 未登录时不能签发token，业务错误码通过ErrorInfo返回
+	var header metadata.MD
+	_, err := client.Sign(ctx, &pb.TokenClaims{Token: &pb.Token{AccountId: "1", UserId: "1",
+		Permission: map[string]uint32{"caty": 1}}}, grpc.Header(&header))
+	s := status.Convert(err)
+	if s.Code() != codes.PermissionDenied {
+		t.Fatalf("unexpected status %v", s)
+	}
+	if len(s.Details()) != 1 || s.Details()[0].(*errdetails.ErrorInfo).Reason !=
+		strconv.Itoa(code.ErrForbiddenAuth.Code()) {
+		t.Fatalf("unexpected details %v", s.Details())
+	}
+	if traceID := header.Get(MetadataTraceID); len(traceID) != 1 || traceID[0] != "trace-test" {
+		t.Fatalf("unexpected header %v", header)
+	}
+
+	// 参数校验与http接口一致
+	if _, err = client.Parse(ctx, &pb.APIToken{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// 无法解析的token
+	ctx = metadata.AppendToOutgoingContext(ctx, MetadataAuthToken, "invalid")
+	if _, err = client.Parse(ctx, &pb.APIToken{Token: "invalid"}); status.Code(err) == codes.OK {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	_, err := Error(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return Recovery(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/test"},
+				func(context.Context, interface{}) (interface{}, error) {
+					panic("test")
+				})
+		})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	if err := code.Loading(); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("rate_limit.enable", true)
+	viper.Set("rate_limit.rules", []map[string]interface{}{
+		{"route": "/caty.v1.AuthService/*", "key": "ip", "requests": 1, "period": time.Minute},
+	})
+	defer func() {
+		viper.Set("rate_limit.enable", false)
+		viper.Set("rate_limit.rules", nil)
+	}()
+	client := pb.NewAuthServiceClient(dial(t))
+	// 按IP限流在校验token之前，无法解析的token同样计入
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataAuthToken, "invalid")
+	var header metadata.MD
+	_, err := client.Parse(ctx, &pb.APIToken{Token: "invalid"}, grpc.Header(&header))
+	if status.Code(err) == codes.OK || status.Code(err) == codes.ResourceExhausted {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if remaining := header.Get("ratelimit-remaining"); len(remaining) != 1 || remaining[0] != "0" {
+		t.Fatalf("unexpected header %v", header)
+	}
+	_, err = client.Parse(ctx, &pb.APIToken{Token: "invalid"}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if retryAfter := header.Get("retry-after"); len(retryAfter) != 1 || retryAfter[0] != "60" {
+		t.Fatalf("unexpected header %v", header)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	mock, err := db.Mock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const method = "/caty.v1.AccountService/Login"
+	request := &pb.LoginRequest{UserId: "10", Password: "password"}
+	body, err := protov2.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := regexp.QuoteMeta("SELECT * FROM `idempotency` WHERE caller =? AND idempotency_key =? AND method =? AND path =?")
+	columns := []string{"id", "caller", "idempotency_key", "method", "path", "fingerprint", "status",
+		"content_type", "headers", "body", "expired_at", "created_at"}
+	var handled int
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		handled++
+		return &pb.APIToken{Token: "token"}, nil
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataIdempotencyKey, "key"))
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	// 首次调用登记并保存响应
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WithArgs("ip:", "key", "POST", method).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `idempotency`")).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `idempotency` SET")).
+		WithArgs(sqlmock.AnyArg(), "application/x-protobuf; messagetype=caty.v1.APIToken", sqlmock.AnyArg(),
+			200, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if _, err = Idempotency(ctx, request, info, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复调用返回首次的响应
+	data, err := protov2.Marshal(&pb.APIToken{Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "ip:", "key", "POST", method,
+		idempotency.Fingerprint("POST", method, body), 200, "application/x-protobuf; messagetype=caty.v1.APIToken",
+		"{}", data, now.Add(time.Hour), now))
+	mock.ExpectCommit()
+	resp, err := Idempotency(ctx, request, info, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, ok := resp.(*pb.APIToken); !ok || token.Token != "token" {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	if handled != 1 {
+		t.Fatalf("handler called %d times", handled)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -7,7 +7,6 @@ package httpx
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
 	"net"
 	"net/http"
 	"net/url"
@@ -15,14 +14,14 @@ import (
 
 	"github.com/crochee/lirity/registry"
 	"github.com/crochee/lirity/registry/etcd"
+	"github.com/crochee/lirity/routine"
 	"github.com/crochee/uid"
-	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
-	"go.uber.org/zap"
 
-	"caty/internal/host"
+	"caty/pkg/health"
+	"caty/pkg/metrics"
+	"caty/pkg/netx"
 	"caty/pkg/router"
-	"caty/pkg/tlsx"
 	"caty/pkg/v"
 )
 
@@ -33,71 +32,97 @@ func NewServer(ctx context.Context) (*HTTPServer, error) {
 	if err != nil {
 		return nil, err
 	}
-	var ip string
-	if gin.Mode() == gin.ReleaseMode {
-		if ip, err = createHost("eth0"); err != nil {
-			ip = "0.0.0.0"
-		}
+	// 能从etcd查询到本服务的注册信息即认为etcd可用
+	health.Register("etcd", health.CheckerFunc(func(ctx context.Context) error {
+		_, err := r.GetService(ctx, v.ServiceName)
+		return err
+	}))
+	cfg, err := netx.Load("http", ":8120")
+	if err != nil {
+		return nil, err
+	}
+	var tlsConfig *tls.Config
+	if tlsConfig, err = cfg.TLSConfig(); err != nil {
+		return nil, err
+	}
+	uri := &url.URL{Scheme: "http"}
+	if tlsConfig != nil {
+		uri.Scheme = "https"
+	}
+	if uri.Host, err = cfg.Endpoint(); err != nil {
+		return nil, err
 	}
 	srv := &HTTPServer{
 		Server: &http.Server{
-			Handler: router.New(),
+			Handler:   router.New(),
+			TLSConfig: tlsConfig,
 			BaseContext: func(_ net.Listener) context.Context {
 				return ctx
 			},
 		},
+		Listen: cfg.Listen,
 		Instance: &registry.ServiceInstance{
-			ID:      uid.New().String(),
-			Name:    v.ServiceName,
-			Version: v.Version,
+			ID:        uid.New().String(),
+			Name:      v.ServiceName,
+			Version:   v.Version,
+			Endpoints: []string{uri.String()},
 		},
 		Registrar: r,
 	}
-	var (
-		cfg *tls.Config
-		uri = &url.URL{
-			Scheme: "https",
-			Host:   fmt.Sprintf("%s:8120", ip),
+	if addr := metrics.Addr(); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		srv.Admin = &http.Server{
+			Addr:    addr,
+			Handler: mux,
 		}
-	)
-	if cfg, err = tlsx.TLSConfig(tls.RequireAndVerifyClientCert, tlsx.Config{
-		Ca:   "ca.pem",
-		Cert: "server.pem",
-		Key:  "server-key.pem",
-	}); err != nil {
-		uri.Scheme = "http"
-		zap.L().Warn(err.Error())
 	}
-	srv.Server.TLSConfig = cfg
-	srv.Server.Addr = uri.Host
-	srv.Instance.Endpoints = []string{uri.String()}
 	return srv, nil
 }
 
-func createHost(name string) (string, error) {
-	ip, err := host.GetIPByName(name)
-	if err == nil {
-		return ip.String(), nil
-	}
-	if ip, err = host.ExternalIP(); err != nil {
-		return "", err
-	}
-	return ip.String(), nil
-}
-
 type HTTPServer struct {
-	Server    *http.Server
+	Server *http.Server
+	// Listen 监听地址，支持tcp与unix socket
+	Listen []string
+	// Admin 独立的管理端口，未配置 metrics.addr 时为空
+	Admin     *http.Server
 	Instance  *registry.ServiceInstance
 	Registrar registry.Registrar
 }
 
 func (s *HTTPServer) Start(ctx context.Context) error {
+	// 先监听再注册，避免注册后端口不可用
+	listeners, err := netx.Listeners(s.Listen)
+	if err != nil {
+		return err
+	}
 	if s.Registrar != nil {
-		if err := s.Registrar.Register(ctx, s.Instance); err != nil {
+		if err = s.Registrar.Register(ctx, s.Instance); err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
 			return err
 		}
 	}
-	return s.Server.ListenAndServe()
+	g := routine.NewGroup(ctx)
+	for _, listener := range listeners {
+		listener := listener
+		g.Go(func(context.Context) error {
+			if s.Server.TLSConfig != nil {
+				return s.Server.ServeTLS(listener, "", "")
+			}
+			return s.Server.Serve(listener)
+		})
+	}
+	return g.Wait()
+}
+
+// StartAdmin 启动管理端口，未配置时直接返回
+func (s *HTTPServer) StartAdmin(context.Context) error {
+	if s.Admin == nil {
+		return nil
+	}
+	return s.Admin.ListenAndServe()
 }
 
 var DefaultStopTime = 10 * time.Second
@@ -110,5 +135,11 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 	}
 	newCtx, cancel := context.WithTimeout(ctx, DefaultStopTime)
 	defer cancel()
-	return s.Server.Shutdown(newCtx)
+	if err := s.Server.Shutdown(newCtx); err != nil {
+		return err
+	}
+	if s.Admin != nil {
+		return s.Admin.Shutdown(newCtx)
+	}
+	return nil
 }
@@ -36,3 +36,35 @@ func GetHost(ctx context.Context) string {
 	}
 	return host
 }
+
+type authTokenKey struct{}
+
+// SetAuthToken Add X-Auth-Token to context.Context.
+func SetAuthToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, authTokenKey{}, token)
+}
+
+// GetAuthToken Get the X-Auth-Token from context.Context.
+func GetAuthToken(ctx context.Context) string {
+	token, ok := ctx.Value(authTokenKey{}).(string)
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+type idempotencyKey struct{}
+
+// SetIdempotencyKey Add idempotency key to context.Context.
+func SetIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// GetIdempotencyKey Get the idempotency key from context.Context.
+func GetIdempotencyKey(ctx context.Context) string {
+	key, ok := ctx.Value(idempotencyKey{}).(string)
+	if !ok {
+		return ""
+	}
+	return key
+}
@@ -8,7 +8,16 @@ const (
 	ServiceName = "caty"
 	Version     = "v1.0.0"
 
-	XTraceID = "X-Trace-Id"
+	XTraceID   = "X-Trace-Id"
+	XAuthToken = "X-Auth-Token"
+
+	XIdempotencyKey      = "Idempotency-Key"
+	XIdempotencyReplayed = "Idempotency-Replayed"
+
+	XRateLimitLimit     = "RateLimit-Limit"
+	XRateLimitRemaining = "RateLimit-Remaining"
+	XRateLimitReset     = "RateLimit-Reset"
+	XRetryAfter         = "Retry-After"
 
 	V1API = "v1"
 
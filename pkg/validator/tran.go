@@ -1,16 +1,150 @@
 package validator
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/crochee/lirity/validator"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	playground "github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+	"github.com/pkg/errors"
+
+	"caty/pkg/i18n"
 )
 
+// translators 各语言的校验错误翻译器
+var translators = map[string]ut.Translator{}
+
 // Init init validator
 func Init() error {
-	v, err := validator.New()
-	if err != nil {
+	v := &defaultValidator{Validate: playground.New()}
+	v.Validate.SetTagName("binding")
+	// 校验错误中使用请求参数的名称而非结构体字段名
+	v.Validate.RegisterTagNameFunc(fieldName)
+	uni := ut.New(zh.New(), zh.New(), en.New())
+	zhTranslator, _ := uni.GetTranslator(i18n.ZH)
+	if err := zhtranslations.RegisterDefaultTranslations(v.Validate, zhTranslator); err != nil {
+		return err
+	}
+	enTranslator, _ := uni.GetTranslator(i18n.EN)
+	if err := entranslations.RegisterDefaultTranslations(v.Validate, enTranslator); err != nil {
+		return err
+	}
+	if err := validator.RegisterValidation(v, "sort", validator.Sort); err != nil {
 		return err
 	}
+	for translator, text := range map[ut.Translator]string{
+		zhTranslator: "{0}必须是有效的排序字段",
+		enTranslator: "{0} must be a valid sort expression",
+	} {
+		if err := registerTranslation(v.Validate, translator, "sort", text); err != nil {
+			return err
+		}
+	}
+	translators = map[string]ut.Translator{
+		i18n.ZH: zhTranslator,
+		i18n.EN: enTranslator,
+	}
 	binding.Validator = v
-	return validator.RegisterValidation(binding.Validator, "sort", validator.Sort)
+	return nil
+}
+
+func registerTranslation(v *playground.Validate, translator ut.Translator, tag, text string) error {
+	return v.RegisterTranslation(tag, translator, func(translator ut.Translator) error {
+		return translator.Add(tag, text, true)
+	}, func(translator ut.Translator, fe playground.FieldError) string {
+		message, err := translator.T(fe.Tag(), fe.Field())
+		if err != nil {
+			return fe.Error()
+		}
+		return message
+	})
+}
+
+// fieldName 依次使用json、form、uri、header标签作为字段名
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri", "header"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Translate 将校验错误翻译为语言 locale 下各字段的错误信息，err 不是校验错误时返回false
+func Translate(locale string, err error) (map[string]string, bool) {
+	var vErrs playground.ValidationErrors
+	if !errors.As(err, &vErrs) {
+		return nil, false
+	}
+	translator, ok := translators[locale]
+	if !ok {
+		translator = translators[i18n.Default]
+	}
+	result := make(map[string]string, len(vErrs))
+	for _, fe := range vErrs {
+		// 去掉命名空间中的结构体名
+		field := fe.Namespace()
+		if index := strings.IndexByte(field, '.'); index >= 0 {
+			field = field[index+1:]
+		}
+		if translator == nil {
+			result[field] = fe.Error()
+			continue
+		}
+		result[field] = fe.Translate(translator)
+	}
+	return result, true
+}
+
+// defaultValidator 与 lirity 的校验器一致，但不在校验时翻译错误，由响应时按请求语言翻译
+type defaultValidator struct {
+	Validate *playground.Validate
+}
+
+// ValidateStruct receives any kind of type, but only performed struct or pointer to struct type.
+func (v *defaultValidator) ValidateStruct(obj interface{}) error {
+	if obj == nil {
+		return nil
+	}
+	value := reflect.ValueOf(obj)
+	switch value.Kind() { // nolint:exhaustive
+	case reflect.Ptr:
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return v.Validate.Struct(obj)
+	case reflect.Slice, reflect.Array:
+		var vErrs playground.ValidationErrors
+		for i := 0; i < value.Len(); i++ {
+			err := v.ValidateStruct(value.Index(i).Interface())
+			if err == nil {
+				continue
+			}
+			var itemErrs playground.ValidationErrors
+			if !errors.As(err, &itemErrs) {
+				return err
+			}
+			vErrs = append(vErrs, itemErrs...)
+		}
+		if len(vErrs) == 0 {
+			return nil
+		}
+		return vErrs
+	default:
+		return nil
+	}
+}
+
+// Engine returns the underlying validator engine.
+func (v *defaultValidator) Engine() interface{} {
+	return v.Validate
 }